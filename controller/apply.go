@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// StatusApplier knows how to patch objects, the Kubernetes dynamic client resources
+// (`dynamic.ResourceInterface`) satisfy it.
+type StatusApplier interface {
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error)
+}
+
+// ApplyStatus applies the status of an object using server-side apply with the field manager.
+// The apply configuration can be anything that serializes to a partial object with the
+// `apiVersion`, `kind`, `metadata.name` and the status fields owned by the field manager (e.g
+// generated apply configurations or `unstructured.Unstructured`).
+//
+// If force is set the conflicts with other field managers will be resolved taking the
+// ownership of the fields, otherwise the apply will fail on conflicts.
+func ApplyStatus(ctx context.Context, client StatusApplier, applyConfig interface{}, fieldManager string, force bool) (*unstructured.Unstructured, error) {
+	if fieldManager == "" {
+		return nil, fmt.Errorf("field manager is required")
+	}
+
+	data, err := json.Marshal(applyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal apply configuration: %w", err)
+	}
+
+	var obj struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apply configuration: %w", err)
+	}
+
+	switch {
+	case obj.APIVersion == "" || obj.Kind == "":
+		return nil, fmt.Errorf("apply configuration apiVersion and kind are required")
+	case obj.Metadata.Name == "":
+		return nil, fmt.Errorf("apply configuration name is required")
+	}
+
+	res, err := client.Patch(ctx, obj.Metadata.Name, types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	}, "status")
+	if err != nil {
+		return nil, fmt.Errorf("could not apply %q status: %w", obj.Metadata.Name, err)
+	}
+
+	return res, nil
+}
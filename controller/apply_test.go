@@ -0,0 +1,126 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// fakeStatusApplier stores the received patch.
+type fakeStatusApplier struct {
+	name         string
+	patchType    types.PatchType
+	data         string
+	options      metav1.PatchOptions
+	subresources []string
+	err          error
+}
+
+func (f *fakeStatusApplier) Patch(_ context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	f.name, f.patchType, f.data, f.options, f.subresources = name, pt, string(data), options, subresources
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &unstructured.Unstructured{}, nil
+}
+
+// The Kubernetes dynamic client should be able to apply status.
+var _ controller.StatusApplier = dynamic.ResourceInterface(nil)
+
+// testApplyConfig is like a generated apply configuration, only set fields are serialized.
+type testApplyConfig struct {
+	APIVersion *string `json:"apiVersion,omitempty"`
+	Kind       *string `json:"kind,omitempty"`
+	Metadata   *struct {
+		Name *string `json:"name,omitempty"`
+	} `json:"metadata,omitempty"`
+	Status *struct {
+		Phase *string `json:"phase,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+func newTestApplyConfig(name, phase string) testApplyConfig {
+	apiVersion, kind := "v1", "Namespace"
+	c := testApplyConfig{APIVersion: &apiVersion, Kind: &kind}
+	c.Metadata = &struct {
+		Name *string `json:"name,omitempty"`
+	}{Name: &name}
+	c.Status = &struct {
+		Phase *string `json:"phase,omitempty"`
+	}{Phase: &phase}
+	return c
+}
+
+func TestApplyStatus(t *testing.T) {
+	tests := map[string]struct {
+		applyConfig  interface{}
+		fieldManager string
+		force        bool
+		patchErr     error
+		expErr       bool
+		expData      string
+	}{
+		"Applying the status should issue a server-side apply patch on the status with the field manager.": {
+			applyConfig:  newTestApplyConfig("test", "Active"),
+			fieldManager: "my-controller",
+			expData:      `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"},"status":{"phase":"Active"}}`,
+		},
+
+		"Applying the status with force should issue a forced server-side apply patch.": {
+			applyConfig:  newTestApplyConfig("test", "Active"),
+			fieldManager: "my-controller",
+			force:        true,
+			expData:      `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"},"status":{"phase":"Active"}}`,
+		},
+
+		"Applying the status without field manager should fail.": {
+			applyConfig: newTestApplyConfig("test", "Active"),
+			expErr:      true,
+		},
+
+		"Applying the status without name should fail.": {
+			applyConfig:  newTestApplyConfig("", "Active"),
+			fieldManager: "my-controller",
+			expErr:       true,
+		},
+
+		"Applying the status with a patch error should fail.": {
+			applyConfig:  newTestApplyConfig("test", "Active"),
+			fieldManager: "my-controller",
+			patchErr:     fmt.Errorf("conflict"),
+			expErr:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &fakeStatusApplier{err: test.patchErr}
+			_, err := controller.ApplyStatus(context.TODO(), client, test.applyConfig, test.fieldManager, test.force)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			if assert.NoError(err) {
+				assert.Equal("test", client.name)
+				assert.Equal(types.ApplyPatchType, client.patchType)
+				assert.JSONEq(test.expData, client.data)
+				assert.Equal(test.fieldManager, client.options.FieldManager)
+				if assert.NotNil(client.options.Force) {
+					assert.Equal(test.force, *client.options.Force)
+				}
+				assert.Equal([]string{"status"}, client.subresources)
+			}
+		})
+	}
+}
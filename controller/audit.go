@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AuditRecord is the structured audit record of a mutation performed by a handler.
+type AuditRecord struct {
+	// Controller is the name of the controller that performed the mutation.
+	Controller string
+	// Key is the key of the object being handled when the mutation was performed.
+	Key string
+	// Leader is the leader election identity of the controller, empty without leader election.
+	Leader string
+	// TraceID is the trace ID of the handling, empty if the handling is not traced.
+	TraceID string
+	// Action is the mutation performed (e.g create, update, delete).
+	Action string
+	// Object is the reference to the mutated object.
+	Object corev1.ObjectReference
+	// Time is when the mutation was performed.
+	Time time.Time
+}
+
+// AuditSink knows how to store the audit records (e.g logs, an external audit system).
+type AuditSink interface {
+	// Audit stores an audit record. It's called synchronously by the handlers, it should not block.
+	Audit(ctx context.Context, r AuditRecord)
+}
+
+// AuditSinkFunc is a helper to create an AuditSink from a function.
+type AuditSinkFunc func(ctx context.Context, r AuditRecord)
+
+// Audit satisfies AuditSink interface.
+func (a AuditSinkFunc) Audit(ctx context.Context, r AuditRecord) {
+	a(ctx, r)
+}
+
+type auditCtxKey struct{}
+
+// auditContext is the handling context of the audit records.
+type auditContext struct {
+	sink       AuditSink
+	tracer     Tracer
+	controller string
+	key        string
+	leader     string
+}
+
+// Audit records a mutation performed by the handler on an object, the controller completes the
+// record with the handling context (controller, object key, leader identity and trace ID) and
+// sends it to the `Config.AuditSink`. It's safe to call it when the audit is disabled.
+func Audit(ctx context.Context, action string, objRef corev1.ObjectReference) {
+	ac, ok := ctx.Value(auditCtxKey{}).(*auditContext)
+	if !ok {
+		return
+	}
+
+	r := AuditRecord{
+		Controller: ac.controller,
+		Key:        ac.key,
+		Leader:     ac.leader,
+		Action:     action,
+		Object:     objRef,
+		Time:       time.Now(),
+		TraceID:    ac.tracer.TraceID(ctx),
+	}
+
+	ac.sink.Audit(ctx, r)
+}
+
+// newAuditProcessor returns a processor that sets the audit context of the handlings.
+func newAuditProcessor(name string, sink AuditSink, tracer Tracer, leader string, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		ac := &auditContext{sink: sink, tracer: tracer, controller: name, key: key, leader: leader}
+		return next.Process(context.WithValue(ctx, auditCtxKey{}, ac), key)
+	})
+}
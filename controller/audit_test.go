@@ -0,0 +1,94 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/leaderelection"
+	"github.com/adevjoe/kooper/v2/log"
+	"github.com/adevjoe/kooper/v2/tracing/opentelemetry"
+)
+
+func TestGenericControllerAudit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}},
+		},
+	}
+	ret, _ := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	var records []controller.AuditRecord
+	var traceID string
+	sink := controller.AuditSinkFunc(func(_ context.Context, r controller.AuditRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, r)
+	})
+	h := controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		mu.Lock()
+		traceID = trace.SpanContextFromContext(ctx).TraceID().String()
+		mu.Unlock()
+
+		ns := obj.(*corev1.Namespace)
+		controller.Audit(ctx, "create", corev1.ObjectReference{Kind: "ConfigMap", Namespace: ns.Name, Name: "config"})
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       h,
+		Retriever:     ret,
+		LeaderElector: leaderelection.NewInMemory(log.Dummy).Runner("replica-1"),
+		Logger:        log.Dummy,
+		Tracer:        opentelemetry.New(opentelemetry.Config{TracerProvider: sdktrace.NewTracerProvider()}),
+		AuditSink:     sink,
+		DisableResync: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(records) == 1
+	}, 1*time.Second, 5*time.Millisecond)
+
+	// The audit record should have the handling context.
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(records, 1)
+	r := records[0]
+	assert.Equal("test", r.Controller)
+	assert.Equal("ns-1", r.Key)
+	assert.Equal("replica-1", r.Leader)
+	assert.NotEmpty(r.TraceID)
+	assert.Equal(traceID, r.TraceID)
+	assert.Equal("create", r.Action)
+	assert.Equal(corev1.ObjectReference{Kind: "ConfigMap", Namespace: "ns-1", Name: "config"}, r.Object)
+	assert.False(r.Time.IsZero())
+}
+
+func TestAuditWithoutSink(t *testing.T) {
+	// Auditing outside of a controller handling should not fail.
+	assert.NotPanics(t, func() {
+		controller.Audit(context.Background(), "create", corev1.ObjectReference{Name: "test"})
+	})
+}
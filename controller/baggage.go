@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// newBaggageHandler returns a handler that propagates the baggage of the object annotation (W3C baggage
+// format) into the handling context with the tracer, so the downstream calls of the handler carry it. The
+// annotation members are added to the baggage already present on the context, the objects without the
+// annotation or with an invalid baggage are handled without it.
+func newBaggageHandler(annotation string, tracer Tracer, logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return handleWithResult(ctx, next, obj)
+		}
+		value, ok := m.GetAnnotations()[annotation]
+		if !ok {
+			return handleWithResult(ctx, next, obj)
+		}
+
+		bctx, err := tracer.ContextWithBaggage(ctx, value)
+		if err != nil {
+			logger.WithKV(log.KV{"object-key": m.GetNamespace() + "/" + m.GetName()}).
+				Warningf("could not propagate %q annotation baggage: %s", annotation, err)
+			return handleWithResult(ctx, next, obj)
+		}
+
+		return handleWithResult(bctx, next, obj)
+	})
+}
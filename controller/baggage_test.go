@@ -0,0 +1,95 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+	"github.com/adevjoe/kooper/v2/tracing/opentelemetry"
+)
+
+func TestGenericControllerBaggageAnnotation(t *testing.T) {
+	const annotation = "example.com/baggage"
+
+	tests := map[string]struct {
+		annotations map[string]string
+		expBaggage  map[string]string
+	}{
+		"An object without the annotation should be handled without baggage.": {
+			annotations: map[string]string{"other": "tenant=team-a"},
+			expBaggage:  map[string]string{},
+		},
+
+		"An object with the annotation should propagate the baggage to the handler.": {
+			annotations: map[string]string{annotation: "tenant=team-a,request-id=1234"},
+			expBaggage:  map[string]string{"tenant": "team-a", "request-id": "1234"},
+		},
+
+		"An object with an invalid baggage should be handled without baggage.": {
+			annotations: map[string]string{annotation: "tenant=team-a,=,"},
+			expBaggage:  map[string]string{},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsl := &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items: []corev1.Namespace{
+					{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1", Annotations: test.annotations}},
+				},
+			}
+			ret, _ := newFakeNamespaceRetriever(nsl)
+
+			var mu sync.Mutex
+			var gotBaggage map[string]string
+			h := controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				gotBaggage = map[string]string{}
+				for _, m := range baggage.FromContext(ctx).Members() {
+					gotBaggage[m.Key()] = m.Value()
+				}
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:              "test",
+				Handler:           h,
+				Retriever:         ret,
+				Logger:            log.Dummy,
+				Tracer:            opentelemetry.New(opentelemetry.Config{}),
+				BaggageAnnotation: annotation,
+				DisableResync:     true,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return gotBaggage != nil
+			}, 1*time.Second, 5*time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expBaggage, gotBaggage)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+type baseCtxKey struct{}
+
+func TestGenericControllerBaseContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	valueC := make(chan interface{}, 1)
+	h := controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+		valueC <- ctx.Value(baseCtxKey{})
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:        "test",
+		Handler:     h,
+		Retriever:   newNamespaceRetriever(mc),
+		Logger:      log.Dummy,
+		BaseContext: func() context.Context { return context.WithValue(context.Background(), baseCtxKey{}, "base-value") },
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case v := <-valueC:
+		assert.Equal("base-value", v)
+	case <-time.After(1 * time.Second):
+		require.Fail("timeout waiting for handling")
+	}
+}
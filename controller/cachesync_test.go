@@ -0,0 +1,97 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerCacheSyncTimeout(t *testing.T) {
+	tests := map[string]struct {
+		cacheSyncTimeout time.Duration
+		ctxTimeout       time.Duration
+		blockList        bool
+		expErr           bool
+		expTimeoutErr    bool
+	}{
+		"A cache that syncs before the timeout should run the controller.": {
+			cacheSyncTimeout: 500 * time.Millisecond,
+			ctxTimeout:       300 * time.Millisecond,
+		},
+
+		"A cache that doesn't sync before the timeout should fail with a timeout error.": {
+			cacheSyncTimeout: 50 * time.Millisecond,
+			ctxTimeout:       5 * time.Second,
+			blockList:        true,
+			expErr:           true,
+			expTimeoutErr:    true,
+		},
+
+		"A context done while waiting for the cache sync should fail with the context error.": {
+			cacheSyncTimeout: 5 * time.Second,
+			ctxTimeout:       50 * time.Millisecond,
+			blockList:        true,
+			expErr:           true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithTimeout(context.Background(), test.ctxTimeout)
+			defer cancel()
+
+			// A blocked list simulates a broken apiserver connection.
+			unblockC := make(chan struct{})
+			defer close(unblockC)
+			nsList, _ := createNamespaceList("testing", 1)
+			ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+				ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+					if test.blockList {
+						<-unblockC
+					}
+					return nsList, nil
+				},
+				WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:             "test",
+				Handler:          controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+				Retriever:        ret,
+				Logger:           log.Dummy,
+				CacheSyncTimeout: test.cacheSyncTimeout,
+			})
+			require.NoError(err)
+
+			errC := make(chan error, 1)
+			go func() { errC <- c.Run(ctx) }()
+
+			select {
+			case err = <-errC:
+			case <-time.After(1 * time.Second):
+				require.FailNow("timeout waiting for the controller to end")
+			}
+
+			if test.expErr {
+				assert.Error(err)
+				assert.Equal(test.expTimeoutErr, errors.Is(err, controller.ErrCacheSyncTimeout))
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// inCanary returns true if the object key is part of the canary. The selection is based on the
+// key hash, so the same objects are always part of the canary.
+func inCanary(key string, percent int) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32()%100) < percent
+}
+
+// newCanaryProcessor returns a processor that will skip the processing of the keys that are not
+// part of the canary.
+func newCanaryProcessor(percent int, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		if !inCanary(key, percent) {
+			return nil
+		}
+
+		return next.Process(ctx, key)
+	})
+}
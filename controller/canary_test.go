@@ -0,0 +1,86 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerCanaryPercent(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	const total = 1000
+	nsList, _ := createNamespaceList("testing", total)
+
+	// runCanary runs a controller until all the objects are processed and returns the handled ones.
+	runCanary := func() map[string]bool {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mc := &fake.Clientset{}
+		onKubeClientListNamespaceReturn(mc, nsList)
+
+		var mu sync.Mutex
+		handled := map[string]bool{}
+		c, err := controller.New(&controller.Config{
+			Name: "test",
+			Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handled[obj.(*corev1.Namespace).Name] = true
+				return nil
+			}),
+			Retriever:     newNamespaceRetriever(mc),
+			Logger:        log.Dummy,
+			CanaryPercent: 20,
+		})
+		require.NoError(err)
+		go func() { _ = c.Run(ctx) }()
+
+		// Wait until the queue is empty, the skipped objects are not processed.
+		assert.Eventually(func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return c.Ready() == nil && c.Stats().QueueLength == 0 && len(handled) > 0
+		}, 1*time.Second, 5*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		res := map[string]bool{}
+		for k, v := range handled {
+			res[k] = v
+		}
+		return res
+	}
+
+	// Roughly the configured fraction of objects should be handled.
+	first := runCanary()
+	assert.InDelta(total*0.2, len(first), total*0.05)
+
+	// The selection should be the same across restarts.
+	second := runCanary()
+	assert.Equal(first, second)
+}
+
+func TestGenericControllerCanaryPercentInvalid(t *testing.T) {
+	_, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:     newNamespaceRetriever(&fake.Clientset{}),
+		Logger:        log.Dummy,
+		CanaryPercent: 101,
+	})
+	assert.Error(t, err)
+}
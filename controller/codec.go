@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Codec knows how to serialize and deserialize objects, e.g: to store or send them.
+type Codec interface {
+	// Encode serializes the object.
+	Encode(obj runtime.Object) ([]byte, error)
+	// Decode deserializes the object.
+	Decode(data []byte) (runtime.Object, error)
+}
+
+// NewJSONCodec returns a JSON codec, the objects types are resolved with the scheme,
+// if nil the Kubernetes client scheme will be used. This is the default codec.
+func NewJSONCodec(s *runtime.Scheme) Codec {
+	if s == nil {
+		s = scheme.Scheme
+	}
+	return serializerCodec{
+		scheme:     s,
+		serializer: json.NewSerializerWithOptions(json.DefaultMetaFactory, s, s, json.SerializerOptions{}),
+	}
+}
+
+// NewProtobufCodec returns a protobuf codec, faster and smaller than JSON, it only supports the
+// Kubernetes objects that have protobuf definitions (e.g `unstructured.Unstructured` is not supported).
+// The objects types are resolved with the scheme, if nil the Kubernetes client scheme will be used.
+func NewProtobufCodec(s *runtime.Scheme) Codec {
+	if s == nil {
+		s = scheme.Scheme
+	}
+	return serializerCodec{
+		scheme:     s,
+		serializer: protobuf.NewSerializer(s, s),
+	}
+}
+
+// serializerCodec is a codec based on Kubernetes serializers.
+type serializerCodec struct {
+	scheme     *runtime.Scheme
+	serializer runtime.Serializer
+}
+
+func (s serializerCodec) Encode(obj runtime.Object) ([]byte, error) {
+	// The objects from the clients usually don't have the type information, that
+	// is required to decode them.
+	if obj.GetObjectKind().GroupVersionKind().Empty() {
+		gvks, _, err := s.scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, fmt.Errorf("could not get object kind: %w", err)
+		}
+		obj = obj.DeepCopyObject()
+		obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+	}
+
+	var b bytes.Buffer
+	err := s.serializer.Encode(obj, &b)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode object: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (s serializerCodec) Decode(data []byte) (runtime.Object, error) {
+	obj, _, err := s.serializer.Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode object: %w", err)
+	}
+
+	return obj, nil
+}
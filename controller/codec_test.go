@@ -0,0 +1,56 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	objs := map[string]runtime.Object{
+		"namespace": &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "test",
+				ResourceVersion: "42",
+				Labels:          map[string]string{"team": "platform"},
+			},
+			Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		"configmap": &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Data:       map[string]string{"key": "value"},
+			BinaryData: map[string][]byte{"bin": {0, 1, 2}},
+		},
+	}
+
+	codecs := map[string]controller.Codec{
+		"json":     controller.NewJSONCodec(nil),
+		"protobuf": controller.NewProtobufCodec(nil),
+	}
+
+	for codecName, codec := range codecs {
+		for objName, obj := range objs {
+			t.Run(codecName+"/"+objName, func(t *testing.T) {
+				assert := assert.New(t)
+				require := require.New(t)
+
+				data, err := codec.Encode(obj)
+				require.NoError(err)
+				got, err := codec.Decode(data)
+				require.NoError(err)
+
+				// The decoded object has the type information.
+				gvk := got.GetObjectKind().GroupVersionKind()
+				assert.False(gvk.Empty())
+				got.GetObjectKind().SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+				assert.Equal(obj, got)
+			})
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// keyedMutex is a set of mutexes identified by a key, the mutexes are created
+// on demand and removed when nobody is using them.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexLock
+}
+
+type keyedMutexLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: map[string]*keyedMutexLock{}}
+}
+
+// lock locks the key mutex and returns the function to unlock it.
+func (k *keyedMutex) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedMutexLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
+
+// newConcurrencyKeyHandler returns a handler that will not handle at the same time the objects
+// that share the same concurrency key.
+func newConcurrencyKeyHandler(keyFunc func(obj runtime.Object) string, next Handler) Handler {
+	locks := newKeyedMutex()
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		key := keyFunc(obj)
+		if key != "" {
+			unlock := locks.lock(key)
+			defer unlock()
+		}
+
+		return handleWithResult(ctx, next, obj)
+	})
+}
@@ -0,0 +1,75 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerConcurrencyKey(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 6)
+	ret, _ := newFakeNamespaceRetriever(nsList)
+
+	// testing-0, testing-2 and testing-4 share the same external resource.
+	keyFunc := func(obj runtime.Object) string {
+		switch obj.(*corev1.Namespace).Name {
+		case "testing-0", "testing-2", "testing-4":
+			return "shared"
+		}
+		return ""
+	}
+
+	var mu sync.Mutex
+	active, maxActiveShared, maxActive := map[string]int{}, 0, 0
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		key := keyFunc(obj)
+		mu.Lock()
+		active[key]++
+		if key == "shared" && active[key] > maxActiveShared {
+			maxActiveShared = active[key]
+		}
+		if active[""]+active["shared"] > maxActive {
+			maxActive = active[""] + active["shared"]
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active[key]--
+		mu.Unlock()
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:               "test",
+		Handler:            h,
+		Retriever:          ret,
+		ConcurrentWorkers:  6,
+		ConcurrencyKeyFunc: keyFunc,
+		Logger:             log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return c.Stats().Processed == 6 }, 1*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(1, maxActiveShared, "objects sharing concurrency key should not be handled simultaneously")
+	assert.Greater(maxActive, 1, "objects without shared key should be handled concurrently")
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// newConflictRequeueHandler returns a handler that when the handling fails with a conflict error, it usually
+// means that the handled object from the cache was stale, instead of failing it requeues the object
+// after a short delay so the cache can catch up.
+func newConflictRequeueHandler(name string, delay time.Duration, mrec MetricsRecorder, logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		res, err := handleWithResult(ctx, next, obj)
+		if err == nil || !apierrors.IsConflict(err) {
+			return res, err
+		}
+
+		key, _ := cache.MetaNamespaceKeyFunc(obj)
+		mrec.IncResourceStaleCacheConflict(ctx, name)
+		logger.WithKV(log.KV{"object-key": key}).Debugf("conflict handling stale object, requeued: %s", err)
+
+		return Result{RequeueAfter: delay}, nil
+	})
+}
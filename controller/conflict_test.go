@@ -0,0 +1,111 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// conflictRecorder is a metrics recorder that counts the stale cache conflicts.
+type conflictRecorder struct {
+	controller.MetricsRecorder
+	conflicts int64
+}
+
+func (c *conflictRecorder) IncResourceStaleCacheConflict(context.Context, string) {
+	atomic.AddInt64(&c.conflicts, 1)
+}
+
+func TestGenericControllerConflictRequeue(t *testing.T) {
+	conflictErr := apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, "testing-0", fmt.Errorf("object modified"))
+
+	tests := map[string]struct {
+		err          error
+		expConflicts int64
+		expRequeued  bool
+	}{
+		"A conflict error should requeue the object after a short delay and measure the conflict.": {
+			err:          conflictErr,
+			expConflicts: 1,
+			expRequeued:  true,
+		},
+
+		"A wrapped conflict error should requeue the object after a short delay and measure the conflict.": {
+			err:          fmt.Errorf("could not update: %w", conflictErr),
+			expConflicts: 1,
+			expRequeued:  true,
+		},
+
+		"Other errors should not be handled as conflicts.": {
+			err:          fmt.Errorf("wanted error"),
+			expConflicts: 0,
+			expRequeued:  false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList, _ := createNamespaceList("testing", 1)
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			var mu sync.Mutex
+			calls := []time.Time{}
+			h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, time.Now())
+				if len(calls) == 1 {
+					return test.err
+				}
+				return nil
+			})
+			getCalls := func() []time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]time.Time{}, calls...)
+			}
+
+			mrec := &conflictRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            newNamespaceRetriever(mc),
+				Logger:               log.Dummy,
+				MetricsRecorder:      mrec,
+				ConflictRequeueDelay: 50 * time.Millisecond,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			if test.expRequeued {
+				assert.Eventually(func() bool { return len(getCalls()) == 2 }, 1*time.Second, 5*time.Millisecond)
+				calls := getCalls()
+				assert.GreaterOrEqual(int64(calls[1].Sub(calls[0])), int64(50*time.Millisecond))
+			} else {
+				assert.Eventually(func() bool { return len(getCalls()) == 1 }, 1*time.Second, 5*time.Millisecond)
+				time.Sleep(100 * time.Millisecond)
+				assert.Len(getCalls(), 1)
+			}
+			assert.Equal(test.expConflicts, atomic.LoadInt64(&mrec.conflicts))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// contentDeduplicator collapses the enqueued objects that have the same content hash, while an
+// object is waiting on the queue, other objects with the same hash will not be enqueued.
+//
+// Unlike the queue deduplication that is based on the object key, this is based on the result
+// of the hash func, so different objects that produce the same work are handled once.
+type contentDeduplicator struct {
+	hashFunc func(obj runtime.Object) string
+	mu       sync.Mutex
+	pending  map[string]string // hash -> key.
+	keyHash  map[string]string // key -> hash.
+}
+
+func newContentDeduplicator(hashFunc func(obj runtime.Object) string) *contentDeduplicator {
+	return &contentDeduplicator{
+		hashFunc: hashFunc,
+		pending:  map[string]string{},
+		keyHash:  map[string]string{},
+	}
+}
+
+// enqueue returns true if the object should be enqueued, false if another object with the
+// same content hash is already waiting on the queue. Empty hashes are never deduplicated.
+func (c *contentDeduplicator) enqueue(key string, obj interface{}) bool {
+	rtobj, ok := obj.(runtime.Object)
+	if !ok {
+		return true
+	}
+
+	hash := c.hashFunc(rtobj)
+	if hash == "" {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pk, ok := c.pending[hash]; ok && pk != key {
+		return false
+	}
+
+	// The object content could have changed while waiting on the queue.
+	if oldHash, ok := c.keyHash[key]; ok && oldHash != hash {
+		delete(c.pending, oldHash)
+	}
+	c.pending[hash] = key
+	c.keyHash[key] = hash
+
+	return true
+}
+
+// dequeue releases the content hash of the object key.
+func (c *contentDeduplicator) dequeue(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash, ok := c.keyHash[key]
+	if !ok {
+		return
+	}
+	delete(c.keyHash, key)
+	delete(c.pending, hash)
+}
+
+// newContentDedupProcessor returns a processor that releases the content hash of the object
+// when its processing starts, so new objects with the same content can be enqueued.
+func newContentDedupProcessor(dedup *contentDeduplicator, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		dedup.dequeue(key)
+		return next.Process(ctx, key)
+	})
+}
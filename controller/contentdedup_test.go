@@ -0,0 +1,79 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerContentHashDedup(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "blocker", ResourceVersion: "1"}}},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	// The first handling blocks the only worker until all the events have been enqueued.
+	releaseC := make(chan struct{})
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		name := obj.(*corev1.Namespace).Name
+		if name == "blocker" {
+			<-releaseC
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, name)
+		return nil
+	})
+	handledNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         ret,
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 1,
+		ContentHashFunc: func(obj runtime.Object) string {
+			return obj.(*corev1.Namespace).Labels["work"]
+		},
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "2", Labels: map[string]string{"work": "a"}}})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-2", ResourceVersion: "3", Labels: map[string]string{"work": "a"}}})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-3", ResourceVersion: "4", Labels: map[string]string{"work": "b"}}})
+	assert.Eventually(func() bool { return c.EventCounts().Add == 4 }, 1*time.Second, 5*time.Millisecond)
+	close(releaseC)
+
+	// The objects with the same content hash should be handled once.
+	assert.Eventually(func() bool { return len(handledNames()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal([]string{"blocker", "ns-1", "ns-3"}, handledNames())
+
+	// Once handled, the same content can be enqueued again.
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-2", ResourceVersion: "5", Labels: map[string]string{"work": "a"}}})
+	assert.Eventually(func() bool { return len(handledNames()) == 4 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal("ns-2", handledNames()[3])
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/adevjoe/kooper/v2/controller/leaderelection"
@@ -21,8 +28,12 @@ import (
 var (
 	// ErrControllerNotValid will be used when the controller has invalid configuration.
 	ErrControllerNotValid = errors.New("controller not valid")
+	// ErrCacheSyncTimeout will be used when the initial cache sync doesn't finish in the `CacheSyncTimeout`.
+	ErrCacheSyncTimeout = errors.New("initial cache sync timeout")
 )
 
+var labelKeyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // Controller is the object that will implement the different kinds of controllers that will be running
 // on the application.
 type Controller interface {
@@ -34,6 +45,9 @@ type Controller interface {
 type Config struct {
 	// Handler is the controller handler.
 	Handler Handler
+	// EventHandler is the controller handler that receives the kind of change (add, update or delete),
+	// and the old object on the updates. Can't be used with `Handler` nor `DeleteHandler`.
+	EventHandler EventHandler
 	// Retriever is the controller retriever.
 	Retriever Retriever
 	// Leader elector will be used to use only one instance, if no set it will be
@@ -47,23 +61,298 @@ type Config struct {
 	// name of the controller.
 	Name string
 	// ConcurrentWorkers is the number of concurrent workers the controller will have running processing events.
+	// If unset (zero) it will use the default number of workers (3), a negative number of workers is not valid
+	// and will fallback to the default with a warning.
 	ConcurrentWorkers int
 	// ResyncInterval is the interval the controller will process all the selected resources.
 	ResyncInterval time.Duration
+	// ResyncJitter is the factor (0-1) of the `ResyncInterval` used to smear the resync enqueues, instead of
+	// enqueuing all the objects at once every object resync is delayed randomly up to `ResyncJitter * ResyncInterval`.
+	// The resync peaks are lowered, so the `ConcurrentWorkers` can be sized for the spread rate (the objects
+	// divided by the jitter window) instead of handling all the objects at once. By default 0 (disabled).
+	ResyncJitter float64
 	// ProcessingJobRetries is the number of times the job will try to reprocess the event before returning a real error.
 	ProcessingJobRetries int
+	// OnMaxRetriesExceeded is called when the processing of an object failed and the controller gives up on it,
+	// it will not be retried anymore (the `ProcessingJobRetries` have been exhausted or the `RetryPolicy` gave up).
+	// Receives the object and the last processing error, useful to mark the object as failed or to alert. It's
+	// not called for the deleted objects.
+	OnMaxRetriesExceeded func(ctx context.Context, obj runtime.Object, err error)
 	// DisableResync will disable resyncing, if disabled the controller only will react on event updates and resync
 	// all when it runs for the first time.
 	// This is useful for secondary resource controllers (e.g pod controller of a primary controller based on deployments).
 	DisableResync bool
+	// VerifyOnResync checks that the resynced objects still exist on the apiserver (with a list of the object only)
+	// before handling them, the objects that don't exist are dropped. An object deleted while its delete event was
+	// missed lingers on the cache until the next relist. By default the cache is trusted.
+	VerifyOnResync bool
+	// InitialListRetries is the number of times the first list of the resources will be retried (e.g the apiserver
+	// is temporarily unavailable at boot) before `Run` fails. By default 0, the list will be retried forever.
+	InitialListRetries int
+	// InitialListRetryBackoff is the time waited before retrying a failed first list, doubled on every retry
+	// (capped at 30s). By default 1s.
+	InitialListRetryBackoff time.Duration
+	// CacheSyncTimeout is the max time `Run` will wait for the initial cache sync, if the caches are not
+	// synced in time `Run` will fail with `ErrCacheSyncTimeout` (e.g an unreachable apiserver). By default
+	// disabled, it will wait until the context is done.
+	CacheSyncTimeout time.Duration
+	// ShutdownGracePeriod is the max time `Run` will wait for the in-flight handlings to finish after the
+	// context is done. While stopping, the workers will not take new objects from the queue. By default
+	// disabled, `Run` returns as soon as the context is done without waiting for the running handlers.
+	ShutdownGracePeriod time.Duration
+	// LeaseBoundDeadline caps the handling context deadline at the remaining time of the leadership lease, the
+	// handlings will not mutate after the leadership is likely lost (e.g the lease can't be renewed). Requires
+	// a `LeaderElector` that knows its lease expiration (`leaderelection.LeaseRunner`). By default disabled.
+	LeaseBoundDeadline bool
+	// RequeueBackoffBase is the first delay used to requeue an object when the handler result asks
+	// for a requeue without an explicit delay (`Result.Requeue`). Every time the same object version is
+	// requeued the delay will be doubled, the delay is reset when the object changes. By default 1s.
+	RequeueBackoffBase time.Duration
+	// RequeueBackoffMax is the max delay used to requeue an object when the handler result asks for a
+	// requeue without an explicit delay. By default 5m.
+	RequeueBackoffMax time.Duration
+	// WatchOnly will only handle the changes on the resources received after the controller started.
+	// The resources on the initial list are stored in the cache but not handled, the watch starts from the
+	// resource version of the initial list so no change is lost. Resync is disabled in this mode.
+	WatchOnly bool
+	// WatchList will stream the objects of the lists with a watch (WatchList, `sendInitialEvents`) instead
+	// of listing them, lowering the API server memory usage on big lists. Requires a `WatchListRetriever`
+	// (check `RetrieverWithWatchList`). If the API server rejects the watch lists, the controller falls back
+	// to the classic list and watch, the used one is logged. By default disabled.
+	WatchList bool
+	// InitialQueue are the object keys queued once the cache is synced, before the workers start. Used to
+	// seed the queue with the pending work of a previous controller (check `SnapshotQueue`), e.g on the
+	// upgrades of single replica operators combined with `WatchOnly`.
+	InitialQueue []string
+	// ConcurrencyKeyFunc returns the concurrency key of an object. The objects that share the same
+	// concurrency key will not be handled at the same time even if they are different objects
+	// (e.g they use the same external resource). Objects with an empty key are not serialized.
+	ConcurrencyKeyFunc func(obj runtime.Object) string
+	// RestartOnChange cancels the context of the in-flight handling of an object when a newer version of
+	// the object is received, so the object is handled again with the latest state instead of waiting for
+	// the stale handling to finish. The handlers should respect the context cancellation.
+	RestartOnChange bool
+	// Reporter if set will collect the result of every object processing.
+	Reporter *Reporter
+	// EventStream if set will stream the result of every object processing to its HTTP subscribers.
+	EventStream *EventStream
+	// AuditSink if set will receive the audit records of the mutations reported by the handlers with `Audit`.
+	AuditSink AuditSink
+	// AdaptiveResyncLatencyThreshold enables the adaptive resync. Before every resync the latency of the last
+	// informer list or watch request is checked (no extra requests are sent to the apiserver), if it's greater
+	// than the threshold the resync interval will be doubled (up to
+	// `AdaptiveResyncMaxInterval`), when the latency is back under the threshold the interval returns to
+	// `ResyncInterval`. Ignored if the resync is disabled.
+	AdaptiveResyncLatencyThreshold time.Duration
+	// AdaptiveResyncMaxInterval is the max resync interval used by the adaptive resync. By default 10 times
+	// the `ResyncInterval`.
+	AdaptiveResyncMaxInterval time.Duration
+	// Labels are arbitrary metadata of the controller (e.g team, tier...) that will be added to the
+	// controller logs and metrics. To keep the metrics cardinality in check, the metrics recorder
+	// can reject the label keys that are not allowed.
+	Labels map[string]string
+	// SlowHandlingThreshold enables the handling timeline, the handlers can mark the sub-steps of
+	// the handling with `Step` and if the handling of an object takes more than the threshold, the
+	// timeline will be logged. By default disabled.
+	SlowHandlingThreshold time.Duration
+	// WarmUpTimeout enables the warm-up, the objects of the initial sync that fail will be retried
+	// (after `RequeueBackoffBase`) until they are handled successfully or the timeout is reached,
+	// the controller will not be ready until then. Ignored when `WatchOnly` is used. By default disabled.
+	WarmUpTimeout time.Duration
+	// MaxObjectSize is the max serialized (JSON) size in bytes of the objects, the objects that exceed
+	// it will be skipped (logged and measured) and will not enter the cache nor the queue, useful to
+	// avoid memory problems caching many big objects. By default disabled.
+	MaxObjectSize int
+	// NormalizeVersionFunc converts the objects to their canonical API version before they are cached,
+	// keyed and handled. The representations of the same object version received under multiple API
+	// versions (e.g a CRD version migration) are handled once. By default disabled.
+	NormalizeVersionFunc func(obj runtime.Object) (runtime.Object, error)
+	// DependentsFunc returns the keys of the objects that depend on a changed object (e.g the objects
+	// that reference a shared object), these will be enqueued too. The dependents enqueues are coalesced
+	// and rate limited to avoid enqueue storms when an object has many dependents. By default disabled.
+	DependentsFunc func(obj runtime.Object) []string
+	// DependentsEnqueueQPS is the max number of dependents enqueued per second. By default 50.
+	DependentsEnqueueQPS float64
+	// DependentsEnqueueBurst is the max number of dependents enqueued at once. By default 10.
+	DependentsEnqueueBurst int
+	// Filter decides if the object of an add or update event should be enqueued to be processed, if it
+	// returns false the event will be ignored. Can be replaced while running with `SetFilter`. By default
+	// all the objects are enqueued.
+	Filter func(obj runtime.Object) bool
+	// UpdatePredicate decides if an update event should be enqueued with the old and the new object, if it
+	// returns false the update will be ignored (e.g `GenerationChangedPredicate`). The resyncs (same object
+	// version) are not affected. By default all the updates are enqueued.
+	UpdatePredicate func(oldObj, newObj runtime.Object) bool
+	// KeyNormalizeFunc normalizes the object keys before any queue operation, so the aliased keys of an
+	// object (e.g case-insensitive names) are deduplicated and processed once, using the last received object.
+	// The normalized keys are the ones used by the controller (e.g `Exclude`), the func must be idempotent.
+	KeyNormalizeFunc func(key string) string
+	// KeyFunc returns the key of the objects, used to queue the objects and to get them back from the cache,
+	// so the objects can be keyed by something else than their namespace and name (e.g a label value to
+	// deduplicate renamed objects). When multiple objects share the same key the newest one is handled.
+	// The key func must be deterministic. By default `cache.DeletionHandlingMetaNamespaceKeyFunc`.
+	KeyFunc cache.KeyFunc
+	// BaseContext returns the root context of the controller operations (e.g the handling context),
+	// useful to add context values for the whole controller. The `Run` context cancellation is not
+	// propagated to the handling context. By default `context.Background`.
+	BaseContext func() context.Context
+	// ConflictRequeueDelay enables the stale cache conflicts handling, when the handling fails with a
+	// conflict error (usually because the handled object from the cache was stale) instead of failing,
+	// the object will be requeued after the delay to let the cache catch up. By default disabled.
+	ConflictRequeueDelay time.Duration
+	// EventRecorder is the Kubernetes event recorder used to post the handling errors as Warning
+	// events on the objects (check `ErrorEventInterval`).
+	EventRecorder record.EventRecorder
+	// ErrorEventInterval enables posting the handling errors as Warning events with the `EventRecorder`,
+	// while an object keeps failing it will post at most one event per interval. By default disabled.
+	ErrorEventInterval time.Duration
+	// DumpObjectOnError will log at debug level the YAML of the objects that failed the handling.
+	DumpObjectOnError bool
+	// DumpObjectRedactFunc receives a copy of the object that will be dumped (check `DumpObjectOnError`)
+	// so it can strip the sensitive data (e.g secrets) before logging it.
+	DumpObjectRedactFunc func(obj runtime.Object) runtime.Object
+	// OnWatchExpired is called when the watch expires because the resource version is too old
+	// (410 Gone), the controller will relist to recover. Frequent expirations indicate etcd
+	// compaction pressure. It should return quickly.
+	OnWatchExpired func(err error)
+	// RetryPolicy decides what to do when the handling of an object fails: forget it, requeue it or requeue
+	// it after a duration. If set, it replaces the default retry behavior and `ProcessingJobRetries` is ignored.
+	RetryPolicy RetryPolicy
+	// Namespace if set, only the objects of this namespace will be enqueued. If the retriever declares
+	// its scope (check `ScopedRetriever`), it will be validated to be compatible with it.
+	Namespace string
+	// LabelSelector if set, only the objects that match the selector will be listed and watched, the
+	// selector is sent to the server on the retriever list options so the unrelated objects never enter the
+	// cache. Retrievers that set their own label selector should merge it with the received one.
+	LabelSelector labels.Selector
+	// OnStatsSample is called periodically with a snapshot of the controller stats while running, useful
+	// to feed the internal signals (e.g queue length, processing rate) to autoscalers or tuning logic.
+	OnStatsSample func(Stats)
+	// StatsSampleInterval is the interval `OnStatsSample` will be called. By default 10s.
+	StatsSampleInterval time.Duration
+	// ContentHashFunc returns the hash of the reconcile work of an object, when set, the objects with the
+	// same hash waiting on the queue are collapsed so only one of them is handled. Objects with an empty hash
+	// are not collapsed. Unlike the key based deduplication, this collapses different objects.
+	ContentHashFunc func(obj runtime.Object) string
+	// MaxRetryDuration will retry the failed objects, regardless of the number of attempts, until they have
+	// been failing for this duration, then they are forgotten. If set, `ProcessingJobRetries` is ignored.
+	// `RetryPolicy` has precedence over it.
+	MaxRetryDuration time.Duration
+	// Clock is the clock used to measure the retry durations (check `MaxRetryDuration`). By default
+	// the real clock, useful for tests.
+	Clock clock.PassiveClock
+	// InformerRebuildThreshold is the number of objects that can't be resolved to a key (key func errors or
+	// invalid object meta) that triggers an automatic informer rebuild (check `RebuildInformer`), the count
+	// is reset on every rebuild. The handlers can also trigger it returning `ErrInconsistentCache`. The
+	// automatic rebuilds happen at most once per minute. By default 0 (disabled for the key failures).
+	InformerRebuildThreshold int
+	// HandleQPS is the max number of handlings per second of the controller, regardless of the objects and
+	// the workers. Useful when the handler reconciles against an external API with a global rate limit.
+	// By default disabled.
+	HandleQPS float64
+	// HandleBurst is the max number of handlings at once when `HandleQPS` is set. By default 1.
+	HandleBurst int
+	// ExposeExpvar publishes the controller counters (processed, errored, queue length, leader...) on
+	// expvar under the `ExpvarName` variable keyed by the controller name, available on `/debug/vars`.
+	ExposeExpvar bool
+	// Deterministic is a debug mode that makes the processing reproducible: a single worker processes
+	// the objects strictly in enqueue order, and the coalescing of the enqueued objects is disabled
+	// (`ContentHashFunc` and the dependents rate limiting). Not intended for production.
+	Deterministic bool
+	// Owns are the secondary resources owned by the controller resources (check `OwnsKind`), when an owned
+	// object changes, its owners (using the owner references or the `OwnerKeyFunc`) will be enqueued, the
+	// handlings can get the owned kinds that changed with `OwnedChangeSources`. By default none.
+	Owns []OwnedResource
+	// DuplicateNameBehavior is the behavior when other live controller of the process is using the same
+	// name, their metrics and logs would collide. The name is released once the controller `Run` returns or
+	// it's stopped, so the controllers recreated with the same name (e.g `FeatureFlagRunner`) are not
+	// detected as duplicated. By default ignored.
+	DuplicateNameBehavior DuplicateNameBehavior
+	// DeleteHandler will handle the deleted objects, receiving their last known state, the key of the
+	// deleted object can be obtained with `DeletedObjectKey`. The delete handlings are not retried. By
+	// default the deletions are not handled, `Handler` is not called for deleted objects.
+	DeleteHandler Handler
+	// SkipUnhandledDeletes will not call the `DeleteHandler` for the objects that the controller never
+	// handled (e.g filtered out on the add), so there is nothing to clean up.
+	SkipUnhandledDeletes bool
+	// RateLimiter computes the delay of the processing retries of the failed objects. By default
+	// `DefaultRetryRateLimiter`.
+	RateLimiter workqueue.RateLimiter
+	// DetectGoroutineLeaks is a debug mode that warns when a handling leaves goroutines running after
+	// returning. Every handling takes a goroutine profile, and the handlings that leave goroutines will
+	// wait up to 100ms for them to end, so don't use it in production. By default disabled.
+	DetectGoroutineLeaks bool
+	// DetectObjectMutations is a debug mode that fails the handlings that mutate the received object (a common
+	// bug, the objects are shared with the cache) with `ErrObjectMutated`. Every handling receives a deep copy of
+	// the object that is compared with the original after the handling, so don't use it in production (e.g enable
+	// it on the tests). By default disabled.
+	DetectObjectMutations bool
+	// Tracer traces the handlings (e.g OpenTelemetry, check `tracing/opentelemetry`), every handling will have
+	// a span named after the controller with the object as attributes, the span is passed to the handler on
+	// the context. By default a dummy tracer.
+	Tracer Tracer
+	// CanaryPercent enables the canary mode, only the percentage (1-100) of the objects will be handled,
+	// the rest are ignored. The objects are selected by their key hash, so the same objects are always
+	// part of the canary (e.g rolling out a new handling logic safely). By default disabled.
+	CanaryPercent int
+	// TraceObjectLifecycle links every handling span (check `Tracer`) to the previous handling span
+	// of the same object UID, so the whole lifecycle of an object (creation, updates and deletion) can be
+	// followed. The last span of every object is kept in memory until the object is deleted.
+	TraceObjectLifecycle bool
+	// TraceSteps creates a child span of the handling span (check `Tracer`) for every handling sub-step
+	// marked with `Step`, so the handling latency can be broken down by phase.
+	TraceSteps bool
+	// TraceSampleRate is the fraction (0-1) of the handlings that will be traced (head-based sampling), useful
+	// on high volume controllers. The failed handlings are always traced, if they were not sampled a span
+	// is created when the handling ends. By default 1, all the handlings are traced.
+	TraceSampleRate float64
+	// BaggageAnnotation is the object annotation with the baggage (W3C baggage format), if set the baggage of
+	// the handled objects (e.g set by the external systems that triggered the change) is propagated into the
+	// handling context with the `Tracer`, so the downstream calls of the handler carry it. By default disabled.
+	BaggageAnnotation string
+	// PanicHandler is called when a handling panics, the panics are always recovered and treated as
+	// handling errors (the object will be retried). Useful to log or measure the panics.
+	PanicHandler func(ctx context.Context, obj runtime.Object, r interface{})
+	// PanicQuarantineThreshold is the number of consecutive panics of the same object after which the
+	// object will be quarantined (excluded, check `Exclude`), so an object that always panics (e.g bad
+	// data) is not retried in a loop. It can be processed again with `Include`. By default disabled.
+	PanicQuarantineThreshold int
+	// PriorityQueue enables the priority queue, required to move objects to the front of the
+	// queue with `Prioritize` (e.g admin tooling that needs an object reconciled now).
+	PriorityQueue bool
+}
+
+// DefaultConfig returns a configuration with the default values that `New` will use
+// for the unset fields. The required fields (`Name`, `Handler` and `Retriever`) and
+// the `Logger` and `MetricsRecorder` are not set.
+func DefaultConfig() Config {
+	return Config{
+		ConcurrentWorkers:       3,
+		ResyncInterval:          3 * time.Minute,
+		ProcessingJobRetries:    0,
+		InitialListRetries:      0,
+		InitialListRetryBackoff: time.Second,
+		RequeueBackoffBase:      time.Second,
+		RequeueBackoffMax:       5 * time.Minute,
+		DependentsEnqueueQPS:    50,
+		DependentsEnqueueBurst:  10,
+		StatsSampleInterval:     10 * time.Second,
+		TraceSampleRate:         1,
+	}
 }
 
 func (c *Config) setDefaults() error {
+	def := DefaultConfig()
+
 	if c.Name == "" {
 		return fmt.Errorf("a controller name is required")
 	}
 
-	if c.Handler == nil {
+	if c.EventHandler != nil {
+		if c.Handler != nil || c.DeleteHandler != nil {
+			return fmt.Errorf("event handler can't be used with handler nor delete handler")
+		}
+	} else if c.Handler == nil {
 		return fmt.Errorf("a handler is required")
 	}
 
@@ -71,51 +360,175 @@ func (c *Config) setDefaults() error {
 		return fmt.Errorf("a retriever is required")
 	}
 
+	if err := validateScope(c.Retriever, c.Namespace); err != nil {
+		return err
+	}
+
+	if _, ok := c.Retriever.(WatchListRetriever); c.WatchList && !ok {
+		return fmt.Errorf("watch list requires a watch list retriever")
+	}
+
 	if c.Logger == nil {
 		c.Logger = log.NewStd(false)
 		c.Logger.Warningf("no logger specified, fallback to default logger, to disable logging use a explicit Noop logger")
 	}
-	c.Logger = c.Logger.WithKV(log.KV{
-		"service":       "kooper.controller",
-		"controller-id": c.Name,
-	})
+	for k := range c.Labels {
+		if !labelKeyRegexp.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+	}
+
+	kv := log.KV{}
+	for k, v := range c.Labels {
+		kv[k] = v
+	}
+	kv["service"] = "kooper.controller"
+	kv["controller-id"] = c.Name
+	c.Logger = c.Logger.WithKV(kv)
 
 	if c.MetricsRecorder == nil {
 		c.MetricsRecorder = DummyMetricsRecorder
 		c.Logger.Warningf("no metrics recorder specified, disabling metrics")
 	}
 
+	if c.ConcurrentWorkers < 0 {
+		c.Logger.Warningf("invalid %d concurrent workers, fallback to the default %d workers", c.ConcurrentWorkers, def.ConcurrentWorkers)
+	}
+
 	if c.ConcurrentWorkers <= 0 {
-		c.ConcurrentWorkers = 3
+		c.ConcurrentWorkers = def.ConcurrentWorkers
+	}
+
+	if c.Deterministic {
+		c.Logger.Warningf("deterministic debug mode enabled, using a single worker without coalescing")
+		c.ConcurrentWorkers = 1
+		c.ContentHashFunc = nil
 	}
 
 	if c.ResyncInterval <= 0 {
-		c.ResyncInterval = 3 * time.Minute
+		c.ResyncInterval = def.ResyncInterval
 	}
 
-	if c.DisableResync {
+	if c.DisableResync || c.WatchOnly {
 		c.ResyncInterval = 0 // 0 == resync disabled.
 	}
 
 	if c.ProcessingJobRetries < 0 {
-		c.ProcessingJobRetries = 0
+		c.ProcessingJobRetries = def.ProcessingJobRetries
+	}
+
+	if c.InitialListRetries < 0 {
+		c.InitialListRetries = def.InitialListRetries
+	}
+
+	if c.InitialListRetryBackoff <= 0 {
+		c.InitialListRetryBackoff = def.InitialListRetryBackoff
+	}
+
+	if c.RequeueBackoffBase <= 0 {
+		c.RequeueBackoffBase = def.RequeueBackoffBase
+	}
+
+	if c.RequeueBackoffMax <= 0 {
+		c.RequeueBackoffMax = def.RequeueBackoffMax
+	}
+
+	if c.RequeueBackoffMax < c.RequeueBackoffBase {
+		c.RequeueBackoffMax = c.RequeueBackoffBase
+	}
+
+	if c.BaseContext == nil {
+		c.BaseContext = context.Background
+	}
+
+	if c.Clock == nil {
+		c.Clock = clock.RealClock{}
+	}
+
+	if c.ResyncJitter < 0 || c.ResyncJitter > 1 {
+		return fmt.Errorf("resync jitter must be between 0 and 1")
+	}
+
+	if c.CanaryPercent < 0 || c.CanaryPercent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100")
+	}
+
+	if c.TraceSampleRate < 0 || c.TraceSampleRate > 1 {
+		return fmt.Errorf("trace sample rate must be between 0 and 1")
+	}
+
+	if c.TraceSampleRate == 0 {
+		c.TraceSampleRate = def.TraceSampleRate
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = DummyTracer
+	}
+
+	if c.RateLimiter == nil {
+		c.RateLimiter = DefaultRetryRateLimiter()
+	}
+
+	if c.DependentsEnqueueQPS <= 0 {
+		c.DependentsEnqueueQPS = def.DependentsEnqueueQPS
+	}
+
+	if c.DependentsEnqueueBurst <= 0 {
+		c.DependentsEnqueueBurst = def.DependentsEnqueueBurst
+	}
+
+	if c.StatsSampleInterval <= 0 {
+		c.StatsSampleInterval = def.StatsSampleInterval
+	}
+
+	if c.HandleQPS > 0 && c.HandleBurst <= 0 {
+		c.HandleBurst = 1
+	}
+
+	if c.AdaptiveResyncMaxInterval < c.ResyncInterval {
+		c.AdaptiveResyncMaxInterval = 10 * c.ResyncInterval
+	}
+
+	if _, ok := c.LeaderElector.(leaderelection.LeaseRunner); c.LeaseBoundDeadline && !ok {
+		return fmt.Errorf("lease bound deadline requires a leader elector that knows its lease expiration")
 	}
 
 	return nil
 }
 
-// generic controller is a controller that can be used to create different kind of controllers.
-type generic struct {
+// Generic is the controller returned by `New`, a controller that can be used to create different kind
+// of controllers. Apart from being a `Controller`, it exposes the methods to inspect and operate the
+// running controller (e.g `Stats`, `Ready`, `Stop`).
+type Generic struct {
 	queue     blockingQueue             // queue will have the jobs that the controller will get and send to handlers.
 	informer  cache.SharedIndexInformer // informer will notify be inform us about resource changes.
 	processor processor                 // processor will call the user handler (logic).
 
-	running   bool
-	runningMu sync.Mutex
-	cfg       Config
-	metrics   MetricsRecorder
-	leRunner  leaderelection.Runner
-	logger    log.Logger
+	running         bool
+	synced          bool
+	runningMu       sync.Mutex
+	stopOnce        sync.Once
+	releaseOnce     sync.Once
+	releaseName     func()
+	stopC           chan struct{}
+	cfg             Config
+	metrics         MetricsRecorder
+	leRunner        leaderelection.Runner
+	stopGracePeriod time.Duration
+	logger          log.Logger
+	initialListErrC chan error
+	listRetrier     *initialListRetrier
+	stats           *stats
+	excluded        *exclusionSet
+	degraded        *degradedState
+	snapshotQueue   *snapshotBlockingQueue
+	resyncer        *adaptiveResyncer
+	warmUp          *warmUp
+	dependents      *dependentsEnqueuer
+	filter          *objectFilter
+	rebuilder       *informerRebuilder
+	owned           *ownedInformers
+	priorityQueue   *priorityQueue
 }
 
 func listerWatcherFromRetriever(ret Retriever) cache.ListerWatcher {
@@ -130,19 +543,114 @@ func listerWatcherFromRetriever(ret Retriever) cache.ListerWatcher {
 	}
 }
 
+const maxInitialListRetryBackoff = 30 * time.Second
+
+// initialListRetrier is a ListerWatcher that will retry the first list until it succeeds or the
+// max retries are reached, in that case the list error will be sent on errC. Once the first list
+// succeeded the lists will not be retried. The retries wait until the controller stops running
+// (check `setContext`).
+type initialListRetrier struct {
+	maxRetries int
+	backoff    time.Duration
+	errC       chan<- error
+	logger     log.Logger
+	lw         cache.ListerWatcher
+
+	mu     sync.Mutex
+	listed bool
+	ctx    context.Context
+}
+
+func newInitialListRetrier(maxRetries int, backoff time.Duration, errC chan<- error, logger log.Logger, lw cache.ListerWatcher) *initialListRetrier {
+	return &initialListRetrier{
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		errC:       errC,
+		logger:     logger,
+		lw:         lw,
+		ctx:        context.Background(),
+	}
+}
+
+// setContext sets the context of the controller run, the list retries stop once it's done.
+func (r *initialListRetrier) setContext(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+}
+
+func (r *initialListRetrier) state() (listed bool, ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listed, r.ctx
+}
+
+func (r *initialListRetrier) List(options metav1.ListOptions) (runtime.Object, error) {
+	listed, ctx := r.state()
+	if listed {
+		return r.lw.List(options)
+	}
+
+	wait := r.backoff
+	for retry := 0; ; retry++ {
+		obj, err := r.lw.List(options)
+		if err == nil {
+			r.mu.Lock()
+			r.listed = true
+			r.mu.Unlock()
+			return obj, nil
+		}
+
+		if retry >= r.maxRetries {
+			select {
+			case r.errC <- err:
+			default:
+			}
+			return nil, err
+		}
+
+		r.logger.Warningf("initial list failed, retrying in %s: %s", wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("initial list retries stopped: %w", err)
+		}
+		wait *= 2
+		if wait > maxInitialListRetryBackoff {
+			wait = maxInitialListRetryBackoff
+		}
+	}
+}
+
+func (r *initialListRetrier) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return r.lw.Watch(options)
+}
+
 // New creates a new controller that can be configured using the cfg parameter.
-func New(cfg *Config) (Controller, error) {
+func New(cfg *Config) (*Generic, error) {
 	// Sets the required default configuration.
 	err := cfg.setDefaults()
 	if err != nil {
 		return nil, fmt.Errorf("could no create controller: %w: %v", ErrControllerNotValid, err)
 	}
 
+	err = cfg.MetricsRecorder.RegisterControllerLabels(cfg.Name, cfg.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("could no create controller: %w: invalid labels: %v", ErrControllerNotValid, err)
+	}
+
 	// Create the queue that will have our received job changes.
-	queue := newRateLimitingBlockingQueue(
-		cfg.ProcessingJobRetries,
-		workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-	)
+	st := &stats{}
+	var pq *priorityQueue
+	var wq workqueue.RateLimitingInterface = workqueue.NewRateLimitingQueue(cfg.RateLimiter)
+	if cfg.PriorityQueue {
+		pq = newPriorityQueue(cfg.RateLimiter)
+		wq = pq
+	}
+	queue := newRateLimitingBlockingQueue(cfg.ProcessingJobRetries, wq)
+	queue = newStatsBlockingQueue(st, queue)
 
 	// Measure the queue.
 	queue, err = newMetricsBlockingQueue(
@@ -154,77 +662,433 @@ func New(cfg *Config) (Controller, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not measure the queue: %w", err)
 	}
+	snapshotQueue := newSnapshotBlockingQueue(queue)
+	queue = snapshotQueue
+	var kn *keyNormalizer
+	if cfg.KeyNormalizeFunc != nil {
+		kn = newKeyNormalizer(cfg.KeyNormalizeFunc)
+		queue = normalizedBlockingQueue{blockingQueue: queue, kn: kn}
+	}
 
 	// store is the internal cache where objects will be store.
 	store := cache.Indexers{}
+	keyFunc := cache.DeletionHandlingMetaNamespaceKeyFunc
+	if cfg.KeyFunc != nil {
+		keyFunc = deletionHandlingKeyFunc(cfg.KeyFunc)
+		store[keyFuncIndex] = keyFuncIndexFunc(keyFunc)
+	}
 	lw := listerWatcherFromRetriever(cfg.Retriever)
-	informer := cache.NewSharedIndexInformer(lw, nil, cfg.ResyncInterval, store)
+	if cfg.WatchList {
+		lw = newWatchListListerWatcher(cfg.Retriever.(WatchListRetriever), cfg.Logger, lw)
+	}
+	degraded := newDegradedState(cfg.Name, cfg.MetricsRecorder, cfg.Logger)
+	lw = degraded.wrap(lw)
+	if cfg.LabelSelector != nil && !cfg.LabelSelector.Empty() {
+		lw = newLabelSelectorListerWatcher(cfg.LabelSelector, lw)
+	}
+	lw = watchExpiredDetector{name: cfg.Name, mrec: cfg.MetricsRecorder, hook: cfg.OnWatchExpired, logger: cfg.Logger}.wrap(lw)
+	// The relister is set once the informer ListerWatcher is ready.
+	rebuilder := &informerRebuilder{
+		name:      cfg.Name,
+		threshold: cfg.InformerRebuildThreshold,
+		clock:     cfg.Clock,
+		mrec:      cfg.MetricsRecorder,
+		logger:    cfg.Logger,
+	}
+	lw = objectMetaValidator{name: cfg.Name, mrec: cfg.MetricsRecorder, logger: cfg.Logger, rebuilder: rebuilder}.wrap(lw)
+	var sizeDeleted *sizeDeletions
+	if cfg.MaxObjectSize > 0 {
+		sizeDeleted = newSizeDeletions()
+		lw = objectSizeFilter{name: cfg.Name, maxSize: cfg.MaxObjectSize, mrec: cfg.MetricsRecorder, logger: cfg.Logger, deletions: sizeDeleted}.wrap(lw)
+	}
+	if cfg.NormalizeVersionFunc != nil {
+		lw = newVersionNormalizer(cfg.NormalizeVersionFunc, cfg.Logger).wrap(lw)
+	}
+	initialListErrC := make(chan error, 1)
+	var listRetrier *initialListRetrier
+	if cfg.InitialListRetries > 0 {
+		listRetrier = newInitialListRetrier(cfg.InitialListRetries, cfg.InitialListRetryBackoff, initialListErrC, cfg.Logger, lw)
+		lw = listRetrier
+	}
+	var initialListIgnored *initialListIgnorer
+	if cfg.WatchOnly {
+		initialListIgnored = newInitialListIgnorer()
+		lw = initialListIgnored.wrap(lw)
+	}
+	relister := newRelister(lw)
+	rebuilder.relister = relister
+	lw = relister
+	// If the resync is adaptive the informer will not resync, we will do it.
+	informerResyncInterval := cfg.ResyncInterval
+	adaptiveResync := cfg.AdaptiveResyncLatencyThreshold > 0 && cfg.ResyncInterval > 0
+	var latencyObserver *apiLatencyObserver
+	if adaptiveResync {
+		informerResyncInterval = 0
+		latencyObserver = &apiLatencyObserver{}
+		lw = latencyObserver.wrap(lw)
+	}
+	informer := cache.NewSharedIndexInformer(lw, nil, informerResyncInterval, store)
+
+	filter := newObjectFilter(cfg.Namespace, cfg.Filter)
+	var dedup *contentDeduplicator
+	if cfg.ContentHashFunc != nil {
+		dedup = newContentDeduplicator(cfg.ContentHashFunc)
+	}
+	var dependents *dependentsEnqueuer
+	if cfg.DependentsFunc != nil {
+		dependents = newDependentsEnqueuer(cfg.DependentsEnqueueQPS, cfg.DependentsEnqueueBurst, cfg.DependentsFunc, queue)
+		dependents.direct = cfg.Deterministic
+	}
+	handler, deleteHandler := cfg.Handler, cfg.DeleteHandler
+	if cfg.EventHandler != nil {
+		eha := newEventHandlerAdapter(cfg.EventHandler, keyFunc)
+		handler, deleteHandler = eha.handler(), eha.deleteHandler()
+	}
+	var deletes *deleteTracker
+	if deleteHandler != nil {
+		deletes = newDeleteTracker(cfg.SkipUnhandledDeletes)
+	}
+	var lifecycle *lifecycleLinks
+	if cfg.TraceObjectLifecycle {
+		lifecycle = newLifecycleLinks()
+	}
+	var restarter *changeRestarter
+	if cfg.RestartOnChange {
+		restarter = newChangeRestarter()
+	}
+	var verifier *resyncVerifier
+	if cfg.VerifyOnResync && cfg.ResyncInterval > 0 {
+		verifier = newResyncVerifier(cfg.Retriever, cfg.Logger)
+	}
+	// The adaptive resyncer is created after the event handler, but its current interval is the
+	// window used by the event handler to smear the resyncs.
+	var resyncer *adaptiveResyncer
+	var warmUp *warmUp
+	if cfg.WarmUpTimeout > 0 && !cfg.WatchOnly {
+		warmUp = newWarmUp()
+	}
+	var owned *ownedInformers
+	if len(cfg.Owns) > 0 {
+		owned = newOwnedInformers(cfg.Owns, informer.GetIndexer(), keyFunc, queue, cfg.Logger)
+	}
 
 	// Set up our informer event handler.
 	// Objects are already in our local store. Add only keys/jobs on the queue so they can re processed
 	// afterwards.
-	informer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
+	eventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
+			atomic.AddInt64(&st.addEvents, 1)
 			key, err := cache.MetaNamespaceKeyFunc(obj)
 			if err != nil {
 				cfg.Logger.Warningf("could not add item from 'add' event to queue: %s", err)
+				rebuilder.keyFailed()
 				return
 			}
-			queue.Add(context.TODO(), key)
+			qkey, err := keyFunc(obj)
+			if err != nil {
+				cfg.Logger.Warningf("could not add item from 'add' event to queue: %s", err)
+				rebuilder.keyFailed()
+				return
+			}
+			if kn != nil {
+				qkey = kn.received(qkey)
+			}
+			if deletes != nil {
+				deletes.added(qkey)
+			}
+			if initialListIgnored != nil && initialListIgnored.ignore(key, obj.(runtime.Object)) {
+				return
+			}
+			if !filter.match(obj) {
+				return
+			}
+			if dedup != nil && !dedup.enqueue(qkey, obj) {
+				return
+			}
+			if warmUp != nil {
+				warmUp.enqueued(qkey)
+			}
+			queue.Add(context.TODO(), qkey)
+			if dependents != nil {
+				dependents.enqueue(obj)
+			}
 		},
-		UpdateFunc: func(_ interface{}, new interface{}) {
+		UpdateFunc: func(old interface{}, new interface{}) {
+			atomic.AddInt64(&st.updateEvents, 1)
 			key, err := cache.MetaNamespaceKeyFunc(new)
 			if err != nil {
 				cfg.Logger.Warningf("could not add item from 'update' event to queue: %s", err)
+				rebuilder.keyFailed()
 				return
 			}
-			queue.Add(context.TODO(), key)
+			if cfg.UpdatePredicate != nil && !isResync(old, new) && !cfg.UpdatePredicate(old.(runtime.Object), new.(runtime.Object)) {
+				return
+			}
+			if initialListIgnored != nil && initialListIgnored.ignore(key, new.(runtime.Object)) {
+				return
+			}
+			if !filter.match(new) {
+				return
+			}
+			qkey, err := keyFunc(new)
+			if err != nil {
+				cfg.Logger.Warningf("could not add item from 'update' event to queue: %s", err)
+				rebuilder.keyFailed()
+				return
+			}
+			if kn != nil {
+				qkey = kn.received(qkey)
+			}
+			if dedup != nil && !dedup.enqueue(qkey, new) {
+				return
+			}
+			if verifier != nil && isResync(old, new) {
+				verifier.resynced(qkey)
+			}
+			if cfg.ResyncJitter > 0 && isResync(old, new) {
+				interval := cfg.ResyncInterval
+				if resyncer != nil {
+					interval = resyncer.currentInterval()
+				}
+				queue.AddAfter(context.TODO(), qkey, resyncJitterDelay(interval, cfg.ResyncJitter), false)
+			} else {
+				if warmUp != nil {
+					warmUp.enqueued(qkey)
+				}
+				queue.Add(context.TODO(), qkey)
+			}
+			if restarter != nil {
+				restarter.changed(key, new.(runtime.Object))
+			}
+			if dependents != nil {
+				dependents.enqueue(new)
+			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			atomic.AddInt64(&st.deleteEvents, 1)
+			key, err := keyFunc(obj)
 			if err != nil {
 				cfg.Logger.Warningf("could not add item from 'delete' event to queue: %s", err)
+				rebuilder.keyFailed()
 				return
 			}
+			if kn != nil {
+				key = kn.received(key)
+			}
+			if warmUp != nil {
+				warmUp.deleted(key)
+			}
+			handledDelete := false
+			switch {
+			case sizeDeleted != nil && sizeDeleted.pop(obj):
+				// The object still exists, it has been removed from the cache for being oversized.
+				cfg.Logger.WithKV(log.KV{"object-key": key}).Debugf("deletion skipped, the object exceeds the max object size")
+			case deletes != nil && !deletes.delete(key, obj):
+				cfg.Logger.WithKV(log.KV{"object-key": key}).Debugf("deletion skipped, the object was never handled")
+			default:
+				handledDelete = deletes != nil
+			}
+			// The deletions handled by the delete handler forget the lifecycle once traced.
+			if lifecycle != nil && !handledDelete {
+				lifecycle.forget(obj)
+			}
 			queue.Add(context.TODO(), key)
+			if dependents != nil {
+				dependents.enqueue(obj)
+			}
 		},
-	}, cfg.ResyncInterval)
+	}
+	informer.AddEventHandlerWithResyncPeriod(eventHandler, informerResyncInterval)
+
+	if adaptiveResync {
+		resyncer = newAdaptiveResyncer(latencyObserver, informer.GetIndexer(), eventHandler, cfg.ResyncInterval,
+			cfg.AdaptiveResyncMaxInterval, cfg.AdaptiveResyncLatencyThreshold, cfg.Logger)
+	}
 
 	// Create processing chain: processor(+middlewares) -> handler(+middlewares).
-	processor := newIndexerProcessor(informer.GetIndexer(), cfg.Handler)
-	if cfg.ProcessingJobRetries > 0 {
+	excluded := newExclusionSet()
+	var quarantine *panicQuarantine
+	if cfg.PanicQuarantineThreshold > 0 {
+		quarantine = newPanicQuarantine(cfg.PanicQuarantineThreshold, excluded)
+	}
+	if cfg.DetectObjectMutations {
+		handler = newObjectMutationHandler(cfg.Logger, handler)
+	}
+	handler = newPanicRecoveryHandler(cfg.PanicHandler, quarantine, cfg.Logger, handler)
+	if cfg.LeaseBoundDeadline {
+		handler = newLeaseDeadlineHandler(cfg.LeaderElector.(leaderelection.LeaseRunner).LeaseExpiration, handler)
+	}
+	if cfg.DetectGoroutineLeaks {
+		handler = newGoroutineLeakHandler(cfg.Logger, handler)
+	}
+	if cfg.HandleQPS > 0 {
+		handler = newRateLimitedHandler(flowcontrol.NewTokenBucketRateLimiter(float32(cfg.HandleQPS), cfg.HandleBurst), handler)
+	}
+	if cfg.ConflictRequeueDelay > 0 {
+		handler = newConflictRequeueHandler(cfg.Name, cfg.ConflictRequeueDelay, cfg.MetricsRecorder, cfg.Logger, handler)
+	}
+	if cfg.DumpObjectOnError {
+		handler = newObjectDumpHandler(cfg.DumpObjectRedactFunc, cfg.Logger, handler)
+	}
+	if cfg.EventRecorder != nil && cfg.ErrorEventInterval > 0 {
+		handler = newErrorEventHandler(cfg.EventRecorder, cfg.ErrorEventInterval, handler)
+	}
+	if cfg.ConcurrencyKeyFunc != nil {
+		handler = newConcurrencyKeyHandler(cfg.ConcurrencyKeyFunc, handler)
+	}
+	if cfg.BaggageAnnotation != "" {
+		handler = newBaggageHandler(cfg.BaggageAnnotation, cfg.Tracer, cfg.Logger, handler)
+	}
+	handler = newTracingHandler(cfg.Name, cfg.Tracer, cfg.TraceSampleRate, lifecycle, cfg.TraceSteps, false, handler)
+	if restarter != nil {
+		handler = restarter.handler(handler)
+	}
+	var indexer cache.Indexer = informer.GetIndexer()
+	if cfg.KeyFunc != nil {
+		indexer = keyFuncIndexer{Indexer: indexer, f: keyFunc}
+	}
+	if kn != nil {
+		indexer = normalizedIndexer{Indexer: indexer, kn: kn}
+	}
+	requeuer := newResultRequeuer(queue, cfg.RequeueBackoffBase, cfg.RequeueBackoffMax)
+	processor := newIndexerProcessor(indexer, handler, requeuer)
+	processor = rebuilder.processor(processor)
+	if deletes != nil {
+		deleteHandler = newPanicRecoveryHandler(cfg.PanicHandler, nil, cfg.Logger, deleteHandler)
+		if cfg.LeaseBoundDeadline {
+			deleteHandler = newLeaseDeadlineHandler(cfg.LeaderElector.(leaderelection.LeaseRunner).LeaseExpiration, deleteHandler)
+		}
+		if cfg.BaggageAnnotation != "" {
+			deleteHandler = newBaggageHandler(cfg.BaggageAnnotation, cfg.Tracer, cfg.Logger, deleteHandler)
+		}
+		deleteHandler = newTracingHandler(cfg.Name, cfg.Tracer, cfg.TraceSampleRate, lifecycle, cfg.TraceSteps, true, deleteHandler)
+		processor = newDeleteProcessor(deletes, indexer, deleteHandler, processor)
+	}
+	if verifier != nil {
+		processor = verifier.processor(indexer, processor)
+	}
+	if cfg.SlowHandlingThreshold > 0 {
+		processor = newTimelineProcessor(cfg.SlowHandlingThreshold, cfg.Logger, processor)
+	}
+	if warmUp != nil {
+		processor = newWarmUpProcessor(warmUp, queue, cfg.RequeueBackoffBase, processor)
+	}
+	processor = newStatsProcessor(st, processor)
+	if cfg.Reporter != nil {
+		processor = newReportProcessor(cfg.Reporter, processor)
+	}
+	if cfg.EventStream != nil {
+		processor = newEventStreamProcessor(cfg.EventStream, processor)
+	}
+	if cfg.AuditSink != nil {
+		leader := ""
+		if ir, ok := cfg.LeaderElector.(leaderelection.IdentityRunner); ok {
+			leader = ir.Identity()
+		}
+		processor = newAuditProcessor(cfg.Name, cfg.AuditSink, cfg.Tracer, leader, processor)
+	}
+	switch {
+	case cfg.RetryPolicy != nil:
+		processor = newRetryPolicyProcessor(cfg.RetryPolicy.decider(), cfg.RateLimiter, cfg.Clock, indexer, queue, st, processor)
+	case cfg.MaxRetryDuration > 0:
+		processor = newRetryPolicyProcessor(maxRetryDurationDecider(cfg.MaxRetryDuration), cfg.RateLimiter, cfg.Clock, indexer, queue, st, processor)
+	case cfg.ProcessingJobRetries > 0:
 		processor = newRetryProcessor(cfg.Name, queue, cfg.Logger, processor)
 	}
+	if cfg.OnMaxRetriesExceeded != nil {
+		processor = newGiveUpProcessor(cfg.OnMaxRetriesExceeded, indexer, processor)
+	}
+	if owned != nil {
+		processor = owned.processor(processor)
+	}
+	processor = newOutcomeProcessor(cfg.Name, cfg.MetricsRecorder, indexer, processor)
 	processor = newMetricsProcessor(cfg.Name, cfg.MetricsRecorder, processor)
+	processor = newExclusionProcessor(excluded, cfg.Logger, processor)
+	if cfg.CanaryPercent > 0 {
+		processor = newCanaryProcessor(cfg.CanaryPercent, processor)
+	}
+	if dedup != nil {
+		processor = newContentDedupProcessor(dedup, processor)
+	}
+
+	// Register the name once nothing else can fail, so a failed creation doesn't keep the name.
+	releaseName, err := registerName(cfg.Name, cfg.DuplicateNameBehavior, cfg.Logger)
+	if err != nil {
+		if u, ok := cfg.MetricsRecorder.(ResourceQueueLengthFuncUnregisterer); ok {
+			u.UnregisterResourceQueueLengthFunc(cfg.Name)
+		}
+		return nil, fmt.Errorf("could no create controller: %w: %v", ErrControllerNotValid, err)
+	}
 
 	// Create our generic controller object.
-	return &generic{
-		queue:     queue,
-		informer:  informer,
-		metrics:   cfg.MetricsRecorder,
-		processor: processor,
-		leRunner:  cfg.LeaderElector,
-		cfg:       *cfg,
-		logger:    cfg.Logger,
-	}, nil
+	g := &Generic{
+		queue:           queue,
+		informer:        informer,
+		metrics:         cfg.MetricsRecorder,
+		processor:       processor,
+		leRunner:        cfg.LeaderElector,
+		stopC:           make(chan struct{}),
+		stopGracePeriod: stopGracePeriod(cfg),
+		cfg:             *cfg,
+		logger:          cfg.Logger,
+		initialListErrC: initialListErrC,
+		listRetrier:     listRetrier,
+		stats:           st,
+		excluded:        excluded,
+		degraded:        degraded,
+		snapshotQueue:   snapshotQueue,
+		resyncer:        resyncer,
+		warmUp:          warmUp,
+		dependents:      dependents,
+		filter:          filter,
+		rebuilder:       rebuilder,
+		owned:           owned,
+		priorityQueue:   pq,
+		releaseName:     releaseName,
+	}
+	if cfg.ExposeExpvar {
+		publishExpvar(g)
+	}
+
+	return g, nil
 }
 
-func (g *generic) isRunning() bool {
+func (g *Generic) isRunning() bool {
 	g.runningMu.Lock()
 	defer g.runningMu.Unlock()
 	return g.running
 }
 
-func (g *generic) setRunning(running bool) {
+func (g *Generic) setRunning(running bool) {
 	g.runningMu.Lock()
 	defer g.runningMu.Unlock()
 	g.running = running
 }
 
 // Run will run the controller.
-func (g *generic) Run(ctx context.Context) error {
+func (g *Generic) Run(ctx context.Context) error {
+	// The name and queue metrics are used until the controller is not running anymore.
+	defer g.release()
+
+	select {
+	case <-g.stopC:
+		return fmt.Errorf("controller stopped")
+	default:
+	}
+	ctx, cancel := g.stoppable(ctx)
+	defer cancel()
+
 	// Check if leader election is required.
 	if g.leRunner != nil {
+		// Stop the controller if the leader election runner ends (e.g leadership lost).
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// The demotable runners stop the controller when the leadership is lost, waiting for the in-flight handlings.
+		if dr, ok := g.leRunner.(leaderelection.DemotableRunner); ok {
+			return dr.RunDemotable(ctx, g.run)
+		}
+
 		return g.leRunner.Run(func() error {
 			return g.run(ctx)
 		})
@@ -234,7 +1098,7 @@ func (g *generic) Run(ctx context.Context) error {
 }
 
 // run is the real run of the controller.
-func (g *generic) run(ctx context.Context) error {
+func (g *Generic) run(ctx context.Context) error {
 	if g.isRunning() {
 		return fmt.Errorf("controller already running")
 	}
@@ -242,25 +1106,90 @@ func (g *generic) run(ctx context.Context) error {
 	g.logger.Infof("starting controller")
 	// Set state of controller.
 	g.setRunning(true)
-	defer g.setRunning(false)
+	defer func() {
+		g.runningMu.Lock()
+		g.running, g.synced = false, false
+		g.runningMu.Unlock()
+	}()
+
+	// Stop everything started by the controller when we return, even if the received context is not done.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// Shutdown when Run is stopped so we can process the last items and the queue doesn't
 	// accept more jobs.
 	defer g.queue.ShutDown(ctx)
 
 	// Run the informer so it starts listening to resource events.
+	if g.listRetrier != nil {
+		g.listRetrier.setContext(ctx)
+	}
 	go g.informer.Run(ctx.Done())
+	if g.dependents != nil {
+		go g.dependents.run(ctx)
+	}
+	hasSynced := []cache.InformerSynced{g.informer.HasSynced}
+	if g.owned != nil {
+		g.owned.run(ctx)
+		hasSynced = append(hasSynced, g.owned.hasSynced)
+	}
 
 	// Wait until our store, jobs... stuff is synced (first list on resource, resources on store and jobs on queue).
-	if !cache.WaitForCacheSync(ctx.Done(), g.informer.HasSynced) {
-		return fmt.Errorf("timed out waiting for caches to sync")
+	syncCtx, syncCancel := ctx, context.CancelFunc(func() {})
+	if g.cfg.CacheSyncTimeout > 0 {
+		syncCtx, syncCancel = context.WithTimeout(ctx, g.cfg.CacheSyncTimeout)
+	}
+	defer syncCancel()
+	syncedC := make(chan bool, 1)
+	go func() {
+		syncedC <- cache.WaitForCacheSync(syncCtx.Done(), hasSynced...)
+	}()
+	select {
+	case err := <-g.initialListErrC:
+		return fmt.Errorf("could not list the initial resources: %w", err)
+	case synced := <-syncedC:
+		if !synced {
+			if ctx.Err() != nil {
+				return fmt.Errorf("stopped waiting for caches to sync: %w", ctx.Err())
+			}
+			return fmt.Errorf("%w after %s", ErrCacheSyncTimeout, g.cfg.CacheSyncTimeout)
+		}
+	}
+
+	g.runningMu.Lock()
+	g.synced = true
+	g.runningMu.Unlock()
+
+	for _, k := range g.cfg.InitialQueue {
+		g.queue.Add(ctx, k)
+	}
+
+	if g.warmUp != nil {
+		g.warmUp.start(func(k string) bool {
+			return g.excluded.has(k) || (g.cfg.CanaryPercent > 0 && !inCanary(k, g.cfg.CanaryPercent))
+		})
+		go g.warmUp.wait(ctx, g.cfg.WarmUpTimeout, g.logger)
+	}
+
+	if g.resyncer != nil {
+		go g.resyncer.run(ctx)
+	}
+
+	if g.cfg.OnStatsSample != nil {
+		go wait.Until(func() { g.cfg.OnStatsSample(g.Stats()) }, g.cfg.StatsSampleInterval, ctx.Done())
 	}
 
 	// Start our resource processing worker, if finishes then restart the worker. The workers should
-	// not end.
+	// not end. The workers and the handling context are labeled with the controller name for
+	// profiling (pprof).
+	workerCtx := pprof.WithLabels(g.cfg.BaseContext(), pprof.Labels("controller", g.cfg.Name))
+	var workersWG sync.WaitGroup
 	for i := 0; i < g.cfg.ConcurrentWorkers; i++ {
+		workersWG.Add(1)
 		go func() {
-			wait.Until(g.runWorker, time.Second, ctx.Done())
+			defer workersWG.Done()
+			pprof.SetGoroutineLabels(workerCtx)
+			wait.Until(func() { g.runWorker(workerCtx, ctx.Done()) }, time.Second, ctx.Done())
 		}()
 	}
 
@@ -269,14 +1198,47 @@ func (g *generic) run(ctx context.Context) error {
 	<-ctx.Done()
 	g.logger.Infof("stopping controller")
 
+	if g.stopGracePeriod > 0 {
+		g.drainWorkers(&workersWG)
+	}
+
 	return nil
 }
 
+// stopGracePeriod returns the max time the controller waits for the in-flight handlings when it stops,
+// the shutdown grace period or the leader election demotion grace period if it's longer.
+func stopGracePeriod(cfg *Config) time.Duration {
+	grace := cfg.ShutdownGracePeriod
+	if dr, ok := cfg.LeaderElector.(leaderelection.DemotableRunner); ok && dr.DemotionGracePeriod() > grace {
+		grace = dr.DemotionGracePeriod()
+	}
+	return grace
+}
+
+// drainWorkers shuts down the queue so the idle workers end, and waits until the in-flight handlings
+// finish or the shutdown grace period elapses.
+func (g *Generic) drainWorkers(workersWG *sync.WaitGroup) {
+	g.queue.ShutDown(context.Background())
+
+	doneC := make(chan struct{})
+	go func() {
+		workersWG.Wait()
+		close(doneC)
+	}()
+
+	select {
+	case <-doneC:
+		g.logger.Infof("all in-flight handlings finished")
+	case <-time.After(g.stopGracePeriod):
+		g.logger.Warningf("shutdown grace period of %s elapsed with in-flight handlings", g.stopGracePeriod)
+	}
+}
+
 // runWorker will start a processing loop on event queue.
-func (g *generic) runWorker() {
+func (g *Generic) runWorker(ctx context.Context, stopC <-chan struct{}) {
 	for {
 		// Process next queue job, if needs to stop processing it will return true.
-		if g.processNextJob() {
+		if g.processNextJob(ctx, stopC) {
 			break
 		}
 	}
@@ -285,9 +1247,12 @@ func (g *generic) runWorker() {
 // processNextJob job will process the next job of the queue job and returns if
 // it needs to stop processing.
 //
-// If the queue has been closed then it will end the processing.
-func (g *generic) processNextJob() bool {
-	ctx := context.Background()
+// If the queue has been closed then it will end the processing. When the controller is
+// stopping with a shutdown grace period, the queued jobs will not be processed.
+func (g *Generic) processNextJob(ctx context.Context, stopC <-chan struct{}) bool {
+	if g.stopGracePeriod > 0 && isStopped(stopC) {
+		return true
+	}
 
 	// Get next job.
 	nextJob, exit := g.queue.Get(ctx)
@@ -298,6 +1263,12 @@ func (g *generic) processNextJob() bool {
 	defer g.queue.Done(ctx, nextJob)
 	key := nextJob.(string)
 
+	// The stop could happen while waiting for the job, don't lose it.
+	if g.stopGracePeriod > 0 && isStopped(stopC) {
+		g.queue.Add(ctx, nextJob)
+		return true
+	}
+
 	// Process the job.
 	err := g.processor.Process(ctx, key)
 
@@ -313,3 +1284,15 @@ func (g *generic) processNextJob() bool {
 
 	return false
 }
+
+// isStopped returns true if the stop channel is closed.
+func isStopped(stopC <-chan struct{}) bool {
+	select {
+	case <-stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+var _ Controller = &Generic{}
@@ -3,6 +3,8 @@ package controller_test
 import (
 	"context"
 	"fmt"
+	goruntime "runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -37,10 +39,24 @@ func newNamespaceRetriever(client kubernetes.Interface) controller.Retriever {
 	})
 }
 
+// newFakeNamespaceRetriever returns a retriever that lists the received namespaces and
+// watches the events sent on the returned fake watcher.
+func newFakeNamespaceRetriever(nsl *corev1.NamespaceList) (controller.Retriever, *watch.FakeWatcher) {
+	w := watch.NewFake()
+	return controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return nsl, nil },
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return w, nil },
+	}), w
+}
+
 func onKubeClientListNamespaceReturn(client *fake.Clientset, nss *corev1.NamespaceList) {
 	client.AddReactor("list", "namespaces", func(action kubetesting.Action) (bool, runtime.Object, error) {
 		return true, nss, nil
 	})
+	// Without watch reactor the watches would fail like an unreachable API server.
+	client.AddWatchReactor("namespaces", func(action kubetesting.Action) (bool, watch.Interface, error) {
+		return true, watch.NewFake(), nil
+	})
 }
 
 func createNamespaceList(prefix string, q int) (*corev1.NamespaceList, []*corev1.Namespace) {
@@ -308,8 +324,11 @@ func TestGenericControllerWithLeaderElection(t *testing.T) {
 
 			// Run multiple controller in background.
 			go func() { resultC <- c1.Run(ctx) }()
-			// Let the first controller became the leader.
-			time.Sleep(200 * time.Microsecond)
+			// Let the first controller became the leader (has created the lock).
+			require.Eventually(func() bool {
+				_, err := mc.CoordinationV1().Leases("default").Get(context.TODO(), "test", metav1.GetOptions{})
+				return err == nil
+			}, 1*time.Second, time.Millisecond)
 			go func() { resultC <- c2.Run(ctx) }()
 			go func() { resultC <- c3.Run(ctx) }()
 
@@ -328,3 +347,336 @@ func TestGenericControllerWithLeaderElection(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericControllerInitialListRetries(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 3)
+
+	tests := map[string]struct {
+		listFailures int
+		retries      int
+		expErr       bool
+	}{
+		"If the initial list fails less times than the retries, the controller should start.": {
+			listFailures: 2,
+			retries:      3,
+		},
+		"If the initial list fails more times than the retries, the controller should fail.": {
+			listFailures: 10,
+			retries:      2,
+			expErr:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancelCtx := context.WithCancel(context.Background())
+			defer cancelCtx()
+			resultC := make(chan error)
+
+			// Mocks kubernetes client, fail the first lists.
+			var mu sync.Mutex
+			listCalls := 0
+			mc := &fake.Clientset{}
+			mc.AddReactor("list", "namespaces", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				listCalls++
+				if listCalls <= test.listFailures {
+					return true, nil, fmt.Errorf("wanted error")
+				}
+				return true, nsList, nil
+			})
+
+			handledCalls := len(nsList.Items)
+			h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handledCalls--
+				if handledCalls == 0 {
+					cancelCtx()
+				}
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:                    "test",
+				Handler:                 h,
+				Retriever:               newNamespaceRetriever(mc),
+				InitialListRetries:      test.retries,
+				InitialListRetryBackoff: time.Millisecond,
+				Logger:                  log.Dummy,
+			})
+			require.NoError(err)
+
+			go func() {
+				resultC <- c.Run(ctx)
+			}()
+
+			select {
+			case err := <-resultC:
+				if test.expErr {
+					assert.Error(err)
+				} else if assert.NoError(err) {
+					mu.Lock()
+					assert.Equal(0, handledCalls)
+					assert.Equal(test.listFailures+1, listCalls)
+					mu.Unlock()
+				}
+			case <-time.After(1 * time.Second):
+				assert.Fail("timeout waiting for controller handling, this could mean the controller is not receiving resources")
+			}
+		})
+	}
+}
+
+func TestGenericControllerInitialListRetriesStop(t *testing.T) {
+	tests := map[string]struct {
+		stop func(cancel func(), c *controller.Generic)
+	}{
+		"Cancelling the run context should stop the initial list retries.": {
+			stop: func(cancel func(), _ *controller.Generic) { cancel() },
+		},
+
+		"Stopping the controller should stop the initial list retries.": {
+			stop: func(_ func(), c *controller.Generic) { c.Stop() },
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The lists always fail.
+			listedC := make(chan struct{}, 1)
+			mc := &fake.Clientset{}
+			mc.AddReactor("list", "namespaces", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				select {
+				case listedC <- struct{}{}:
+				default:
+				}
+				return true, nil, fmt.Errorf("wanted error")
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:                    "test",
+				Handler:                 controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+				Retriever:               newNamespaceRetriever(mc),
+				InitialListRetries:      3,
+				InitialListRetryBackoff: time.Hour,
+				Logger:                  log.Dummy,
+			})
+			require.NoError(err)
+			resultC := make(chan error, 1)
+			go func() { resultC <- c.Run(ctx) }()
+
+			// Stop while waiting for the first retry.
+			<-listedC
+			test.stop(cancel, c)
+			select {
+			case <-resultC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for the controller to stop")
+			}
+
+			// The retry wait should not be running anymore.
+			assert.Eventually(func() bool {
+				buf := make([]byte, 1<<20)
+				return !strings.Contains(string(buf[:goruntime.Stack(buf, true)]), "initialListRetrier")
+			}, 1*time.Second, 5*time.Millisecond)
+		})
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Check the documented defaults.
+	def := controller.DefaultConfig()
+	assert.Equal(3, def.ConcurrentWorkers)
+	assert.Equal(3*time.Minute, def.ResyncInterval)
+	assert.Equal(0, def.ProcessingJobRetries)
+	assert.Equal(time.Second, def.InitialListRetryBackoff)
+	assert.Equal(time.Second, def.RequeueBackoffBase)
+	assert.Equal(5*time.Minute, def.RequeueBackoffMax)
+	assert.Equal(50.0, def.DependentsEnqueueQPS)
+	assert.Equal(10, def.DependentsEnqueueBurst)
+	assert.Equal(10*time.Second, def.StatsSampleInterval)
+	assert.Equal(1.0, def.TraceSampleRate)
+
+	// New should set the defaults on the unset fields.
+	cfg := &controller.Config{
+		Name:              "test",
+		Handler:           &controllermock.Handler{},
+		Retriever:         newNamespaceRetriever(&fake.Clientset{}),
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 7,
+	}
+	_, err := controller.New(cfg)
+	require.NoError(err)
+
+	assert.Equal(7, cfg.ConcurrentWorkers)
+	assert.Equal(def.ResyncInterval, cfg.ResyncInterval)
+	assert.Equal(def.ProcessingJobRetries, cfg.ProcessingJobRetries)
+	assert.Equal(def.InitialListRetryBackoff, cfg.InitialListRetryBackoff)
+	assert.Equal(def.RequeueBackoffBase, cfg.RequeueBackoffBase)
+	assert.Equal(def.RequeueBackoffMax, cfg.RequeueBackoffMax)
+	assert.Equal(def.DependentsEnqueueQPS, cfg.DependentsEnqueueQPS)
+	assert.Equal(def.DependentsEnqueueBurst, cfg.DependentsEnqueueBurst)
+	assert.Equal(def.StatsSampleInterval, cfg.StatsSampleInterval)
+	assert.Equal(def.TraceSampleRate, cfg.TraceSampleRate)
+}
+
+// warningLogger is a logger that stores the logged warnings.
+type warningLogger struct {
+	log.Logger
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (w *warningLogger) Warningf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.warnings = append(w.warnings, fmt.Sprintf(format, args...))
+}
+
+func (w *warningLogger) WithKV(log.KV) log.Logger { return w }
+
+func TestGenericControllerConcurrentWorkersDefault(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 5)
+
+	tests := map[string]struct {
+		workers    int
+		expWarning bool
+	}{
+		"Unset concurrent workers should use the default workers and process the objects.": {
+			workers: 0,
+		},
+
+		"Invalid concurrent workers should use the default workers with a warning and process the objects.": {
+			workers:    -2,
+			expWarning: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var wg sync.WaitGroup
+			wg.Add(len(nsList.Items))
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+			logger := &warningLogger{Logger: log.Dummy}
+			cfg := &controller.Config{
+				Name: "test",
+				Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error {
+					wg.Done()
+					return nil
+				}),
+				Retriever:         newNamespaceRetriever(mc),
+				Logger:            logger,
+				MetricsRecorder:   controller.DummyMetricsRecorder,
+				ConcurrentWorkers: test.workers,
+			}
+			c, err := controller.New(cfg)
+			require.NoError(err)
+			assert.Equal(controller.DefaultConfig().ConcurrentWorkers, cfg.ConcurrentWorkers)
+
+			go func() { _ = c.Run(ctx) }()
+
+			doneC := make(chan struct{})
+			go func() { wg.Wait(); close(doneC) }()
+			select {
+			case <-doneC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for the objects to be processed")
+			}
+
+			logger.mu.Lock()
+			defer logger.mu.Unlock()
+			if test.expWarning {
+				assert.Len(logger.warnings, 1)
+			} else {
+				assert.Empty(logger.warnings)
+			}
+		})
+	}
+}
+
+func TestGenericControllerLeaderHandover(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	mc := fake.NewSimpleClientset(nsList)
+	nsret := newNamespaceRetriever(mc)
+	le := leaderelection.NewInMemory(log.Dummy)
+
+	// Track the handled objects of each controller.
+	var mu sync.Mutex
+	handled := map[string][]string{}
+	newHandler := func(id string) controller.Handler {
+		return controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[id] = append(handled[id], obj.(*corev1.Namespace).Name)
+			return nil
+		})
+	}
+	handledBy := func(id string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled[id]...)
+	}
+
+	newCtrl := func(id string) controller.Controller {
+		c, err := controller.New(&controller.Config{
+			Name:          id,
+			Handler:       newHandler(id),
+			Retriever:     nsret,
+			LeaderElector: le.Runner(id),
+			Logger:        log.Dummy,
+		})
+		require.NoError(err)
+		return c
+	}
+	c1 := newCtrl("c1")
+	c2 := newCtrl("c2")
+
+	c1ResultC := make(chan error, 1)
+	go func() { c1ResultC <- c1.Run(ctx) }()
+	require.Eventually(func() bool { return le.Leader() == "c1" }, 1*time.Second, time.Millisecond)
+	go func() { _ = c2.Run(ctx) }()
+
+	// The leader should process the objects.
+	assert.Eventually(func() bool { return len(handledBy("c1")) == 3 }, 1*time.Second, 5*time.Millisecond)
+	assert.Empty(handledBy("c2"))
+
+	// Force the handover, the processing should move to the new leader.
+	require.Eventually(func() bool { return le.ForceHandover("c2") == nil }, 1*time.Second, time.Millisecond)
+	assert.Equal("c2", le.Leader())
+	select {
+	case err := <-c1ResultC:
+		assert.Error(err)
+	case <-time.After(1 * time.Second):
+		assert.Fail("old leader should stop running")
+	}
+	assert.Eventually(func() bool { return len(handledBy("c2")) == 3 }, 1*time.Second, 5*time.Millisecond)
+
+	_, err := mc.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new"}}, metav1.CreateOptions{})
+	require.NoError(err)
+	assert.Eventually(func() bool { return len(handledBy("c2")) == 4 }, 1*time.Second, 5*time.Millisecond)
+	assert.Len(handledBy("c1"), 3)
+}
@@ -0,0 +1,50 @@
+// Package controllerruntime has the helpers to bridge kooper controllers with
+// controller-runtime (https://github.com/kubernetes-sigs/controller-runtime). It's a separate
+// module, so only the users of the bridge depend on controller-runtime.
+package controllerruntime
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// Runnable is a kooper controller adapted to a controller-runtime manager runnable.
+type Runnable struct {
+	ctrl           controller.Controller
+	leaderElection bool
+}
+
+// NewRunnable returns a controller-runtime runnable that runs the kooper controller, this way
+// the controller can be added to a controller-runtime `manager.Manager` and it will be started
+// and stopped with the manager context.
+//
+// The runnable will only run when the manager is the leader (if the manager has the leader election
+// enabled), so the kooper controller should not have its own leader election.
+func NewRunnable(ctrl controller.Controller) *Runnable {
+	return &Runnable{ctrl: ctrl, leaderElection: true}
+}
+
+// WithoutLeaderElection makes the runnable run on all the manager instances, regardless of
+// the leader election of the manager.
+func (r *Runnable) WithoutLeaderElection() *Runnable {
+	r.leaderElection = false
+	return r
+}
+
+// Start satisfies manager.Runnable interface.
+func (r *Runnable) Start(ctx context.Context) error {
+	return r.ctrl.Run(ctx)
+}
+
+// NeedLeaderElection satisfies manager.LeaderElectionRunnable interface.
+func (r *Runnable) NeedLeaderElection() bool {
+	return r.leaderElection
+}
+
+var (
+	_ manager.Runnable               = &Runnable{}
+	_ manager.LeaderElectionRunnable = &Runnable{}
+)
@@ -0,0 +1,79 @@
+package controllerruntime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/adevjoe/kooper/v2/controller/controllerruntime"
+)
+
+// lifecycleController is a controller that tracks its run lifecycle.
+type lifecycleController struct {
+	startedC chan struct{}
+	stoppedC chan struct{}
+}
+
+func (l lifecycleController) Run(ctx context.Context) error {
+	close(l.startedC)
+	<-ctx.Done()
+	close(l.stoppedC)
+	return nil
+}
+
+func TestRunnableLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mgr, err := manager.New(&rest.Config{Host: "http://127.0.0.1:0"}, manager.Options{
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: "0",
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+	})
+	require.NoError(err)
+
+	ctrl := lifecycleController{startedC: make(chan struct{}), stoppedC: make(chan struct{})}
+	require.NoError(mgr.Add(controllerruntime.NewRunnable(ctrl)))
+
+	// Start the manager, the controller should start.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mgrDoneC := make(chan error)
+	go func() { mgrDoneC <- mgr.Start(ctx) }()
+
+	select {
+	case <-ctrl.startedC:
+	case <-time.After(5 * time.Second):
+		require.Fail("controller should be started with the manager")
+	}
+
+	// Stop the manager, the controller should stop.
+	cancel()
+	select {
+	case <-ctrl.stoppedC:
+	case <-time.After(5 * time.Second):
+		require.Fail("controller should be stopped with the manager")
+	}
+
+	select {
+	case err := <-mgrDoneC:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		require.Fail("manager should be stopped")
+	}
+}
+
+func TestRunnableLeaderElection(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(controllerruntime.NewRunnable(nil).NeedLeaderElection())
+	assert.False(controllerruntime.NewRunnable(nil).WithoutLeaderElection().NeedLeaderElection())
+}
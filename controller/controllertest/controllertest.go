@@ -0,0 +1,29 @@
+// Package controllertest has helpers to test the controllers and their behavior.
+package controllertest
+
+import (
+	"time"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// BackoffSchedule returns the delays the controller configuration would wait before retrying
+// the processing of an object that fails on every attempt, without real waiting. The schedule is
+// limited by the configured retries (`ProcessingJobRetries`), after these the object is forgotten.
+// The configured rate limiter (`RateLimiter`) is used if set.
+func BackoffSchedule(cfg controller.Config, attempts int) []time.Duration {
+	const item = "controllertest/backoff-schedule"
+
+	rl := cfg.RateLimiter
+	if rl == nil {
+		rl = controller.DefaultRetryRateLimiter()
+	}
+	defer rl.Forget(item)
+
+	delays := []time.Duration{}
+	for i := 0; i < attempts && i < cfg.ProcessingJobRetries; i++ {
+		delays = append(delays, rl.When(item))
+	}
+
+	return delays
+}
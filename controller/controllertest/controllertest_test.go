@@ -0,0 +1,80 @@
+package controllertest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/controllertest"
+)
+
+func TestBackoffSchedule(t *testing.T) {
+	tests := map[string]struct {
+		cfg      controller.Config
+		attempts int
+		exp      []time.Duration
+	}{
+		"Without retries there shouldn't be retry delays.": {
+			cfg:      controller.Config{},
+			attempts: 5,
+			exp:      []time.Duration{},
+		},
+
+		"The retry delays should be exponential.": {
+			cfg:      controller.Config{ProcessingJobRetries: 10},
+			attempts: 5,
+			exp: []time.Duration{
+				5 * time.Millisecond,
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+				40 * time.Millisecond,
+				80 * time.Millisecond,
+			},
+		},
+
+		"The retry delays should be limited by the retries.": {
+			cfg:      controller.Config{ProcessingJobRetries: 3},
+			attempts: 5,
+			exp: []time.Duration{
+				5 * time.Millisecond,
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+			},
+		},
+
+		"The retry delays should use the configured rate limiter.": {
+			cfg: controller.Config{
+				ProcessingJobRetries: 10,
+				RateLimiter:          workqueue.NewItemExponentialFailureRateLimiter(time.Second, 3*time.Second),
+			},
+			attempts: 4,
+			exp: []time.Duration{
+				1 * time.Second,
+				2 * time.Second,
+				3 * time.Second,
+				3 * time.Second,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got := controllertest.BackoffSchedule(test.cfg, test.attempts)
+			assert.Equal(test.exp, got)
+
+			// By default should match the Kubernetes controllers rate limiter.
+			if test.cfg.RateLimiter != nil {
+				return
+			}
+			rl := workqueue.DefaultControllerRateLimiter()
+			for _, d := range got {
+				assert.Equal(rl.When("test"), d)
+			}
+		})
+	}
+}
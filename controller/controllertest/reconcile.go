@@ -0,0 +1,81 @@
+package controllertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// ReconcileNotifier tracks the objects reconciled by a controller handler so the tests can wait
+// until an object reaches an state, instead of sleeping and hoping the controller has finished.
+type ReconcileNotifier struct {
+	mu      sync.Mutex
+	objs    map[string]runtime.Object
+	notifyC chan struct{}
+}
+
+// NewReconcileNotifier returns a new ReconcileNotifier.
+func NewReconcileNotifier() *ReconcileNotifier {
+	return &ReconcileNotifier{
+		objs:    map[string]runtime.Object{},
+		notifyC: make(chan struct{}),
+	}
+}
+
+// Wrap returns a handler that notifies every successful reconcile of the wrapped handler, the
+// returned handler should be used as the controller handler. If the wrapped handler is a
+// `controller.ResultHandler`, its results are returned to the controller (e.g requeues).
+func (n *ReconcileNotifier) Wrap(next controller.Handler) controller.ResultHandlerFunc {
+	return controller.ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (controller.Result, error) {
+		var res controller.Result
+		var err error
+		if rh, ok := next.(controller.ResultHandler); ok {
+			res, err = rh.HandleWithResult(ctx, obj)
+		} else {
+			err = next.Handle(ctx, obj)
+		}
+		if err != nil {
+			return res, err
+		}
+
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return res, err
+		}
+
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.objs[key] = obj.DeepCopyObject()
+		close(n.notifyC)
+		n.notifyC = make(chan struct{})
+
+		return res, nil
+	})
+}
+
+// WaitForCondition blocks until the last reconciled object of the key satisfies the predicate. The
+// predicate is checked against the already reconciled object and after every reconcile of the key,
+// an error is returned if the context is done before the predicate holds (e.g timeout).
+func (n *ReconcileNotifier) WaitForCondition(ctx context.Context, key string, predicate func(obj runtime.Object) bool) error {
+	for {
+		n.mu.Lock()
+		obj, ok := n.objs[key]
+		notifyC := n.notifyC
+		n.mu.Unlock()
+
+		if ok && predicate(obj) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("condition of %q not satisfied: %w", key, ctx.Err())
+		case <-notifyC:
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package controllertest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/controllertest"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestReconcileNotifierWaitForCondition(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns", ResourceVersion: "1"}}
+	fw := watch.NewFake()
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.NamespaceList{Items: []corev1.Namespace{*ns}}, nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return fw, nil },
+	})
+
+	n := controllertest.NewReconcileNotifier()
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   n.Wrap(controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })),
+		Retriever: ret,
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	isPhase := func(phase corev1.NamespacePhase) func(obj runtime.Object) bool {
+		return func(obj runtime.Object) bool { return obj.(*corev1.Namespace).Status.Phase == phase }
+	}
+
+	// Wait until the initial object has been reconciled.
+	waitCtx, waitCancel := context.WithTimeout(ctx, 1*time.Second)
+	defer waitCancel()
+	err = n.WaitForCondition(waitCtx, "test-ns", func(runtime.Object) bool { return true })
+	require.NoError(err)
+
+	// Change the object and wait until the change has been reconciled.
+	ns = ns.DeepCopy()
+	ns.ResourceVersion = "2"
+	ns.Status.Phase = corev1.NamespaceTerminating
+	fw.Modify(ns)
+	err = n.WaitForCondition(waitCtx, "test-ns", isPhase(corev1.NamespaceTerminating))
+	assert.NoError(err)
+
+	// A condition that never holds should end with the context.
+	shortCtx, shortCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer shortCancel()
+	err = n.WaitForCondition(shortCtx, "test-ns", isPhase(corev1.NamespaceActive))
+	assert.Error(err)
+}
+
+func TestReconcileNotifierWrapResult(t *testing.T) {
+	tests := map[string]struct {
+		handler   controller.Handler
+		expResult controller.Result
+	}{
+		"The result of a result handler should be returned.": {
+			handler: controller.ResultHandlerFunc(func(context.Context, runtime.Object) (controller.Result, error) {
+				return controller.Result{RequeueAfter: time.Minute}, nil
+			}),
+			expResult: controller.Result{RequeueAfter: time.Minute},
+		},
+
+		"A regular handler should return an empty result.": {
+			handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+			expResult: controller.Result{},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			n := controllertest.NewReconcileNotifier()
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}
+			res, err := n.Wrap(test.handler).HandleWithResult(context.TODO(), ns)
+			require.NoError(err)
+			assert.Equal(test.expResult, res)
+
+			// The reconcile should be notified.
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+			assert.NoError(n.WaitForCondition(ctx, "test-ns", func(runtime.Object) bool { return true }))
+		})
+	}
+}
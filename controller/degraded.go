@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// degradedState tracks if the controller can reach the API server. While degraded, the controller
+// keeps handling the objects from its cache and the informer keeps retrying until it recovers.
+type degradedState struct {
+	name   string
+	mrec   MetricsRecorder
+	logger log.Logger
+
+	mu  sync.Mutex
+	err error
+}
+
+func newDegradedState(name string, mrec MetricsRecorder, logger log.Logger) *degradedState {
+	return &degradedState{
+		name:   name,
+		mrec:   mrec,
+		logger: logger,
+	}
+}
+
+func (d *degradedState) failed(err error) {
+	// The expired watches are recovered with a relist, the API server is reachable.
+	if isWatchExpired(err) {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.logger.Warningf("controller degraded, could not reach the API server, handling objects from the cache: %s", err)
+		d.mrec.SetResourceDegraded(context.Background(), d.name, true)
+	}
+	d.err = err
+}
+
+func (d *degradedState) recovered() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		return
+	}
+	d.err = nil
+	d.logger.Infof("controller recovered from degraded state, API server reachable")
+	d.mrec.SetResourceDegraded(context.Background(), d.name, false)
+}
+
+// get returns the error that degraded the controller, nil if not degraded.
+func (d *degradedState) get() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// wrap returns a ListerWatcher that sets the degraded state when the lists and watches fail,
+// and clears it when they succeed again.
+func (d *degradedState) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				d.failed(err)
+				return nil, err
+			}
+			d.recovered()
+			return obj, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				d.failed(err)
+				return nil, err
+			}
+			d.recovered()
+			return w, nil
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// degradedRecorder is a metrics recorder that stores the degraded state changes.
+type degradedRecorder struct {
+	controller.MetricsRecorder
+	mu     sync.Mutex
+	states []bool
+}
+
+func (d *degradedRecorder) SetResourceDegraded(_ context.Context, _ string, degraded bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.states = append(d.states, degraded)
+}
+
+func (d *degradedRecorder) getStates() []bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]bool{}, d.states...)
+}
+
+func TestGenericControllerDegraded(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The retriever will fail while the API server is unavailable.
+	var mu sync.Mutex
+	unavailable := false
+	var w *watch.FakeWatcher
+	isUnavailable := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return unavailable
+	}
+	nsList, _ := createNamespaceList("testing", 3)
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			if isUnavailable() {
+				return nil, fmt.Errorf("connection refused")
+			}
+			return nsList, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+			if isUnavailable() {
+				return nil, fmt.Errorf("connection refused")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			w = watch.NewFake()
+			return w, nil
+		},
+	})
+
+	mrec := &degradedRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:       ret,
+		Logger:          log.Dummy,
+		MetricsRecorder: mrec,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return c.Ready() == nil }, 1*time.Second, 5*time.Millisecond)
+
+	// Make the API server unavailable and drop the watch.
+	mu.Lock()
+	unavailable = true
+	w.Stop()
+	mu.Unlock()
+	assert.Eventually(func() bool { return c.Ready() != nil }, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(c.Ready().Error(), "degraded")
+	assert.Equal([]bool{true}, mrec.getStates())
+
+	// Recover the API server.
+	mu.Lock()
+	unavailable = false
+	mu.Unlock()
+	assert.Eventually(func() bool { return c.Ready() == nil }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal([]bool{true, false}, mrec.getStates())
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// deleteTracker keeps the last known state of the deleted objects until their deletion is
+// handled, and the keys of the objects that have been handled.
+type deleteTracker struct {
+	skipUnhandled bool
+	mu            sync.Mutex
+	deleted       map[string]runtime.Object
+	handled       map[string]bool
+}
+
+func newDeleteTracker(skipUnhandled bool) *deleteTracker {
+	return &deleteTracker{
+		skipUnhandled: skipUnhandled,
+		deleted:       map[string]runtime.Object{},
+		handled:       map[string]bool{},
+	}
+}
+
+// delete stores the last known state of the deleted object, returns false if the deletion
+// should not be handled because the object was never handled.
+func (d *deleteTracker) delete(key string, obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	rtobj, ok := obj.(runtime.Object)
+	if !ok {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	handled := d.handled[key]
+	delete(d.handled, key)
+	if d.skipUnhandled && !handled {
+		return false
+	}
+	d.deleted[key] = rtobj
+
+	return true
+}
+
+// added forgets the deletion of an object that has been created again before handling the deletion.
+func (d *deleteTracker) added(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.deleted, key)
+}
+
+// popDeleted returns the last known state of a deleted object that has not been handled.
+func (d *deleteTracker) popDeleted(key string) (runtime.Object, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	obj, ok := d.deleted[key]
+	delete(d.deleted, key)
+	return obj, ok
+}
+
+// deletedKeyCtxKey is the context key of the deleted object key.
+type deletedKeyCtxKey struct{}
+
+// DeletedObjectKey returns the key of the deleted object that is being handled by the `Config.DeleteHandler`,
+// the key is the same the object had in the controller cache. Returns false if the handling is not a deletion.
+func DeletedObjectKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(deletedKeyCtxKey{}).(string)
+	return key, ok
+}
+
+func (d *deleteTracker) markHandled(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handled[key] = true
+}
+
+// newDeleteProcessor returns a processor that handles the deleted objects with the delete handler,
+// the rest of the objects are processed by the next processor. The delete handlings are not retried,
+// the last known state of the object is dropped once the deletion is processed.
+func newDeleteProcessor(tracker *deleteTracker, indexer cache.Indexer, deleteHandler Handler, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		_, exists, err := indexer.GetByKey(key)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			// Any handling attempt counts as handled, the object could have side effects to clean.
+			tracker.markHandled(key)
+			return next.Process(ctx, key)
+		}
+
+		obj, ok := tracker.popDeleted(key)
+		if !ok {
+			return next.Process(ctx, key)
+		}
+
+		return deleteHandler.Handle(context.WithValue(ctx, deletedKeyCtxKey{}, key), obj)
+	})
+}
@@ -0,0 +1,108 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDeleteHandler(t *testing.T) {
+	tests := map[string]struct {
+		skipUnhandled bool
+		expDeleted    []string
+		expKeys       []string
+	}{
+		"The deleted objects should be handled with their last known state.": {
+			expDeleted: []string{"filtered-1/2", "handled-1/2"},
+			expKeys:    []string{"filtered-1", "handled-1"},
+		},
+
+		"The deleted objects that were never handled should not be handled if configured.": {
+			skipUnhandled: true,
+			expDeleted:    []string{"handled-1/2"},
+			expKeys:       []string{"handled-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsl := &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items: []corev1.Namespace{
+					{ObjectMeta: metav1.ObjectMeta{Name: "filtered-1", ResourceVersion: "1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "handled-1", ResourceVersion: "1"}},
+				},
+			}
+			ret, w := newFakeNamespaceRetriever(nsl)
+
+			var mu sync.Mutex
+			handled := []string{}
+			deleted := []string{}
+			record := func(list *[]string) controller.Handler {
+				return controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ns := obj.(*corev1.Namespace)
+					*list = append(*list, ns.Name+"/"+ns.ResourceVersion)
+					return nil
+				})
+			}
+			get := func(list *[]string) []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]string{}, *list...)
+			}
+
+			deletedKeys := []string{}
+			deleteHandler := controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+				key, ok := controller.DeletedObjectKey(ctx)
+				if ok {
+					mu.Lock()
+					deletedKeys = append(deletedKeys, key)
+					mu.Unlock()
+				}
+				return record(&deleted).Handle(ctx, obj)
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              record(&handled),
+				DeleteHandler:        deleteHandler,
+				SkipUnhandledDeletes: test.skipUnhandled,
+				Retriever:            ret,
+				Logger:               log.Dummy,
+				Filter: func(obj runtime.Object) bool {
+					return !strings.HasPrefix(obj.(*corev1.Namespace).Name, "filtered-")
+				},
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+			assert.Eventually(func() bool { return len(get(&handled)) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+			w.Delete(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "filtered-1", ResourceVersion: "2"}})
+			w.Delete(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "handled-1", ResourceVersion: "2"}})
+
+			assert.Eventually(func() bool { return len(get(&deleted)) == len(test.expDeleted) }, 1*time.Second, 5*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+			assert.ElementsMatch(test.expDeleted, get(&deleted))
+			assert.ElementsMatch(test.expKeys, get(&deletedKeys))
+			assert.Equal([]string{"handled-1/1"}, get(&handled))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/leaderelection"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerLeaderElectionDemotionGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mc := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-2"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-3"}},
+	)
+
+	// The lease renewals will fail when demoting.
+	var demoting int32
+	mc.PrependReactor("update", "leases", func(kubetesting.Action) (bool, runtime.Object, error) {
+		if atomic.LoadInt32(&demoting) == 0 {
+			return false, nil, nil
+		}
+		// Like the real clients, return an empty object with the error.
+		return true, &coordinationv1.Lease{}, fmt.Errorf("wanted error")
+	})
+
+	stoppedC := make(chan struct{})
+	le, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+		LeaseDuration:       400 * time.Millisecond,
+		RenewDeadline:       200 * time.Millisecond,
+		RetryPeriod:         50 * time.Millisecond,
+		DemotionGracePeriod: 5 * time.Second,
+		OnStoppedLeading:    func() { close(stoppedC) },
+	}, mc, log.Dummy)
+	require.NoError(err)
+
+	// The first handling blocks until released.
+	var mu sync.Mutex
+	started, finished := 0, 0
+	startedC := make(chan struct{})
+	releaseC := make(chan struct{})
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+		mu.Lock()
+		started++
+		first := started == 1
+		mu.Unlock()
+		if first {
+			close(startedC)
+			<-releaseC
+		}
+		mu.Lock()
+		finished++
+		mu.Unlock()
+		return nil
+	})
+	counts := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return started, finished
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         newNamespaceRetriever(mc),
+		LeaderElector:     le,
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 1,
+	})
+	require.NoError(err)
+	resultC := make(chan error, 1)
+	go func() { resultC <- c.Run(context.Background()) }()
+
+	select {
+	case <-startedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the handling")
+	}
+
+	// Force the demotion.
+	atomic.StoreInt32(&demoting, 1)
+	select {
+	case <-stoppedC:
+	case <-time.After(2 * time.Second):
+		require.FailNow("timeout waiting for the demotion")
+	}
+
+	// While demoting, the in-flight handling should be waited and no new handlings should start.
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(resultC)
+	gotStarted, gotFinished := counts()
+	assert.Equal(1, gotStarted)
+	assert.Equal(0, gotFinished)
+
+	close(releaseC)
+	select {
+	case err := <-resultC:
+		assert.Error(err)
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the run to end")
+	}
+	time.Sleep(50 * time.Millisecond)
+	gotStarted, gotFinished = counts()
+	assert.Equal(1, gotStarted)
+	assert.Equal(1, gotFinished)
+}
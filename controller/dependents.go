@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// dependentsEnqueuer enqueues the dependents of the changed objects in a rate limited way, the
+// dependents that are already pending to be enqueued are coalesced, so a storm of changes on a
+// widely referenced object doesn't end in an enqueue storm.
+type dependentsEnqueuer struct {
+	mu      sync.Mutex
+	pending map[string]bool
+	order   []string
+	notifyC chan struct{}
+	limiter flowcontrol.RateLimiter
+	queue   blockingQueue
+	depsF   func(obj runtime.Object) []string
+	// direct enqueues the dependents right away, without rate limiting nor coalescing.
+	direct bool
+}
+
+func newDependentsEnqueuer(qps float64, burst int, depsF func(obj runtime.Object) []string, queue blockingQueue) *dependentsEnqueuer {
+	return &dependentsEnqueuer{
+		pending: map[string]bool{},
+		notifyC: make(chan struct{}, 1),
+		limiter: flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst),
+		queue:   queue,
+		depsF:   depsF,
+	}
+}
+
+// enqueue will enqueue the dependents of the object.
+func (d *dependentsEnqueuer) enqueue(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	rtobj, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+
+	keys := d.depsF(rtobj)
+	if len(keys) == 0 {
+		return
+	}
+
+	if d.direct {
+		for _, k := range keys {
+			d.queue.Add(context.TODO(), k)
+		}
+		return
+	}
+
+	d.mu.Lock()
+	for _, k := range keys {
+		if d.pending[k] {
+			continue
+		}
+		d.pending[k] = true
+		d.order = append(d.order, k)
+	}
+	d.mu.Unlock()
+
+	select {
+	case d.notifyC <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the next pending dependent, false if there aren't pending dependents.
+func (d *dependentsEnqueuer) next() (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.order) == 0 {
+		return "", false
+	}
+
+	key := d.order[0]
+	d.order = d.order[1:]
+	delete(d.pending, key)
+	return key, true
+}
+
+func (d *dependentsEnqueuer) hasPending() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.order) > 0
+}
+
+// run enqueues the pending dependents until the context is done.
+func (d *dependentsEnqueuer) run(ctx context.Context) {
+	for {
+		if !d.hasPending() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.notifyC:
+				continue
+			}
+		}
+
+		// Wait before getting the dependent, so the new changes are coalesced while we wait.
+		if err := d.limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		key, ok := d.next()
+		if ok {
+			d.queue.Add(ctx, key)
+		}
+	}
+}
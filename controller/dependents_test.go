@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDependentsRateLimited(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A shared object with 500 dependents.
+	const totalDeps = 500
+	shared := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared", ResourceVersion: "1"}}
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{*shared},
+	}
+	deps := []string{}
+	for i := 0; i < totalDeps; i++ {
+		name := fmt.Sprintf("dep-%d", i)
+		deps = append(deps, name)
+		nsl.Items = append(nsl.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: "1"}})
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var handledDeps int64
+	var fanOut int32
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		if atomic.LoadInt32(&fanOut) == 1 && obj.(*corev1.Namespace).Name != "shared" {
+			atomic.AddInt64(&handledDeps, 1)
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: ret,
+		Logger:    log.Dummy,
+		DependentsFunc: func(obj runtime.Object) []string {
+			ns := obj.(*corev1.Namespace)
+			if ns.Name != "shared" || ns.ResourceVersion == "1" {
+				return nil
+			}
+			return deps
+		},
+		DependentsEnqueueQPS:   1000,
+		DependentsEnqueueBurst: 1,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Wait until the initial objects are handled.
+	require.Eventually(func() bool { return c.Stats().Processed == totalDeps+1 }, 1*time.Second, 5*time.Millisecond)
+
+	// Change the shared object multiple times, the dependents enqueues should be coalesced and rate limited.
+	atomic.StoreInt32(&fanOut, 1)
+	for i := 2; i < 5; i++ {
+		shared = shared.DeepCopy()
+		shared.ResourceVersion = fmt.Sprintf("%d", i)
+		w.Modify(shared)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Less(atomic.LoadInt64(&handledDeps), int64(totalDeps/2), "dependents enqueues should be rate limited")
+
+	assert.Eventually(func() bool { return atomic.LoadInt64(&handledDeps) >= totalDeps }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Less(atomic.LoadInt64(&handledDeps), int64(2*totalDeps), "dependents enqueues should be coalesced")
+}
@@ -0,0 +1,72 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDeterministic(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 20)
+	nsList.ResourceVersion = "1"
+	ret, w := newFakeNamespaceRetriever(nsList)
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		return nil
+	})
+	handledNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	cfg := &controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         ret,
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 10,
+		// Would collapse all the objects.
+		ContentHashFunc: func(runtime.Object) string { return "same" },
+		Deterministic:   true,
+	}
+	c, err := controller.New(cfg)
+	require.NoError(err)
+	assert.Equal(1, cfg.ConcurrentWorkers)
+	go func() { _ = c.Run(ctx) }()
+
+	expHandled := []string{}
+	for _, ns := range nsList.Items {
+		expHandled = append(expHandled, ns.Name)
+	}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("watched-%d", i)
+		w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: fmt.Sprintf("%d", i+2)}})
+		expHandled = append(expHandled, name)
+	}
+
+	// The objects should be handled strictly in enqueue order.
+	assert.Eventually(func() bool { return len(handledNames()) == len(expHandled) }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(expHandled, handledNames())
+}
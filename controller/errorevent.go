@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// ErrorEventReason is the reason of the Kubernetes events of the handling errors.
+const ErrorEventReason = "HandlingFailed"
+
+// newErrorEventHandler returns a handler that mirrors the handling errors to Kubernetes Warning events
+// on the object, at most one event per interval and object while the object keeps failing.
+func newErrorEventHandler(recorder record.EventRecorder, interval time.Duration, next Handler) Handler {
+	var mu sync.Mutex
+	lastEvents := map[string]time.Time{}
+
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		res, err := handleWithResult(ctx, next, obj)
+		key, keyErr := cache.MetaNamespaceKeyFunc(obj)
+		if keyErr != nil {
+			return res, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// The object is not failing anymore.
+		if err == nil {
+			delete(lastEvents, key)
+			return res, err
+		}
+
+		if last, ok := lastEvents[key]; ok && time.Since(last) < interval {
+			return res, err
+		}
+		lastEvents[key] = time.Now()
+		recorder.Eventf(obj, corev1.EventTypeWarning, ErrorEventReason, "Error handling object: %s", err)
+
+		return res, err
+	})
+}
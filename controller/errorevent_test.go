@@ -0,0 +1,63 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerErrorEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// The object fails always, with 6 retries it will be handled at 0, 5, 15, 35, 75, 155 and 315ms.
+	var calls int32
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+		atomic.AddInt32(&calls, 1)
+		return fmt.Errorf("wanted error")
+	})
+
+	recorder := record.NewFakeRecorder(100)
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            newNamespaceRetriever(mc),
+		Logger:               log.Dummy,
+		ProcessingJobRetries: 6,
+		EventRecorder:        recorder,
+		ErrorEventInterval:   100 * time.Millisecond,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	require.Eventually(func() bool { return atomic.LoadInt32(&calls) == 7 }, 2*time.Second, 5*time.Millisecond)
+
+	// With a 100ms interval, the events should be posted at 0, 155 and 315ms.
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Len(events, 3)
+	for _, e := range events {
+		assert.True(strings.HasPrefix(e, "Warning "+controller.ErrorEventReason), e)
+		assert.Contains(e, "wanted error")
+	}
+}
@@ -0,0 +1,65 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerEventCounts(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-2", ResourceVersion: "1"}},
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	handledC := make(chan struct{}, 10)
+	c, err := controller.New(&controller.Config{
+		Name: "test",
+		Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error {
+			handledC <- struct{}{}
+			return nil
+		}),
+		Retriever: ret,
+		Logger:    log.Dummy,
+		// Filter everything, the raw events should be counted anyway.
+		Filter: func(runtime.Object) bool { return false },
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return c.EventCounts().Add == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-3", ResourceVersion: "2"}})
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "3"}})
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-2", ResourceVersion: "4"}})
+	w.Delete(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-3", ResourceVersion: "5"}})
+
+	exp := controller.EventCounts{Add: 3, Update: 2, Delete: 1}
+	assert.Eventually(func() bool { return c.EventCounts() == exp }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(exp, c.EventCounts())
+
+	// Nothing should have been handled.
+	assert.Len(handledC, 0)
+
+	// Reset should set the counts to zero.
+	c.ResetStats()
+	assert.Equal(controller.EventCounts{}, c.EventCounts())
+}
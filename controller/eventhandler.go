@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EventHandler knows how to handle the objects depending on the kind of change, unlike `Handler`
+// that receives every change (adds, updates and resyncs) in the same way.
+type EventHandler interface {
+	// OnAdd handles an object that has not been handled before.
+	OnAdd(ctx context.Context, obj runtime.Object) error
+	// OnUpdate handles a change of an already handled object (resyncs included), oldObj is the
+	// last state of the object that was handled successfully.
+	OnUpdate(ctx context.Context, oldObj, newObj runtime.Object) error
+	// OnDelete handles a deleted object with its last known state, the delete handlings are not retried.
+	OnDelete(ctx context.Context, obj runtime.Object) error
+}
+
+// EventHandlerFuncs is a helper to create event handlers, the nil funcs will be ignored.
+type EventHandlerFuncs struct {
+	AddFunc    func(ctx context.Context, obj runtime.Object) error
+	UpdateFunc func(ctx context.Context, oldObj, newObj runtime.Object) error
+	DeleteFunc func(ctx context.Context, obj runtime.Object) error
+}
+
+// OnAdd satisfies EventHandler interface.
+func (e EventHandlerFuncs) OnAdd(ctx context.Context, obj runtime.Object) error {
+	if e.AddFunc == nil {
+		return nil
+	}
+	return e.AddFunc(ctx, obj)
+}
+
+// OnUpdate satisfies EventHandler interface.
+func (e EventHandlerFuncs) OnUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	if e.UpdateFunc == nil {
+		return nil
+	}
+	return e.UpdateFunc(ctx, oldObj, newObj)
+}
+
+// OnDelete satisfies EventHandler interface.
+func (e EventHandlerFuncs) OnDelete(ctx context.Context, obj runtime.Object) error {
+	if e.DeleteFunc == nil {
+		return nil
+	}
+	return e.DeleteFunc(ctx, obj)
+}
+
+// eventHandlerAdapter adapts an event handler to the controller handlers, it keeps the last handled
+// state of the objects to know if the object is new and to pass the old object on the updates.
+type eventHandlerAdapter struct {
+	eh      EventHandler
+	keyFunc cache.KeyFunc
+	mu      sync.Mutex
+	handled map[string]runtime.Object
+}
+
+func newEventHandlerAdapter(eh EventHandler, keyFunc cache.KeyFunc) *eventHandlerAdapter {
+	return &eventHandlerAdapter{
+		eh:      eh,
+		keyFunc: keyFunc,
+		handled: map[string]runtime.Object{},
+	}
+}
+
+// handler returns the handler of the adds and updates.
+func (e *eventHandlerAdapter) handler() Handler {
+	return HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		key, err := e.keyFunc(obj)
+		if err != nil {
+			return fmt.Errorf("could not get object key: %w", err)
+		}
+
+		e.mu.Lock()
+		old, ok := e.handled[key]
+		e.mu.Unlock()
+
+		if ok {
+			err = e.eh.OnUpdate(ctx, old, obj)
+		} else {
+			err = e.eh.OnAdd(ctx, obj)
+		}
+		if err != nil {
+			return err
+		}
+
+		e.mu.Lock()
+		e.handled[key] = obj
+		e.mu.Unlock()
+
+		return nil
+	})
+}
+
+// deleteHandler returns the handler of the deletions.
+func (e *eventHandlerAdapter) deleteHandler() Handler {
+	return HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		if key, ok := DeletedObjectKey(ctx); ok {
+			e.mu.Lock()
+			delete(e.handled, key)
+			e.mu.Unlock()
+		}
+
+		return e.eh.OnDelete(ctx, obj)
+	})
+}
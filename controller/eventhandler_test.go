@@ -0,0 +1,115 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerEventHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}},
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	events := []string{}
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	getEvents := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, events...)
+	}
+	rv := func(obj runtime.Object) string {
+		ns := obj.(*corev1.Namespace)
+		return ns.Name + "/" + ns.ResourceVersion
+	}
+
+	failUpdate := true
+	c, err := controller.New(&controller.Config{
+		Name: "test",
+		EventHandler: controller.EventHandlerFuncs{
+			AddFunc: func(_ context.Context, obj runtime.Object) error {
+				record("add " + rv(obj))
+				return nil
+			},
+			UpdateFunc: func(_ context.Context, oldObj, newObj runtime.Object) error {
+				record("update " + rv(oldObj) + " -> " + rv(newObj))
+				mu.Lock()
+				defer mu.Unlock()
+				if failUpdate {
+					failUpdate = false
+					return fmt.Errorf("wanted error")
+				}
+				return nil
+			},
+			DeleteFunc: func(_ context.Context, obj runtime.Object) error {
+				record("delete " + rv(obj))
+				return nil
+			},
+		},
+		Retriever:            ret,
+		Logger:               log.Dummy,
+		ProcessingJobRetries: 1,
+		DisableResync:        true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getEvents()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	// The failed update should be retried with the last handled object as the old one.
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(getEvents()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "3"}})
+	assert.Eventually(func() bool { return len(getEvents()) == 4 }, 1*time.Second, 5*time.Millisecond)
+
+	// Objects added after a deletion should be new objects.
+	w.Delete(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "4"}})
+	assert.Eventually(func() bool { return len(getEvents()) == 5 }, 1*time.Second, 5*time.Millisecond)
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "5"}})
+	assert.Eventually(func() bool { return len(getEvents()) == 6 }, 1*time.Second, 5*time.Millisecond)
+
+	exp := []string{
+		"add ns-1/1",
+		"update ns-1/1 -> ns-1/2",
+		"update ns-1/1 -> ns-1/2",
+		"update ns-1/2 -> ns-1/3",
+		"delete ns-1/4",
+		"add ns-1/5",
+	}
+	assert.Equal(exp, getEvents())
+}
+
+func TestGenericControllerEventHandlerWithHandler(t *testing.T) {
+	_, err := controller.New(&controller.Config{
+		Name:         "test",
+		Handler:      controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		EventHandler: controller.EventHandlerFuncs{},
+		Retriever:    newNamespaceRetriever(nil),
+		Logger:       log.Dummy,
+	})
+	assert.Error(t, err)
+}
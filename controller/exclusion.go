@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// exclusionSet is a concurrency safe set of excluded object keys.
+type exclusionSet struct {
+	mu   sync.RWMutex
+	keys map[string]struct{}
+}
+
+func newExclusionSet() *exclusionSet {
+	return &exclusionSet{keys: map[string]struct{}{}}
+}
+
+func (e *exclusionSet) add(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys[key] = struct{}{}
+}
+
+// remove removes the key from the set and returns if it was present.
+func (e *exclusionSet) remove(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.keys[key]
+	delete(e.keys, key)
+	return ok
+}
+
+func (e *exclusionSet) has(key string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.keys[key]
+	return ok
+}
+
+// newExclusionProcessor returns a processor that will skip the processing of the excluded keys.
+func newExclusionProcessor(excluded *exclusionSet, logger log.Logger, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		if excluded.has(key) {
+			logger.WithKV(log.KV{"object-key": key}).Debugf("object excluded, skipping processing")
+			return nil
+		}
+
+		return next.Process(ctx, key)
+	})
+}
+
+// Exclude skips the handling of the object key until it's included again, the object
+// will be kept on the cache.
+func (g *Generic) Exclude(key string) {
+	g.excluded.add(key)
+	g.logger.WithKV(log.KV{"object-key": key}).Infof("object excluded from processing")
+}
+
+// Include removes the object key from the excluded ones and processes it again.
+func (g *Generic) Include(key string) {
+	if !g.excluded.remove(key) {
+		return
+	}
+
+	// Process the object again so we don't wait until the next event.
+	g.logger.WithKV(log.KV{"object-key": key}).Infof("object included again for processing")
+	g.queue.Add(context.Background(), key)
+}
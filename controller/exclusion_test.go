@@ -0,0 +1,63 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerExclusion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 5)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[obj.(*corev1.Namespace).Name]++
+		return nil
+	})
+	getHandled := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled[name]
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: newNamespaceRetriever(mc),
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+
+	// Exclude an object before starting.
+	c.Exclude("testing-2")
+	go func() { _ = c.Run(ctx) }()
+
+	// All except the excluded should be handled.
+	assert.Eventually(func() bool { return c.Stats().Processed == 4 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(0, getHandled("testing-2"))
+	assert.Equal(1, getHandled("testing-1"))
+
+	// Including the object again should process it.
+	c.Include("testing-2")
+	assert.Eventually(func() bool { return getHandled("testing-2") == 1 }, 1*time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"expvar"
+	"sync"
+)
+
+// ExpvarName is the name of the expvar variable where the controllers publish their
+// counters (check `Config.ExposeExpvar`), keyed by the controller name.
+const ExpvarName = "kooper.controllers"
+
+var (
+	expvarControllersOnce sync.Once
+	expvarControllers     *expvar.Map
+)
+
+// publishExpvar publishes the controller counters on expvar, the values are computed
+// every time the variable is read.
+func publishExpvar(g *Generic) {
+	// Only publish the variable if any controller uses it.
+	expvarControllersOnce.Do(func() {
+		expvarControllers = expvar.NewMap(ExpvarName)
+	})
+
+	expvarControllers.Set(g.cfg.Name, expvar.Func(func() interface{} {
+		s := g.Stats()
+		return map[string]interface{}{
+			"processed":    s.Processed,
+			"errored":      s.Errored,
+			"requeued":     s.Requeued,
+			"forgotten":    s.Forgotten,
+			"queue_length": s.QueueLength,
+			// The controller only runs while it's the leader (if leader election is used).
+			"leader": g.isRunning(),
+		}
+	}))
+}
@@ -0,0 +1,84 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerExpvar(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 5)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		if obj.(*corev1.Namespace).Name == "testing-2" {
+			return fmt.Errorf("wanted error")
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:         "test-expvar",
+		Handler:      h,
+		Retriever:    newNamespaceRetriever(mc),
+		Logger:       log.Dummy,
+		ExposeExpvar: true,
+	})
+	require.NoError(err)
+
+	// Get the controller values as `/debug/vars` would do.
+	values := func() map[string]interface{} {
+		m, ok := expvar.Get(controller.ExpvarName).(*expvar.Map)
+		require.True(ok)
+		v := m.Get("test-expvar")
+		require.NotNil(v)
+		res := map[string]interface{}{}
+		require.NoError(json.Unmarshal([]byte(v.String()), &res))
+		return res
+	}
+
+	// Not running.
+	assert.Equal(map[string]interface{}{
+		"processed":    0.0,
+		"errored":      0.0,
+		"requeued":     0.0,
+		"forgotten":    0.0,
+		"queue_length": 0.0,
+		"leader":       false,
+	}, values())
+
+	// The values should be updated while running.
+	go func() { _ = c.Run(ctx) }()
+
+	exp := map[string]interface{}{
+		"processed":    5.0,
+		"errored":      1.0,
+		"requeued":     0.0,
+		"forgotten":    0.0,
+		"queue_length": 0.0,
+		"leader":       true,
+	}
+	assert.Eventually(func() bool {
+		v := values()
+		return v["processed"] == exp["processed"] && v["leader"] == true
+	}, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(exp, values())
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// FeatureFlagConfig is the feature flag runner configuration.
+type FeatureFlagConfig struct {
+	// Enabled checks if the feature is enabled.
+	Enabled func() bool
+	// NewController returns a new controller, every time the feature is enabled a new
+	// controller will be created.
+	NewController func() (Controller, error)
+	// CheckInterval is the interval the feature flag will be checked. By default 10s.
+	CheckInterval time.Duration
+	// Logger will log messages of the runner.
+	Logger log.Logger
+}
+
+func (c *FeatureFlagConfig) setDefaults() error {
+	if c.Enabled == nil {
+		return fmt.Errorf("a feature flag check is required")
+	}
+
+	if c.NewController == nil {
+		return fmt.Errorf("a controller factory is required")
+	}
+
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = 10 * time.Second
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.NewStd(false)
+		c.Logger.Warningf("no logger specified, fallback to default logger, to disable logging use a explicit Noop logger")
+	}
+	c.Logger = c.Logger.WithKV(log.KV{
+		"service": "kooper.feature-flag",
+	})
+
+	return nil
+}
+
+// FeatureFlagRunner runs a controller only while a feature flag is enabled. When the feature
+// is disabled the controller is stopped, and when it's enabled again a new controller is created
+// and started.
+type FeatureFlagRunner struct {
+	cfg    FeatureFlagConfig
+	logger log.Logger
+}
+
+// NewFeatureFlagRunner returns a new feature flag runner.
+func NewFeatureFlagRunner(cfg FeatureFlagConfig) (*FeatureFlagRunner, error) {
+	err := cfg.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("could no create feature flag runner: %w", err)
+	}
+
+	return &FeatureFlagRunner{
+		cfg:    cfg,
+		logger: cfg.Logger,
+	}, nil
+}
+
+// Run runs the feature flag runner and blocks until the context is `Done`.
+func (f *FeatureFlagRunner) Run(ctx context.Context) error {
+	var (
+		stop  func()
+		doneC chan struct{}
+	)
+	stopCtrl := func() {
+		if stop == nil {
+			return
+		}
+		stop()
+		<-doneC
+		stop, doneC = nil, nil
+	}
+	defer stopCtrl()
+
+	ticker := time.NewTicker(f.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// Check if the controller finished by itself.
+		if doneC != nil {
+			select {
+			case <-doneC:
+				stop, doneC = nil, nil
+			default:
+			}
+		}
+
+		enabled := f.cfg.Enabled()
+		switch {
+		case enabled && stop == nil:
+			ctrl, err := f.cfg.NewController()
+			if err != nil {
+				f.logger.Errorf("could not create controller: %s", err)
+				break
+			}
+
+			ctrlCtx, cancel := context.WithCancel(ctx)
+			stop, doneC = cancel, make(chan struct{})
+			go func(doneC chan struct{}) {
+				defer close(doneC)
+				if err := ctrl.Run(ctrlCtx); err != nil {
+					f.logger.Errorf("controller stopped with error: %s", err)
+				}
+			}(doneC)
+			f.logger.Infof("feature enabled, controller started")
+
+		case !enabled && stop != nil:
+			stopCtrl()
+			f.logger.Infof("feature disabled, controller stopped")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package controller_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// runCountController is a controller that tracks the running controllers.
+type runCountController struct {
+	running *int32
+}
+
+func (r runCountController) Run(ctx context.Context) error {
+	atomic.AddInt32(r.running, 1)
+	defer atomic.AddInt32(r.running, -1)
+	<-ctx.Done()
+	return nil
+}
+
+func TestFeatureFlagRunner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var enabled, running, created int32
+	r, err := controller.NewFeatureFlagRunner(controller.FeatureFlagConfig{
+		Enabled: func() bool { return atomic.LoadInt32(&enabled) == 1 },
+		NewController: func() (controller.Controller, error) {
+			atomic.AddInt32(&created, 1)
+			return runCountController{running: &running}, nil
+		},
+		CheckInterval: 5 * time.Millisecond,
+		Logger:        log.Dummy,
+	})
+	require.NoError(err)
+
+	runDoneC := make(chan struct{})
+	go func() {
+		_ = r.Run(ctx)
+		close(runDoneC)
+	}()
+
+	isRunning := func() bool { return atomic.LoadInt32(&running) == 1 }
+	isStopped := func() bool { return atomic.LoadInt32(&running) == 0 }
+
+	// Disabled, should not run.
+	time.Sleep(20 * time.Millisecond)
+	assert.True(isStopped())
+	assert.Equal(int32(0), atomic.LoadInt32(&created))
+
+	// Enabled, should start.
+	atomic.StoreInt32(&enabled, 1)
+	assert.Eventually(isRunning, 1*time.Second, time.Millisecond)
+	assert.Equal(int32(1), atomic.LoadInt32(&created))
+
+	// Disabled, should stop.
+	atomic.StoreInt32(&enabled, 0)
+	assert.Eventually(isStopped, 1*time.Second, time.Millisecond)
+
+	// Enabled again, should start a new controller.
+	atomic.StoreInt32(&enabled, 1)
+	assert.Eventually(isRunning, 1*time.Second, time.Millisecond)
+	assert.Equal(int32(2), atomic.LoadInt32(&created))
+
+	// Stopping the runner should stop the controller.
+	cancel()
+	select {
+	case <-runDoneC:
+	case <-time.After(1 * time.Second):
+		require.Fail("runner should stop")
+	}
+	assert.True(isStopped())
+}
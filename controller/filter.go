@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// objectFilter is the filter of the objects to enqueue, can be swapped atomically while running.
+type objectFilter struct {
+	namespace string
+	v         atomic.Value
+}
+
+// filterHolder is required because atomic.Value can't store nil values.
+type filterHolder struct {
+	f func(obj runtime.Object) bool
+}
+
+func newObjectFilter(namespace string, f func(obj runtime.Object) bool) *objectFilter {
+	o := &objectFilter{namespace: namespace}
+	o.set(f)
+	return o
+}
+
+func (o *objectFilter) set(f func(obj runtime.Object) bool) {
+	o.v.Store(filterHolder{f: f})
+}
+
+// match returns true if the object should be enqueued. The namespace filter is static,
+// only the filter func can be replaced.
+func (o *objectFilter) match(obj interface{}) bool {
+	rtobj, ok := obj.(runtime.Object)
+	if !ok {
+		return true
+	}
+
+	if !namespaceMatch(o.namespace, rtobj) {
+		return false
+	}
+
+	f := o.v.Load().(filterHolder).f
+	if f == nil {
+		return true
+	}
+
+	return f(rtobj)
+}
+
+// SetFilter replaces atomically the filter of the objects to enqueue (check `Config.Filter`),
+// only the future events are affected, the already queued objects will be processed.
+func (g *Generic) SetFilter(filter func(obj runtime.Object) bool) {
+	g.filter.set(filter)
+}
@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerSetFilter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "team-a-1", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "team-b-1", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "other-1", ResourceVersion: "1"}},
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		return nil
+	})
+	handledNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	prefixFilter := func(prefix string) func(obj runtime.Object) bool {
+		return func(obj runtime.Object) bool {
+			return strings.HasPrefix(obj.(*corev1.Namespace).Name, prefix)
+		}
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         ret,
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 1,
+		Filter:            prefixFilter("team-"),
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The static filter should be used.
+	assert.Eventually(func() bool { return len(handledNames()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	assert.ElementsMatch([]string{"team-a-1", "team-b-1"}, handledNames())
+
+	// Tighten the filter at runtime, the objects that don't match anymore should not be enqueued.
+	c.SetFilter(prefixFilter("team-a-"))
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b-1", ResourceVersion: "2"}})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b-2", ResourceVersion: "2"}})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a-2", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(handledNames()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal([]string{"team-a-2"}, handledNames()[2:])
+
+	// Removing the filter should enqueue all the objects.
+	c.SetFilter(nil)
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-2", ResourceVersion: "3"}})
+	assert.Eventually(func() bool { return len(handledNames()) == 4 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal("other-2", handledNames()[3])
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ForEachConcurrent calls fn for every item with at most `concurrency` calls running at the same time
+// (if 0 or less, 1 will be used), useful on handlers that need to create or update many child objects.
+// The failed items don't stop the others, all the errors are returned aggregated. When the context is
+// done, the pending items will not be processed and the context error will be returned with the rest.
+func ForEachConcurrent[T any](ctx context.Context, items []T, concurrency int, fn func(ctx context.Context, item T) error) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	addErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	sem := make(chan struct{}, concurrency)
+loop:
+	for _, item := range items {
+		// Check first the context, a select with both ready picks one randomly.
+		if ctx.Err() != nil {
+			addErr(ctx.Err())
+			break
+		}
+		select {
+		case <-ctx.Done():
+			addErr(ctx.Err())
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(item T) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctx, item); err != nil {
+				addErr(err)
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
+}
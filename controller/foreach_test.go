@@ -0,0 +1,120 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+func TestForEachConcurrent(t *testing.T) {
+	errWanted := errors.New("wanted error")
+
+	tests := map[string]struct {
+		items          int
+		concurrency    int
+		failEvery      int
+		cancelCtx      bool
+		expMaxRunning  int64
+		expCalls       int64
+		expErrs        int
+		expCtxCanceled bool
+	}{
+		"Without errors all the items should be processed with the concurrency bound.": {
+			items:         20,
+			concurrency:   4,
+			expMaxRunning: 4,
+			expCalls:      20,
+		},
+
+		"A zero concurrency should process the items one by one.": {
+			items:         5,
+			concurrency:   0,
+			expMaxRunning: 1,
+			expCalls:      5,
+		},
+
+		"The errors should not stop the processing and should be aggregated.": {
+			items:         20,
+			concurrency:   4,
+			failEvery:     5,
+			expMaxRunning: 4,
+			expCalls:      20,
+			expErrs:       4,
+		},
+
+		"A done context should not process the items.": {
+			items:          20,
+			concurrency:    4,
+			cancelCtx:      true,
+			expMaxRunning:  0,
+			expCalls:       0,
+			expErrs:        1,
+			expCtxCanceled: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancelCtx {
+				cancel()
+			}
+
+			items := make([]int, test.items)
+			for i := range items {
+				items[i] = i + 1
+			}
+
+			var mu sync.Mutex
+			var running, maxRunning, calls int64
+			err := controller.ForEachConcurrent(ctx, items, test.concurrency, func(_ context.Context, item int) error {
+				atomic.AddInt64(&calls, 1)
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+
+				if test.failEvery > 0 && item%test.failEvery == 0 {
+					return fmt.Errorf("item %d: %w", item, errWanted)
+				}
+				return nil
+			})
+
+			assert.Equal(test.expCalls, calls)
+			assert.Equal(test.expMaxRunning, maxRunning)
+			if test.expErrs == 0 {
+				assert.NoError(err)
+				return
+			}
+
+			var agg interface{ Errors() []error }
+			if assert.ErrorAs(err, &agg) {
+				assert.Len(agg.Errors(), test.expErrs)
+			}
+			if test.expCtxCanceled {
+				assert.ErrorIs(err, context.Canceled)
+			} else {
+				assert.ErrorIs(err, errWanted)
+			}
+		})
+	}
+}
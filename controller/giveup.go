@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newGiveUpProcessor returns a processor that calls the hook when the processing of an object failed and
+// it will not be retried anymore (e.g the max retries have been reached). It should decorate the retry
+// processors. The deleted objects are not on the cache, so their failures don't call the hook.
+func newGiveUpProcessor(hook func(ctx context.Context, obj runtime.Object, err error), indexer cache.Indexer, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+		if err == nil || errors.Is(err, errRequeued) {
+			return err
+		}
+
+		obj, exists, getErr := indexer.GetByKey(key)
+		if getErr != nil || !exists {
+			return err
+		}
+		hook(ctx, obj.(runtime.Object), err)
+
+		return err
+	})
+}
@@ -0,0 +1,105 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerOnMaxRetriesExceeded(t *testing.T) {
+	tests := map[string]struct {
+		retries           int
+		failures          int
+		expHandlings      int
+		expMaxRetriesObjs []string
+	}{
+		"An object that fails on all the retries should call the hook.": {
+			retries:           2,
+			failures:          100,
+			expHandlings:      3,
+			expMaxRetriesObjs: []string{"testing-0"},
+		},
+
+		"An object that succeeds on a retry should not call the hook.": {
+			retries:           2,
+			failures:          2,
+			expHandlings:      3,
+			expMaxRetriesObjs: []string{},
+		},
+
+		"An object that fails without retries should call the hook.": {
+			retries:           0,
+			failures:          100,
+			expHandlings:      1,
+			expMaxRetriesObjs: []string{"testing-0"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList, _ := createNamespaceList("testing", 1)
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			var mu sync.Mutex
+			handlings := 0
+			maxRetriesObjs := []string{}
+			h := controller.HandlerFunc(func(_ context.Context, _ runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handlings++
+				if handlings <= test.failures {
+					return fmt.Errorf("wanted error %d", handlings)
+				}
+				return nil
+			})
+			onMaxRetries := func(_ context.Context, obj runtime.Object, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				maxRetriesObjs = append(maxRetriesObjs, obj.(*corev1.Namespace).Name)
+				assert.Error(err)
+			}
+
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            newNamespaceRetriever(mc),
+				Logger:               log.Dummy,
+				ProcessingJobRetries: test.retries,
+				OnMaxRetriesExceeded: onMaxRetries,
+				DisableResync:        true,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return handlings == test.expHandlings
+			}, 1*time.Second, 5*time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expHandlings, handlings)
+			assert.Equal(test.expMaxRetriesObjs, maxRetriesObjs)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+const (
+	// goroutineLeakLabel is the profiling label used to identify the goroutines started by a handling.
+	goroutineLeakLabel = "kooper_handling"
+	// goroutineLeakSettleTime is the time the goroutines started by a handling have to end
+	// after the handling returns before being considered leaked.
+	goroutineLeakSettleTime = 100 * time.Millisecond
+	goroutineLeakCheckEvery = 5 * time.Millisecond
+)
+
+var goroutineLeakHandlingID int64
+
+// newGoroutineLeakHandler returns a handler that warns when the handling leaves goroutines
+// running after returning (e.g goroutines that don't respect the context cancellation). The
+// handling is labeled (pprof) so only the goroutines started by the handling are counted, the
+// goroutines inherit the labels of the goroutine that starts them.
+func newGoroutineLeakHandler(logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (res Result, err error) {
+		id := strconv.FormatInt(atomic.AddInt64(&goroutineLeakHandlingID, 1), 10)
+		pprof.Do(ctx, pprof.Labels(goroutineLeakLabel, id), func(ctx context.Context) {
+			res, err = handleWithResult(ctx, next, obj)
+		})
+
+		// Give some time to the goroutines that are ending.
+		leaked := countLabeledGoroutines(goroutineLeakLabel, id)
+		for deadline := time.Now().Add(goroutineLeakSettleTime); leaked > 0 && time.Now().Before(deadline); {
+			time.Sleep(goroutineLeakCheckEvery)
+			leaked = countLabeledGoroutines(goroutineLeakLabel, id)
+		}
+		if leaked > 0 {
+			logger.WithKV(log.KV{"leaked-goroutines": leaked}).
+				Warningf("handling left %d goroutines running after returning, possible goroutine leak", leaked)
+		}
+
+		return res, err
+	})
+}
+
+// countLabeledGoroutines returns the number of running goroutines with the profiling label.
+func countLabeledGoroutines(key, value string) int {
+	var b bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&b, 1); err != nil {
+		return 0
+	}
+
+	// The goroutines are grouped by stack in records like `N @ 0x...` followed by
+	// their labels (`# labels: {"key":"value", ...}`) and the stack.
+	label := []byte(fmt.Sprintf("%q:%q", key, value))
+	count := 0
+	for _, record := range bytes.Split(b.Bytes(), []byte("\n\n")) {
+		if !bytes.Contains(record, label) {
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscanf(string(record), "%d @", &n); err == nil {
+			count += n
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,83 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDetectGoroutineLeaks(t *testing.T) {
+	tests := map[string]struct {
+		handler    func(leakC chan struct{}) controller.Handler
+		expWarning bool
+	}{
+		"A handler that doesn't leave goroutines running should not warn.": {
+			handler: func(chan struct{}) controller.Handler {
+				return controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+					done := make(chan struct{})
+					go func() { close(done) }()
+					<-done
+					return nil
+				})
+			},
+		},
+
+		"A handler that leaves goroutines running should warn.": {
+			handler: func(leakC chan struct{}) controller.Handler {
+				return controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+					go func() { <-leakC }()
+					return nil
+				})
+			},
+			expWarning: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList, _ := createNamespaceList("testing", 1)
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			leakC := make(chan struct{})
+			defer close(leakC)
+			logger := &warningLogger{Logger: log.Dummy}
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              test.handler(leakC),
+				Retriever:            newNamespaceRetriever(mc),
+				Logger:               logger,
+				ConcurrentWorkers:    1,
+				DetectGoroutineLeaks: true,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool { return c.Stats().Processed == 1 }, 1*time.Second, 5*time.Millisecond)
+
+			logger.mu.Lock()
+			defer logger.mu.Unlock()
+			warned := false
+			for _, w := range logger.warnings {
+				if strings.Contains(w, "possible goroutine leak") {
+					warned = true
+				}
+			}
+			assert.Equal(test.expWarning, warned)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// GroupMember is a controller of a Group.
+type GroupMember struct {
+	// Name is the name of the member.
+	Name string
+	// Controller is the controller that will be run by the group.
+	Controller Controller
+	// StopOrder is the order in which the controller will be stopped on shutdown, the members
+	// with lower order are stopped (and waited to finish) before the ones with a higher order.
+	// The members with the same order are stopped at the same time. By default 0.
+	StopOrder int
+}
+
+// GroupConfig is the group configuration.
+type GroupConfig struct {
+	// Members are the controllers of the group, at least one is required.
+	Members []GroupMember
+	// Logger will log messages of the group.
+	Logger log.Logger
+}
+
+func (c *GroupConfig) setDefaults() error {
+	if len(c.Members) == 0 {
+		return fmt.Errorf("at least one member is required")
+	}
+
+	names := map[string]bool{}
+	for i, m := range c.Members {
+		if m.Name == "" {
+			return fmt.Errorf("member %d name is required", i)
+		}
+
+		if names[m.Name] {
+			return fmt.Errorf("member %q is duplicated", m.Name)
+		}
+		names[m.Name] = true
+
+		if m.Controller == nil {
+			return fmt.Errorf("member %q controller is required", m.Name)
+		}
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.NewStd(false)
+		c.Logger.Warningf("no logger specified, fallback to default logger, to disable logging use a explicit Noop logger")
+	}
+	c.Logger = c.Logger.WithKV(log.KV{
+		"service": "kooper.group",
+	})
+
+	return nil
+}
+
+// Group runs multiple controllers at the same time and stops them in a defined order
+// on shutdown (check `GroupMember.StopOrder`).
+type Group struct {
+	cfg    GroupConfig
+	logger log.Logger
+}
+
+// NewGroup returns a new group.
+func NewGroup(cfg GroupConfig) (*Group, error) {
+	err := cfg.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("could no create group: %w", err)
+	}
+
+	return &Group{
+		cfg:    cfg,
+		logger: cfg.Logger,
+	}, nil
+}
+
+type groupRunning struct {
+	member GroupMember
+	stop   func()
+	doneC  chan struct{}
+}
+
+// Run runs all the controllers of the group and blocks until the context is `Done` or
+// any of the controllers ends. In both cases the controllers will be stopped in order.
+func (g *Group) Run(ctx context.Context) error {
+	// The controllers don't use the received context cancellation, they are stopped in order.
+	runCtx := detachedContext{Context: ctx}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	endedC := make(chan struct{}, len(g.cfg.Members))
+	running := make([]groupRunning, 0, len(g.cfg.Members))
+	for _, m := range g.cfg.Members {
+		mctx, stop := context.WithCancel(runCtx)
+		r := groupRunning{member: m, stop: stop, doneC: make(chan struct{})}
+		running = append(running, r)
+
+		go func() {
+			defer close(r.doneC)
+			err := r.member.Controller.Run(mctx)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("controller %q failed: %w", r.member.Name, err)
+				}
+				mu.Unlock()
+			}
+			endedC <- struct{}{}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-endedC:
+		g.logger.Warningf("a controller of the group ended, stopping the group")
+	}
+
+	g.stop(running)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return firstErr
+}
+
+// stop stops the running controllers by their stop order.
+func (g *Group) stop(running []groupRunning) {
+	sort.SliceStable(running, func(i, j int) bool {
+		return running[i].member.StopOrder < running[j].member.StopOrder
+	})
+
+	for i := 0; i < len(running); {
+		// Stop all the controllers with the same order and wait for them.
+		j := i
+		for j < len(running) && running[j].member.StopOrder == running[i].member.StopOrder {
+			running[j].stop()
+			j++
+		}
+		for _, r := range running[i:j] {
+			<-r.doneC
+			g.logger.Infof("controller %q stopped", r.member.Name)
+		}
+		i = j
+	}
+}
+
+// detachedContext is a context that keeps the values of the parent context but
+// not its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,115 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// runFuncController is a controller that only implements Run.
+type runFuncController struct {
+	run func(ctx context.Context) error
+}
+
+func (r runFuncController) Run(ctx context.Context) error { return r.run(ctx) }
+
+func TestGroupStopOrder(t *testing.T) {
+	tests := map[string]struct {
+		stopOrders map[string]int
+		failing    string
+		expStopped [][]string
+		expErr     bool
+	}{
+		"Cancelling the context should stop the controllers in order.": {
+			stopOrders: map[string]int{"cleaner": 2, "writer": 0, "reader": 1},
+			expStopped: [][]string{{"writer"}, {"reader"}, {"cleaner"}},
+		},
+
+		"Controllers with the same order should be stopped at the same time.": {
+			stopOrders: map[string]int{"cleaner": 1, "writer-a": 0, "writer-b": 0},
+			expStopped: [][]string{{"writer-a", "writer-b"}, {"cleaner"}},
+		},
+
+		"A failing controller should stop the rest in order and return the error.": {
+			stopOrders: map[string]int{"cleaner": 2, "writer": 0, "reader": 1},
+			failing:    "reader",
+			expStopped: [][]string{{"writer"}, {"cleaner"}},
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			stopped := []string{}
+			startedC := make(chan struct{}, len(test.stopOrders))
+			members := []controller.GroupMember{}
+			for n, order := range test.stopOrders {
+				n := n
+				members = append(members, controller.GroupMember{
+					Name:      n,
+					StopOrder: order,
+					Controller: runFuncController{run: func(ctx context.Context) error {
+						startedC <- struct{}{}
+						if n == test.failing {
+							return fmt.Errorf("wanted error")
+						}
+						<-ctx.Done()
+						// Slow stop, the next ones should wait.
+						time.Sleep(10 * time.Millisecond)
+						mu.Lock()
+						stopped = append(stopped, n)
+						mu.Unlock()
+						return nil
+					}},
+				})
+			}
+
+			g, err := controller.NewGroup(controller.GroupConfig{Members: members, Logger: log.Dummy})
+			require.NoError(err)
+
+			errC := make(chan error)
+			go func() { errC <- g.Run(ctx) }()
+			for range test.stopOrders {
+				<-startedC
+			}
+			if test.failing == "" {
+				cancel()
+			}
+
+			select {
+			case err = <-errC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for the group to stop")
+			}
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			i := 0
+			for _, exp := range test.expStopped {
+				require.GreaterOrEqual(len(stopped), i+len(exp))
+				assert.ElementsMatch(exp, stopped[i:i+len(exp)])
+				i += len(exp)
+			}
+			assert.Len(stopped, i)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -24,3 +25,48 @@ func (h HandlerFunc) Handle(ctx context.Context, obj runtime.Object) error {
 	}
 	return h(ctx, obj)
 }
+
+// Result is the result of a successful handling.
+type Result struct {
+	// Requeue will process the object again after a delay that starts at `Config.RequeueBackoffBase`
+	// and is doubled every time the same object version asks to be requeued (reset when the object changes).
+	Requeue bool
+	// RequeueAfter will process the object again after the duration, it has precedence over `Requeue`.
+	// Useful for handlers that need to poll until a condition is met, these requeues are not
+	// retries, they don't consume `Config.ProcessingJobRetries` nor count as requeued on the stats.
+	RequeueAfter time.Duration
+}
+
+// ResultHandler is an optional interface that a Handler can implement to return a
+// result when the handling succeeds. If the handler implements it, the controller will
+// use `HandleWithResult` instead of `Handle`.
+type ResultHandler interface {
+	Handler
+	HandleWithResult(context.Context, runtime.Object) (Result, error)
+}
+
+// ResultHandlerFunc knows how to handle resources returning a result.
+type ResultHandlerFunc func(context.Context, runtime.Object) (Result, error)
+
+// Handle satisfies controller.Handler interface.
+func (h ResultHandlerFunc) Handle(ctx context.Context, obj runtime.Object) error {
+	_, err := h.HandleWithResult(ctx, obj)
+	return err
+}
+
+// HandleWithResult satisfies controller.ResultHandler interface.
+func (h ResultHandlerFunc) HandleWithResult(ctx context.Context, obj runtime.Object) (Result, error) {
+	if h == nil {
+		return Result{}, fmt.Errorf("handle func is required")
+	}
+	return h(ctx, obj)
+}
+
+// handleWithResult handles the object using the result handler if the handler implements it.
+func handleWithResult(ctx context.Context, h Handler, obj runtime.Object) (Result, error) {
+	if rh, ok := h.(ResultHandler); ok {
+		return rh.HandleWithResult(ctx, obj)
+	}
+
+	return Result{}, h.Handle(ctx, obj)
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// warmUp tracks the objects of the initial sync that have not been handled successfully yet. The
+// pending objects are the ones enqueued before the cache is synced, so the objects that never
+// reach the queue (e.g filtered or deduplicated) don't delay the warm-up.
+type warmUp struct {
+	mu      sync.Mutex
+	started bool
+	done    bool
+	pending map[string]bool
+	doneC   chan struct{}
+}
+
+func newWarmUp() *warmUp {
+	return &warmUp{
+		pending: map[string]bool{},
+		doneC:   make(chan struct{}),
+	}
+}
+
+// enqueued adds the queue key of an object enqueued by the initial sync to the pending objects.
+func (w *warmUp) enqueued(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		w.pending[key] = true
+	}
+}
+
+// deleted forgets the pending object, a deleted object will never be handled successfully.
+func (w *warmUp) deleted(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, key)
+	if w.started && len(w.pending) == 0 {
+		w.finishLocked()
+	}
+}
+
+// start starts the warm-up once the initial sync objects have been enqueued, the skipped keys
+// will not be processed (e.g excluded) so they are not pending.
+func (w *warmUp) start(skip func(key string) bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.started = true
+	for k := range w.pending {
+		if skip(k) {
+			delete(w.pending, k)
+		}
+	}
+	if len(w.pending) == 0 {
+		w.finishLocked()
+	}
+}
+
+// succeeded marks the key as successfully handled.
+func (w *warmUp) succeeded(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, key)
+	if w.started && len(w.pending) == 0 {
+		w.finishLocked()
+	}
+}
+
+// isPending returns true if the key is pending to be handled successfully while warming up.
+func (w *warmUp) isPending(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.done && w.pending[key]
+}
+
+// finish ends the warm-up even if it has pending objects, returns the number of
+// pending objects.
+func (w *warmUp) finish() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pending := len(w.pending)
+	w.finishLocked()
+	return pending
+}
+
+func (w *warmUp) finishLocked() {
+	if w.done {
+		return
+	}
+	w.done = true
+	w.pending = map[string]bool{}
+	close(w.doneC)
+}
+
+func (w *warmUp) status() (done bool, pending int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.done, len(w.pending)
+}
+
+// wait waits until the warm-up ends or the timeout is reached.
+func (w *warmUp) wait(ctx context.Context, timeout time.Duration, logger log.Logger) {
+	select {
+	case <-ctx.Done():
+	case <-w.doneC:
+		logger.Infof("warm-up finished, all initial objects handled")
+	case <-time.After(timeout):
+		pending := w.finish()
+		logger.Warningf("warm-up timeout reached with %d initial objects failing", pending)
+	}
+}
+
+// newWarmUpProcessor returns a processor that while warming up, will retry the processing of
+// the initial objects until they are handled successfully. The retries are requeued after the
+// backoff and return an `errRequeued` error, so they are not retried again by other processors.
+func newWarmUpProcessor(w *warmUp, queue blockingQueue, backoff time.Duration, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+		if !w.isPending(key) {
+			return err
+		}
+
+		if err == nil {
+			w.succeeded(key)
+			return nil
+		}
+
+		queue.AddAfter(ctx, key, backoff, true)
+		return fmt.Errorf("%w: warming up: %s", errRequeued, err)
+	})
+}
+
+// Ready returns an error if the controller is not ready: not running, the initial cache
+// sync has not finished, it's warming up (check `Config.WarmUpTimeout`) or it's degraded
+// because it can't reach the API server (the objects are still handled from the cache).
+func (g *Generic) Ready() error {
+	g.runningMu.Lock()
+	running, synced := g.running, g.synced
+	g.runningMu.Unlock()
+
+	if !running {
+		return errors.New("controller not running")
+	}
+
+	if !synced {
+		return errors.New("controller cache not synced")
+	}
+
+	if g.warmUp != nil {
+		done, pending := g.warmUp.status()
+		if !done {
+			return fmt.Errorf("controller warming up, %d initial objects pending", pending)
+		}
+	}
+
+	if err := g.degraded.get(); err != nil {
+		return fmt.Errorf("controller degraded, can't reach the API server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerWarmUpReadiness(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 5)
+
+	tests := map[string]struct {
+		failures      map[string]int // Number of times that will fail the object.
+		filter        func(obj runtime.Object) bool
+		keyFunc       cache.KeyFunc
+		warmUpTimeout time.Duration
+		expReadyAfter time.Duration // The min time to be ready.
+	}{
+		"Without failures the controller should be ready after the initial objects are handled.": {
+			failures:      map[string]int{},
+			warmUpTimeout: 5 * time.Second,
+		},
+
+		"With failing initial objects the readiness should be delayed until they succeed.": {
+			failures:      map[string]int{"testing-1": 3, "testing-3": 5},
+			warmUpTimeout: 5 * time.Second,
+			expReadyAfter: 5 * 20 * time.Millisecond,
+		},
+
+		"With initial objects that always fail the controller should be ready after the warm-up timeout.": {
+			failures:      map[string]int{"testing-1": 999999},
+			warmUpTimeout: 300 * time.Millisecond,
+			expReadyAfter: 300 * time.Millisecond,
+		},
+
+		"With filtered initial objects the readiness should not wait for them.": {
+			failures:      map[string]int{},
+			filter:        func(obj runtime.Object) bool { return obj.(*corev1.Namespace).Name != "testing-2" },
+			warmUpTimeout: 5 * time.Second,
+		},
+
+		"With a custom key func the readiness should track the queue keys.": {
+			failures: map[string]int{"testing-1": 3},
+			keyFunc: func(obj interface{}) (string, error) {
+				return "custom/" + obj.(*corev1.Namespace).Name, nil
+			},
+			warmUpTimeout: 5 * time.Second,
+			expReadyAfter: 3 * 20 * time.Millisecond,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			var mu sync.Mutex
+			failures := map[string]int{}
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				name := obj.(*corev1.Namespace).Name
+				if failures[name] < test.failures[name] {
+					failures[name]++
+					return fmt.Errorf("wanted error")
+				}
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:               "test",
+				Handler:            h,
+				Retriever:          newNamespaceRetriever(mc),
+				Logger:             log.Dummy,
+				WarmUpTimeout:      test.warmUpTimeout,
+				RequeueBackoffBase: 20 * time.Millisecond,
+				Filter:             test.filter,
+				KeyFunc:            test.keyFunc,
+			})
+			require.NoError(err)
+
+			// Not running, not ready.
+			assert.Error(c.Ready())
+
+			t0 := time.Now()
+			go func() { _ = c.Run(ctx) }()
+
+			require.Eventually(func() bool { return c.Ready() == nil }, 2*time.Second, time.Millisecond)
+			assert.GreaterOrEqual(int64(time.Since(t0)), int64(test.expReadyAfter))
+
+			// The objects that eventually succeed should be retried until they are successful.
+			mu.Lock()
+			defer mu.Unlock()
+			for name, f := range test.failures {
+				if f < 999999 {
+					assert.Equal(f, failures[name])
+				}
+			}
+		})
+	}
+}
+
+func TestGenericControllerWarmUpDeletedObject(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	ret, w := newFakeNamespaceRetriever(nsList)
+
+	// The object always fails, it will be pending until it's deleted.
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		if obj.(*corev1.Namespace).Name == "testing-1" {
+			return fmt.Errorf("wanted error")
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:               "test",
+		Handler:            h,
+		Retriever:          ret,
+		Logger:             log.Dummy,
+		WarmUpTimeout:      5 * time.Second,
+		RequeueBackoffBase: 20 * time.Millisecond,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Error(c.Ready())
+
+	w.Delete(&nsList.Items[1])
+	assert.Eventually(func() bool { return c.Ready() == nil }, 1*time.Second, 5*time.Millisecond)
+}
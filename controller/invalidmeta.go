@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// objectMetaValidator skips the objects with missing or invalid meta (e.g without name), these
+// objects can't be identified with a key so they can't be stored on the cache nor processed.
+type objectMetaValidator struct {
+	name      string
+	mrec      MetricsRecorder
+	logger    log.Logger
+	rebuilder *informerRebuilder
+}
+
+func validateObjectMeta(obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	if m.GetName() == "" {
+		return fmt.Errorf("object name is missing")
+	}
+
+	return nil
+}
+
+// invalid returns true if the object meta is invalid, it will log and measure the invalid object.
+func (v objectMetaValidator) invalid(obj runtime.Object) bool {
+	err := validateObjectMeta(obj)
+	if err == nil {
+		return false
+	}
+
+	// Log all the info we have of the object.
+	kv := log.KV{
+		"object-type": fmt.Sprintf("%T", obj),
+		"object-kind": obj.GetObjectKind().GroupVersionKind().String(),
+	}
+	if m, err := meta.Accessor(obj); err == nil {
+		kv["object-namespace"] = m.GetNamespace()
+		kv["object-uid"] = m.GetUID()
+		kv["object-resource-version"] = m.GetResourceVersion()
+	}
+	v.mrec.IncResourceInvalidSkipped(context.Background(), v.name)
+	v.logger.WithKV(kv).Errorf("object skipped, invalid object meta: %s", err)
+	v.rebuilder.keyFailed()
+
+	return true
+}
+
+// wrap returns a ListerWatcher that removes the objects with invalid meta from the lists and watches.
+func (v objectMetaValidator) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+
+			objs, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			filtered := make([]runtime.Object, 0, len(objs))
+			for _, o := range objs {
+				if !v.invalid(o) {
+					filtered = append(filtered, o)
+				}
+			}
+			if len(filtered) == len(objs) {
+				return obj, nil
+			}
+
+			err = meta.SetList(obj, filtered)
+			if err != nil {
+				return nil, err
+			}
+			return obj, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				switch e.Type {
+				case watch.Added, watch.Modified, watch.Deleted:
+					return e, !v.invalid(e.Object)
+				}
+				return e, true
+			}), nil
+		},
+	}
+}
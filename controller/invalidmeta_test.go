@@ -0,0 +1,108 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// errorLogger is a logger that stores the logged errors.
+type errorLogger struct {
+	log.Logger
+	mu     sync.Mutex
+	errors []string
+}
+
+func (e *errorLogger) Errorf(format string, args ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+func (e *errorLogger) WithKV(log.KV) log.Logger { return e }
+
+func (e *errorLogger) logged() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.errors...)
+}
+
+// invalidRecorder is a metrics recorder that counts the invalid skipped objects.
+type invalidRecorder struct {
+	controller.MetricsRecorder
+	skipped int64
+}
+
+func (i *invalidRecorder) IncResourceInvalidSkipped(context.Context, string) {
+	atomic.AddInt64(&i.skipped, 1)
+}
+
+func TestGenericControllerInvalidObjectMeta(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "valid-1", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}, // Missing name.
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		return nil
+	})
+	handledNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	logger := &errorLogger{Logger: log.Dummy}
+	mrec := &invalidRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		Logger:          logger,
+		MetricsRecorder: mrec,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return len(handledNames()) == 1 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(int64(1), atomic.LoadInt64(&mrec.skipped))
+
+	// Objects without meta should be skipped and the controller should continue.
+	w.Add(&metav1.Status{})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "valid-2", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(handledNames()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal([]string{"valid-1", "valid-2"}, handledNames())
+	assert.Equal(int64(2), atomic.LoadInt64(&mrec.skipped))
+
+	errs := logger.logged()
+	if assert.Len(errs, 2) {
+		assert.Contains(errs[0], "invalid object meta")
+		assert.Contains(errs[1], "invalid object meta")
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// keyFuncIndex is the informer store index of the objects by the `Config.KeyFunc` keys.
+const keyFuncIndex = "kooper-key-func"
+
+// deletionHandlingKeyFunc returns a key func that gets the key of the deleted objects with an
+// unknown final state from their last known state.
+func deletionHandlingKeyFunc(f cache.KeyFunc) cache.KeyFunc {
+	return func(obj interface{}) (string, error) {
+		if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = d.Obj
+		}
+		return f(obj)
+	}
+}
+
+// keyFuncIndexFunc indexes the objects by their key. The objects without key are not indexed,
+// the index funcs can't fail, the event handlers will warn about them.
+func keyFuncIndexFunc(f cache.KeyFunc) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		key, err := f(obj)
+		if err != nil {
+			return nil, nil
+		}
+		return []string{key}, nil
+	}
+}
+
+// keyFuncIndexer is a cache indexer that gets the objects by their `Config.KeyFunc` keys instead
+// of the informer store keys (the meta namespace keys).
+type keyFuncIndexer struct {
+	cache.Indexer
+	f cache.KeyFunc
+}
+
+// GetByKey returns the object of the key, if multiple objects share the same key (e.g a renamed
+// object while the old one is still on the cache) the newest one is returned.
+func (k keyFuncIndexer) GetByKey(key string) (interface{}, bool, error) {
+	objs, err := k.Indexer.ByIndex(keyFuncIndex, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(objs) == 0 {
+		return nil, false, nil
+	}
+
+	sort.SliceStable(objs, func(i, j int) bool {
+		mi, erri := meta.Accessor(objs[i])
+		mj, errj := meta.Accessor(objs[j])
+		if erri != nil || errj != nil {
+			return false
+		}
+		ti, tj := mi.GetCreationTimestamp(), mj.GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return mi.GetName() < mj.GetName()
+	})
+	obj := objs[0]
+
+	// The objects are stored with the key of the received object, make sure the lookups use the same key.
+	objKey, err := k.f(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get object key: %w", err)
+	}
+	if objKey != key {
+		return nil, false, fmt.Errorf("the stored object key %q doesn't match the lookup key %q, the key func must be deterministic", objKey, key)
+	}
+
+	return obj, true, nil
+}
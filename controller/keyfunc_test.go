@@ -0,0 +1,109 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerKeyFunc(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t0 := time.Now()
+	newNS := func(name, id, rv string, created time.Time) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			ResourceVersion:   rv,
+			Labels:            map[string]string{"id": id},
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{*newNS("ns-old", "1", "1", t0)},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	events := []string{}
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	getEvents := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, events...)
+	}
+
+	// Key the objects by their ID label.
+	keyFunc := func(obj interface{}) (string, error) {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return "", err
+		}
+		id, ok := m.GetLabels()["id"]
+		if !ok {
+			return "", fmt.Errorf("missing id label")
+		}
+		return id, nil
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name: "test",
+		Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			record("handle " + obj.(*corev1.Namespace).Name)
+			return nil
+		}),
+		DeleteHandler: controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+			key, _ := controller.DeletedObjectKey(ctx)
+			record("delete " + key + " " + obj.(*corev1.Namespace).Name)
+			return nil
+		}),
+		Retriever:     ret,
+		Logger:        log.Dummy,
+		DisableResync: true,
+		KeyFunc:       keyFunc,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getEvents()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	// The renamed object shares the key, the newest object should be handled.
+	w.Add(newNS("ns-new", "1", "2", t0.Add(time.Minute)))
+	assert.Eventually(func() bool { return len(getEvents()) == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	// Deleting the old object should not be a deletion while the key has objects.
+	w.Delete(newNS("ns-old", "1", "3", t0))
+	assert.Eventually(func() bool { return len(getEvents()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	w.Delete(newNS("ns-new", "1", "4", t0.Add(time.Minute)))
+	assert.Eventually(func() bool { return len(getEvents()) == 4 }, 1*time.Second, 5*time.Millisecond)
+
+	// The objects without key should be ignored.
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-unkeyed", ResourceVersion: "5"}})
+	time.Sleep(50 * time.Millisecond)
+
+	exp := []string{
+		"handle ns-old",
+		"handle ns-new",
+		"handle ns-new",
+		"delete 1 ns-new",
+	}
+	assert.Equal(exp, getEvents())
+}
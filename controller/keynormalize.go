@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// keyNormalizer normalizes the object keys so the aliased keys of an object are processed as the same
+// one. It keeps the last cache key received for every normalized key, so the objects can be
+// retrieved from the cache with the normalized keys.
+type keyNormalizer struct {
+	f         func(key string) string
+	mu        sync.Mutex
+	cacheKeys map[string]string
+}
+
+func newKeyNormalizer(f func(key string) string) *keyNormalizer {
+	return &keyNormalizer{
+		f:         f,
+		cacheKeys: map[string]string{},
+	}
+}
+
+// normalize returns the normalized key, the normalize func should be idempotent, the
+// normalized keys are normalized again when requeued.
+func (k *keyNormalizer) normalize(key string) string {
+	nk := k.f(key)
+	if nk == key {
+		return nk
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.cacheKeys[nk] = key
+	return nk
+}
+
+// received normalizes the key of a received object event, the received object cache key will be
+// used for the normalized key even if it's already normalized.
+func (k *keyNormalizer) received(key string) string {
+	nk := k.f(key)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if nk == key {
+		delete(k.cacheKeys, nk)
+	} else {
+		k.cacheKeys[nk] = key
+	}
+	return nk
+}
+
+// cacheKey returns the cache key of a normalized key.
+func (k *keyNormalizer) cacheKey(key string) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if ck, ok := k.cacheKeys[key]; ok {
+		return ck
+	}
+	return key
+}
+
+func (k *keyNormalizer) forget(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.cacheKeys, key)
+}
+
+// normalizedIndexer is a cache indexer that gets the objects by their normalized keys.
+type normalizedIndexer struct {
+	cache.Indexer
+	kn *keyNormalizer
+}
+
+func (n normalizedIndexer) GetByKey(key string) (interface{}, bool, error) {
+	obj, exists, err := n.Indexer.GetByKey(n.kn.cacheKey(key))
+	if err == nil && !exists {
+		// The object is gone, we don't need to track its key anymore.
+		n.kn.forget(key)
+	}
+	return obj, exists, err
+}
+
+// normalizedBlockingQueue normalizes the keys before any queue operation, so the aliased keys
+// of an object are deduplicated by the queue.
+type normalizedBlockingQueue struct {
+	blockingQueue
+	kn *keyNormalizer
+}
+
+func (n normalizedBlockingQueue) item(item interface{}) interface{} {
+	if key, ok := item.(string); ok {
+		return n.kn.normalize(key)
+	}
+	return item
+}
+
+func (n normalizedBlockingQueue) Add(ctx context.Context, item interface{}) {
+	n.blockingQueue.Add(ctx, n.item(item))
+}
+
+func (n normalizedBlockingQueue) AddAfter(ctx context.Context, item interface{}, duration time.Duration, requeue bool) {
+	n.blockingQueue.AddAfter(ctx, n.item(item), duration, requeue)
+}
+
+func (n normalizedBlockingQueue) Requeue(ctx context.Context, item interface{}) error {
+	return n.blockingQueue.Requeue(ctx, n.item(item))
+}
@@ -0,0 +1,73 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerKeyNormalizeFunc(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	// Block the only worker so the aliased objects wait on the queue.
+	var mu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+	blockC := make(chan struct{})
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		name := obj.(*corev1.Namespace).Name
+		mu.Lock()
+		handled = append(handled, name)
+		mu.Unlock()
+		if name == "blocker" {
+			<-blockC
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         ret,
+		Logger:            log.Dummy,
+		DisableResync:     true,
+		ConcurrentWorkers: 1,
+		KeyNormalizeFunc:  strings.ToLower,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return c.Ready() == nil }, 1*time.Second, 5*time.Millisecond)
+
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "blocker", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "Widget", ResourceVersion: "3"}})
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "widget", ResourceVersion: "4"}})
+	assert.Eventually(func() bool { return c.Stats().QueueLength == 1 }, 1*time.Second, 5*time.Millisecond)
+	close(blockC)
+
+	// The aliased keys should be handled once with the last received object.
+	assert.Eventually(func() bool { return len(getHandled()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal([]string{"blocker", "widget"}, getHandled())
+}
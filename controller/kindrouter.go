@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// KindRouter is a Handler for controllers that handle multiple kinds, it routes every object to
+// the typed handler registered for its kind (check `HandleKind`). The kinds are identified by their
+// GVK, taken from the object or resolved with the scheme when the object doesn't set it (e.g the
+// informer typed objects). The kinds unknown by the scheme are identified by their Go type.
+type KindRouter struct {
+	scheme   *runtime.Scheme
+	mu       sync.RWMutex
+	handlers map[schema.GroupVersionKind]Handler
+	types    map[reflect.Type]Handler
+	fallback Handler
+}
+
+// NewKindRouter returns a new kind router, the kinds are resolved with the scheme, if nil the Kubernetes
+// client scheme will be used. The objects of unregistered kinds will be handled by the fallback handler.
+// If the fallback is nil, these objects will error.
+func NewKindRouter(s *runtime.Scheme, fallback Handler) *KindRouter {
+	if s == nil {
+		s = scheme.Scheme
+	}
+	return &KindRouter{
+		scheme:   s,
+		handlers: map[schema.GroupVersionKind]Handler{},
+		types:    map[reflect.Type]Handler{},
+		fallback: fallback,
+	}
+}
+
+// HandleKind registers on the router the typed handler of the kind `T`, replacing the
+// previous handler of the same kind. `T` must be a concrete type, it will panic otherwise.
+// The objects of the kind that are not `T` (e.g `*unstructured.Unstructured`) are converted
+// to `T` with the router scheme.
+func HandleKind[T runtime.Object](r *KindRouter, h func(ctx context.Context, obj T) error) {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if t == nil {
+		panic("kind handler type must be a concrete type")
+	}
+
+	handler := HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		tobj, ok := obj.(T)
+		if !ok {
+			if t.Kind() != reflect.Ptr {
+				return fmt.Errorf("could not convert %T to %s", obj, t)
+			}
+			tobj = reflect.New(t.Elem()).Interface().(T)
+			if err := r.scheme.Convert(obj, tobj, nil); err != nil {
+				return fmt.Errorf("could not convert %T to %s: %w", obj, t, err)
+			}
+		}
+		return h(ctx, tobj)
+	})
+
+	// Unknown kinds by the scheme can only be routed by their type.
+	var gvks []schema.GroupVersionKind
+	if t.Kind() == reflect.Ptr {
+		if obj, ok := reflect.New(t.Elem()).Interface().(runtime.Object); ok {
+			gvks, _, _ = r.scheme.ObjectKinds(obj)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, gvk := range gvks {
+		r.handlers[gvk] = handler
+	}
+	r.types[t] = handler
+}
+
+// Handle satisfies controller.Handler interface.
+func (r *KindRouter) Handle(ctx context.Context, obj runtime.Object) error {
+	gvk := r.kind(obj)
+
+	r.mu.RLock()
+	h, ok := r.handlers[gvk]
+	if !ok {
+		h, ok = r.types[reflect.TypeOf(obj)]
+	}
+	r.mu.RUnlock()
+
+	switch {
+	case ok:
+		return h.Handle(ctx, obj)
+	case r.fallback != nil:
+		return r.fallback.Handle(ctx, obj)
+	}
+
+	if gvk.Empty() {
+		return fmt.Errorf("no handler registered for kind %T", obj)
+	}
+	return fmt.Errorf("no handler registered for kind %s", gvk)
+}
+
+// kind returns the GVK of the object, an empty GVK if it's unknown.
+func (r *KindRouter) kind(obj runtime.Object) schema.GroupVersionKind {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return gvk
+	}
+	gvks, _, err := r.scheme.ObjectKinds(obj)
+	if err != nil || len(gvks) == 0 {
+		return schema.GroupVersionKind{}
+	}
+	return gvks[0]
+}
+
+var _ Handler = &KindRouter{}
@@ -0,0 +1,103 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+func TestKindRouter(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod"}}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-cm"}}
+	newUnstructured := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName(name)
+		return u
+	}
+
+	tests := map[string]struct {
+		obj         runtime.Object
+		useFallback bool
+		expHandled  string
+		expErr      bool
+	}{
+		"A pod should be routed to the pod handler.": {
+			obj:        pod,
+			expHandled: "pod/test-pod",
+		},
+
+		"A namespace should be routed to the namespace handler.": {
+			obj:        ns,
+			expHandled: "namespace/test-ns",
+		},
+
+		"An unstructured pod should be routed to the pod handler as a typed pod.": {
+			obj:        newUnstructured("v1", "Pod", "test-pod"),
+			expHandled: "pod/test-pod",
+		},
+
+		"An unstructured object of a kind unknown by the scheme should be routed by its type.": {
+			obj:        newUnstructured("custom.kooper.io/v1", "Custom", "test-custom"),
+			expHandled: "unstructured/Custom/test-custom",
+		},
+
+		"An unregistered kind should be routed to the fallback handler.": {
+			obj:         cm,
+			useFallback: true,
+			expHandled:  "fallback/test-cm",
+		},
+
+		"An unregistered kind without fallback handler should fail.": {
+			obj:    cm,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			handled := ""
+			var fallback controller.Handler
+			if test.useFallback {
+				fallback = controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+					handled = "fallback/" + obj.(metav1.Object).GetName()
+					return nil
+				})
+			}
+
+			r := controller.NewKindRouter(nil, fallback)
+			controller.HandleKind(r, func(_ context.Context, p *corev1.Pod) error {
+				handled = "pod/" + p.Name
+				return nil
+			})
+			controller.HandleKind(r, func(_ context.Context, n *corev1.Namespace) error {
+				handled = "namespace/" + n.Name
+				return nil
+			})
+			controller.HandleKind(r, func(_ context.Context, u *unstructured.Unstructured) error {
+				handled = "unstructured/" + u.GetKind() + "/" + u.GetName()
+				return nil
+			})
+
+			err := r.Handle(context.TODO(), test.obj)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expHandled, handled)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package controller_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+	kooperprometheus "github.com/adevjoe/kooper/v2/metrics/prometheus"
+)
+
+// kvLogger is a logger that stores the KVs of the logged messages.
+type kvLogger struct {
+	log.Logger
+	kv     log.KV
+	mu     *sync.Mutex
+	logged *[]log.KV
+}
+
+func newKVLogger() kvLogger {
+	return kvLogger{Logger: log.Dummy, kv: log.KV{}, mu: &sync.Mutex{}, logged: &[]log.KV{}}
+}
+
+func (k kvLogger) Infof(format string, args ...interface{}) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	*k.logged = append(*k.logged, k.kv)
+}
+
+func (k kvLogger) WithKV(kv log.KV) log.Logger {
+	newKV := log.KV{}
+	for key, v := range k.kv {
+		newKV[key] = v
+	}
+	for key, v := range kv {
+		newKV[key] = v
+	}
+	k.kv = newKV
+	return k
+}
+
+func (k kvLogger) loggedKVs() []log.KV {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]log.KV{}, *k.logged...)
+}
+
+func TestGenericControllerLabels(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 1)
+
+	tests := map[string]struct {
+		allowedLabels []string
+		labels        map[string]string
+		expErr        bool
+		expMetrics    []string
+	}{
+		"Configured labels should be on the controller logs and metrics.": {
+			allowedLabels: []string{"team", "tier"},
+			labels:        map[string]string{"team": "platform", "tier": "critical"},
+			expMetrics: []string{
+				`kooper_controller_queued_events_total{controller="test",requeue="false",team="platform",tier="critical"} 1`,
+				`kooper_controller_event_queue_length{controller="test",team="platform",tier="critical"} 0`,
+			},
+		},
+
+		"Missing allowed labels should be empty on the metrics.": {
+			allowedLabels: []string{"team", "tier"},
+			labels:        map[string]string{"team": "platform"},
+			expMetrics: []string{
+				`kooper_controller_queued_events_total{controller="test",requeue="false",team="platform",tier=""} 1`,
+			},
+		},
+
+		"Labels that are not allowed by the metrics recorder should fail.": {
+			allowedLabels: []string{"team"},
+			labels:        map[string]string{"team": "platform", "user": "batman"},
+			expErr:        true,
+		},
+
+		"Labels with invalid keys should fail.": {
+			allowedLabels: []string{"team"},
+			labels:        map[string]string{"team-name": "platform"},
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reg := prometheus.NewRegistry()
+			recorder := kooperprometheus.New(kooperprometheus.Config{
+				Registerer:       reg,
+				ControllerLabels: test.allowedLabels,
+			})
+			logger := newKVLogger()
+
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+			handledC := make(chan struct{}, 1)
+			c, err := controller.New(&controller.Config{
+				Name: "test",
+				Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error {
+					handledC <- struct{}{}
+					return nil
+				}),
+				Retriever:       newNamespaceRetriever(mc),
+				MetricsRecorder: recorder,
+				Logger:          logger,
+				Labels:          test.labels,
+			})
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			select {
+			case <-handledC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for handling")
+			}
+
+			// Check logs.
+			logged := logger.loggedKVs()
+			require.NotEmpty(logged)
+			for _, kv := range logged {
+				for k, v := range test.labels {
+					assert.Equal(v, kv[k])
+				}
+			}
+
+			// Check metrics.
+			h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+			body, _ := ioutil.ReadAll(w.Result().Body)
+			for _, expMetric := range test.expMetrics {
+				assert.Contains(string(body), expMetric)
+			}
+		})
+	}
+}
+
+func TestGenericControllerRecreatedQueueMetrics(t *testing.T) {
+	require := require.New(t)
+
+	mrec := kooperprometheus.New(kooperprometheus.Config{Registerer: prometheus.NewRegistry()})
+	newController := func() (*controller.Generic, error) {
+		return controller.New(&controller.Config{
+			Name:            "test",
+			Handler:         controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+			Retriever:       newNamespaceRetriever(&fake.Clientset{}),
+			Logger:          log.Dummy,
+			MetricsRecorder: mrec,
+		})
+	}
+
+	// A live controller with the same name can't be created, it would steal the queue metrics.
+	c, err := newController()
+	require.NoError(err)
+	_, err = newController()
+	require.Error(err)
+
+	// Once stopped, the controller can be recreated.
+	c.Stop()
+	_, err = newController()
+	require.NoError(err)
+}
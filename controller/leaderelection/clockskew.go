@@ -0,0 +1,72 @@
+package leaderelection
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// MetricsRecorder knows how to record metrics of the leader election.
+type MetricsRecorder interface {
+	// ObserveLeaderElectionClockSkew will observe the clock skew detected with the leader
+	// of a leader election.
+	ObserveLeaderElectionClockSkew(ctx context.Context, leaderElectionID string, skew time.Duration)
+}
+
+// DummyMetricsRecorder is a dummy leader election metrics recorder.
+var DummyMetricsRecorder = dummy(0)
+
+type dummy int
+
+func (dummy) ObserveLeaderElectionClockSkew(context.Context, string, time.Duration) {}
+
+// clockSkewDetectorLock is a resource lock wrapper that detects the clock skew with the
+// leader by checking the timestamps of the lock records. A leader has set the record timestamps
+// with its own clock, so if the timestamps are in the future (beyond the threshold) the clocks
+// of the replicas are skewed.
+type clockSkewDetectorLock struct {
+	resourcelock.Interface
+	id        string
+	threshold time.Duration
+	recorder  MetricsRecorder
+	logger    log.Logger
+}
+
+func newClockSkewDetectorLock(id string, threshold time.Duration, recorder MetricsRecorder, logger log.Logger, lock resourcelock.Interface) resourcelock.Interface {
+	return clockSkewDetectorLock{
+		Interface: lock,
+		id:        id,
+		threshold: threshold,
+		recorder:  recorder,
+		logger:    logger,
+	}
+}
+
+func (c clockSkewDetectorLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	record, raw, err := c.Interface.Get(ctx)
+	if err != nil || record == nil {
+		return record, raw, err
+	}
+
+	// Our own records use our clock.
+	if record.HolderIdentity == "" || record.HolderIdentity == c.Identity() {
+		return record, raw, err
+	}
+
+	now := time.Now()
+	skew := record.RenewTime.Sub(now)
+	if acquireSkew := record.AcquireTime.Sub(now); acquireSkew > skew {
+		skew = acquireSkew
+	}
+
+	if skew > c.threshold {
+		c.recorder.ObserveLeaderElectionClockSkew(ctx, c.id, skew)
+		c.logger.WithKV(log.KV{"leader": record.HolderIdentity, "skew": skew.String()}).
+			Warningf("possible clock skew with the leader detected, the lock record timestamps are in the future")
+	}
+
+	return record, raw, err
+}
@@ -0,0 +1,168 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// InMemory is an in-memory leader election for the runners of the same process, the leadership
+// can be handed over programmatically in a deterministic way, useful to test failover behavior.
+type InMemory struct {
+	mu         sync.Mutex
+	leader     string
+	candidates []*inMemoryRunner
+	logger     log.Logger
+}
+
+// NewInMemory returns a new in-memory leader election.
+func NewInMemory(logger log.Logger) *InMemory {
+	return &InMemory{
+		logger: logger.WithKV(log.KV{"source-service": "kooper/leader-election-in-memory"}),
+	}
+}
+
+// Runner returns a new runner that will be a candidate of the leader election with the identity. The
+// runner is a `DemotableRunner`, and it can only be run once.
+func (m *InMemory) Runner(identity string) Runner {
+	return &inMemoryRunner{
+		identity: identity,
+		election: m,
+		logger:   m.logger.WithKV(log.KV{"leader-election-identity": identity}),
+		grantedC: make(chan struct{}),
+		revokedC: make(chan struct{}),
+	}
+}
+
+// Leader returns the current leader identity, empty if there is no leader.
+func (m *InMemory) Leader() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leader
+}
+
+// ForceHandover revokes the leadership of the current leader (its run will end with a lost
+// leadership error) and grants it to the running candidate with the identity.
+func (m *InMemory) ForceHandover(to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var target, current *inMemoryRunner
+	for _, c := range m.candidates {
+		switch c.identity {
+		case to:
+			target = c
+		case m.leader:
+			current = c
+		}
+	}
+
+	if target == nil {
+		return fmt.Errorf("%q is not a running candidate", to)
+	}
+	if to == m.leader {
+		return nil
+	}
+
+	if current != nil {
+		m.removeLocked(current)
+		close(current.revokedC)
+	}
+	m.leader = to
+	close(target.grantedC)
+
+	return nil
+}
+
+// join adds the runner to the candidates, if there isn't a leader the candidate is granted.
+func (m *InMemory) join(r *inMemoryRunner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.candidates = append(m.candidates, r)
+	if m.leader == "" {
+		m.leader = r.identity
+		close(r.grantedC)
+	}
+}
+
+// leave removes the runner from the candidates, if the runner was the leader the
+// leadership is granted to the next candidate.
+func (m *InMemory) leave(r *inMemoryRunner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.removeLocked(r) || m.leader != r.identity {
+		return
+	}
+
+	m.leader = ""
+	if len(m.candidates) > 0 {
+		m.leader = m.candidates[0].identity
+		close(m.candidates[0].grantedC)
+	}
+}
+
+func (m *InMemory) removeLocked(r *inMemoryRunner) bool {
+	for i, c := range m.candidates {
+		if c == r {
+			m.candidates = append(m.candidates[:i], m.candidates[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// inMemoryRunner is a runner of the in-memory leader election.
+type inMemoryRunner struct {
+	identity string
+	election *InMemory
+	logger   log.Logger
+	grantedC chan struct{}
+	revokedC chan struct{}
+}
+
+// Identity satisfies IdentityRunner interface.
+func (r *inMemoryRunner) Identity() string {
+	return r.identity
+}
+
+func (r *inMemoryRunner) Run(f func() error) error {
+	return r.RunDemotable(context.Background(), func(context.Context) error { return f() })
+}
+
+// DemotionGracePeriod satisfies DemotableRunner interface, the in-memory runs end as soon as they
+// are demoted.
+func (r *inMemoryRunner) DemotionGracePeriod() time.Duration {
+	return 0
+}
+
+// RunDemotable satisfies DemotableRunner interface.
+func (r *inMemoryRunner) RunDemotable(ctx context.Context, f func(ctx context.Context) error) error {
+	r.election.join(r)
+	defer r.election.leave(r)
+
+	// If the context is done before acquiring the leadership there is nothing to wait.
+	r.logger.Infof("running in leader election mode, waiting to acquire leadership...")
+	select {
+	case <-r.grantedC:
+	case <-ctx.Done():
+		return nil
+	}
+	r.logger.Infof("lead acquire, starting...")
+
+	// Stop the function when the leadership is lost.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() { errC <- f(ctx) }()
+
+	select {
+	case err := <-errC:
+		r.logger.Infof("lead execution stopped")
+		return err
+	case <-r.revokedC:
+		return fmt.Errorf("leadership lost")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -23,8 +24,25 @@ const (
 	defRetryPeriod   = 2 * time.Second
 )
 
+// LockType is the kind of Kubernetes resource used as the leader election lock.
+type LockType string
+
+const (
+	// LeaseLock uses a coordination.k8s.io Lease as the lock.
+	LeaseLock LockType = "lease"
+	// ConfigMapLock uses a ConfigMap as the lock.
+	ConfigMapLock LockType = "configmap"
+	// EndpointsLock uses an Endpoints as the lock.
+	EndpointsLock LockType = "endpoints"
+)
+
 // LockConfig is the configuration for the lock (timing, leases...).
 type LockConfig struct {
+	// LockType is the resource used as the lock. By default `LeaseLock`.
+	//
+	// Changing the lock type of a running deployment is not safe, the replicas with different
+	// lock types would not see each other lock.
+	LockType LockType
 	// LeaseDuration is the duration that non-leader candidates will
 	// wait to force acquire leadership. This is measured against time of
 	// last observed ack.
@@ -35,6 +53,31 @@ type LockConfig struct {
 	// RetryPeriod is the duration the LeaderElector clients should wait
 	// between tries of actions.
 	RetryPeriod time.Duration
+	// ClockSkewThreshold enables the clock skew detection. If the leader lock record
+	// timestamps are in the future beyond the threshold, a warning will be logged and
+	// the skew will be measured. Clock skew between replicas can cause premature lease
+	// expirations or overlapping leaders.
+	ClockSkewThreshold time.Duration
+	// OnRenew is called on every successful renewal of the leadership lease, useful as a liveness
+	// signal of the leader (e.g update a liveness timestamp). It's called synchronously by the leader
+	// election loop, so it should not block.
+	OnRenew func()
+	// OnStartedLeading is called when the leadership is acquired, before running the controller. Like
+	// client-go leader election callbacks it's called on its own goroutine, the context is cancelled
+	// when the leadership is lost.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is called when the leader election stops: the leadership is lost or the run
+	// ends (e.g graceful shutdown). It's also called on graceful shutdown by the replicas that never
+	// acquired the leadership.
+	OnStoppedLeading func()
+	// DemotionGracePeriod is the time the run has to finish its in-flight operations when the leadership is
+	// lost (demotion). While demoting, the controllers don't start new handlings and the run waits up to the
+	// grace period for the in-flight handlings before returning the leadership lost error, minimizing the
+	// split-brain mutations. It should be lower than `LeaseDuration - RenewDeadline`, after that other replica
+	// could have acquired the leadership. By default disabled.
+	DemotionGracePeriod time.Duration
+	// MetricsRecorder will record the leader election metrics. By default disabled.
+	MetricsRecorder MetricsRecorder
 }
 
 // Runner knows how to run using the leader election.
@@ -43,6 +86,25 @@ type Runner interface {
 	Run(func() error) error
 }
 
+// DemotableRunner is a Runner that notifies the run when the leadership is lost (demotion) and gives it
+// a grace period to end, the controllers use it to finish their in-flight handlings before stopping.
+type DemotableRunner interface {
+	Runner
+	// RunDemotable will run if the instance takes the lead, the run context is cancelled when the
+	// leadership is lost. It's a blocking action.
+	RunDemotable(ctx context.Context, f func(ctx context.Context) error) error
+	// DemotionGracePeriod returns the time the run has to end once the leadership is lost.
+	DemotionGracePeriod() time.Duration
+}
+
+// IdentityRunner is a Runner that knows its identity as a leader election candidate, the identity
+// that is set as the lock holder when it's the leader.
+type IdentityRunner interface {
+	Runner
+	// Identity returns the candidate identity.
+	Identity() string
+}
+
 // runner is the leader election default implementation.
 type runner struct {
 	key          string
@@ -50,6 +112,7 @@ type runner struct {
 	k8scli       kubernetes.Interface
 	lockCfg      *LockConfig
 	resourceLock resourcelock.Interface
+	leaseLock    *leaseExpirationLock
 	logger       log.Logger
 }
 
@@ -69,6 +132,14 @@ func New(key, namespace string, lockCfg *LockConfig, k8scli kubernetes.Interface
 		}
 	}
 
+	if lockCfg.LockType == "" {
+		lockCfg.LockType = LeaseLock
+	}
+
+	if lockCfg.MetricsRecorder == nil {
+		lockCfg.MetricsRecorder = DummyMetricsRecorder
+	}
+
 	r := &runner{
 		lockCfg:   lockCfg,
 		key:       key,
@@ -100,9 +171,21 @@ func (r *runner) validate() error {
 	if r.key == "" {
 		return fmt.Errorf("running in leader election mode requires a key for identification the different instances")
 	}
+	// Lock type must be known.
+	if _, ok := resourceLockTypes[r.lockCfg.LockType]; !ok {
+		return fmt.Errorf("unknown lock type %q", r.lockCfg.LockType)
+	}
+
 	return nil
 }
 
+// resourceLockTypes are the client-go resource locks of every lock type.
+var resourceLockTypes = map[LockType]string{
+	LeaseLock:     resourcelock.LeasesResourceLock,
+	ConfigMapLock: resourcelock.ConfigMapsResourceLock,
+	EndpointsLock: resourcelock.EndpointsResourceLock,
+}
+
 func (r *runner) initResourceLock() error {
 	// Create the lock resource for the leader election.
 	hostname, err := os.Hostname()
@@ -115,7 +198,7 @@ func (r *runner) initResourceLock() error {
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: r.key, Host: id})
 
 	rl, err := resourcelock.New(
-		resourcelock.ConfigMapsResourceLock,
+		resourceLockTypes[r.lockCfg.LockType],
 		r.namespace,
 		r.key,
 		r.k8scli.CoreV1(),
@@ -129,24 +212,74 @@ func (r *runner) initResourceLock() error {
 		return fmt.Errorf("error creating lock: %v", err)
 	}
 
+	if r.lockCfg.ClockSkewThreshold > 0 {
+		rl = newClockSkewDetectorLock(fmt.Sprintf("%s/%s", r.namespace, r.key), r.lockCfg.ClockSkewThreshold, r.lockCfg.MetricsRecorder, r.logger, rl)
+	}
+
+	if r.lockCfg.OnRenew != nil {
+		rl = newRenewNotifierLock(r.lockCfg.OnRenew, rl)
+	}
+
+	r.leaseLock = newLeaseExpirationLock(rl)
+	rl = r.leaseLock
+
 	r.resourceLock = rl
 	return nil
 
 }
 
 func (r *runner) Run(f func() error) error {
+	return r.RunDemotable(context.Background(), func(context.Context) error { return f() })
+}
+
+// Identity satisfies IdentityRunner interface.
+func (r *runner) Identity() string {
+	return r.resourceLock.Identity()
+}
+
+// DemotionGracePeriod satisfies DemotableRunner interface.
+func (r *runner) DemotionGracePeriod() time.Duration {
+	return r.lockCfg.DemotionGracePeriod
+}
+
+// RunDemotable satisfies DemotableRunner interface.
+func (r *runner) RunDemotable(ctx context.Context, f func(ctx context.Context) error) error {
 	errC := make(chan error, 1) // Channel to get the function returning error.
 
+	// runDoneC is closed when the function returns, it's set once the leadership is acquired.
+	var mu sync.Mutex
+	var runDoneC chan struct{}
+
 	// The function to execute when leader acquired.
-	lef := func(ctx context.Context) {
+	lef := func(ctx context.Context, leCtx context.Context) {
 		r.logger.Infof("lead acquire, starting...")
-		// Wait until f finishes or leader elector runner stops.
-		select {
-		case <-ctx.Done():
-			errC <- nil
-		case errC <- f():
-		}
+		doneC := make(chan struct{})
+		mu.Lock()
+		runDoneC = doneC
+		mu.Unlock()
+
+		// Stop the function when the leadership is lost.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-leCtx.Done():
+				cancel()
+			case <-doneC:
+			}
+		}()
+
+		err := f(ctx)
+		close(doneC)
 		r.logger.Infof("lead execution stopped")
+
+		// If the leadership has been lost, the stopped leading callback returns the result.
+		if leCtx.Err() == nil {
+			select {
+			case errC <- err:
+			default:
+			}
+		}
 	}
 
 	// Create the leader election configuration
@@ -156,9 +289,39 @@ func (r *runner) Run(f func() error) error {
 		RenewDeadline: r.lockCfg.RenewDeadline,
 		RetryPeriod:   r.lockCfg.RetryPeriod,
 		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: lef,
+			OnStartedLeading: func(leCtx context.Context) {
+				if r.lockCfg.OnStartedLeading != nil {
+					go r.lockCfg.OnStartedLeading(leCtx)
+				}
+				lef(ctx, leCtx)
+			},
 			OnStoppedLeading: func() {
-				errC <- fmt.Errorf("leadership lost")
+				r.leaseLock.reset()
+				if r.lockCfg.OnStoppedLeading != nil {
+					r.lockCfg.OnStoppedLeading()
+				}
+
+				mu.Lock()
+				doneC := runDoneC
+				mu.Unlock()
+				if grace := r.lockCfg.DemotionGracePeriod; doneC != nil && grace > 0 {
+					select {
+					case <-doneC:
+					default:
+						r.logger.Warningf("leadership lost, waiting up to %s for the in-flight operations", grace)
+						select {
+						case <-doneC:
+						case <-time.After(grace):
+							r.logger.Warningf("demotion grace period of %s elapsed with in-flight operations", grace)
+						}
+					}
+				}
+
+				// If the run already ended nobody is waiting for the result.
+				select {
+				case errC <- fmt.Errorf("leadership lost"):
+				default:
+				}
 			},
 		},
 	}
@@ -170,10 +333,24 @@ func (r *runner) Run(f func() error) error {
 	}
 
 	// Execute!
+	// The leader election stops when the run ends.
+	leCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	r.logger.Infof("running in leader election mode, waiting to acquire leadership...")
-	go le.Run(context.TODO())
+	go le.Run(leCtx)
 
-	// Wait until stopping the execution returns the result.
-	err = <-errC
+	// Wait until stopping the execution returns the result, if the context is done before acquiring
+	// the leadership there is nothing to wait.
+	select {
+	case err = <-errC:
+	case <-ctx.Done():
+		mu.Lock()
+		leading := runDoneC != nil
+		mu.Unlock()
+		if !leading {
+			return nil
+		}
+		err = <-errC
+	}
 	return err
 }
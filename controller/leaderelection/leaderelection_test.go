@@ -0,0 +1,475 @@
+package leaderelection_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/adevjoe/kooper/v2/controller/leaderelection"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// warningLogger is a logger that stores the logged warnings.
+type warningLogger struct {
+	log.Logger
+	mu       *sync.Mutex
+	warnings *[]string
+}
+
+func (w warningLogger) Warningf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	*w.warnings = append(*w.warnings, fmt.Sprintf(format, args...))
+}
+
+func (w warningLogger) WithKV(log.KV) log.Logger { return w }
+
+func (w warningLogger) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(*w.warnings)
+}
+
+// skewRecorder is a metrics recorder that stores the observed clock skews.
+type skewRecorder struct {
+	mu    sync.Mutex
+	skews []time.Duration
+}
+
+func (s *skewRecorder) ObserveLeaderElectionClockSkew(_ context.Context, _ string, skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skews = append(s.skews, skew)
+}
+
+func (s *skewRecorder) observed() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Duration{}, s.skews...)
+}
+
+func TestRunnerClockSkew(t *testing.T) {
+	tests := map[string]struct {
+		leaderTimeOffset time.Duration
+		expSkew          bool
+	}{
+		"A leader with the lock timestamps in the future beyond the threshold should be detected as a clock skew.": {
+			leaderTimeOffset: 1 * time.Hour,
+			expSkew:          true,
+		},
+
+		"A leader with the lock timestamps in the past should not be detected as a clock skew.": {
+			leaderTimeOffset: -2 * time.Second,
+			expSkew:          false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Create a lock already taken by other leader with skewed timestamps.
+			leaderTime := metav1.NewTime(time.Now().Add(test.leaderTimeOffset))
+			record, err := json.Marshal(resourcelock.LeaderElectionRecord{
+				HolderIdentity:       "other-leader",
+				LeaseDurationSeconds: 9999,
+				AcquireTime:          leaderTime,
+				RenewTime:            leaderTime,
+			})
+			require.NoError(err)
+			mc := fake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "default",
+					Annotations: map[string]string{resourcelock.LeaderElectionRecordAnnotationKey: string(record)},
+				},
+			})
+
+			logger := warningLogger{Logger: log.Dummy, mu: &sync.Mutex{}, warnings: &[]string{}}
+			recorder := &skewRecorder{}
+			r, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+				LockType:           leaderelection.ConfigMapLock,
+				LeaseDuration:      9999 * time.Second,
+				RenewDeadline:      9998 * time.Second,
+				RetryPeriod:        10 * time.Millisecond,
+				ClockSkewThreshold: 5 * time.Second,
+				MetricsRecorder:    recorder,
+			}, mc, logger)
+			require.NoError(err)
+
+			// The other leader has the lock, so our runner will be trying to acquire the lock.
+			go func() { _ = r.Run(func() error { return nil }) }()
+
+			if test.expSkew {
+				assert.Eventually(func() bool { return len(recorder.observed()) > 0 }, 1*time.Second, 5*time.Millisecond)
+				assert.Greater(logger.count(), 0)
+				for _, skew := range recorder.observed() {
+					assert.Greater(int64(skew), int64(59*time.Minute))
+				}
+			} else {
+				time.Sleep(100 * time.Millisecond)
+				assert.Empty(recorder.observed())
+				assert.Equal(0, logger.count())
+			}
+		})
+	}
+}
+
+func TestInMemoryForceHandover(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	le := leaderelection.NewInMemory(log.Dummy)
+	stopC := make(chan struct{})
+	r1ResultC := make(chan error, 1)
+	r2ResultC := make(chan error, 1)
+	r2StartedC := make(chan struct{})
+
+	go func() { r1ResultC <- le.Runner("r1").Run(func() error { <-stopC; return nil }) }()
+	require.Eventually(func() bool { return le.Leader() == "r1" }, 1*time.Second, time.Millisecond)
+
+	// Not running candidates can't be granted.
+	assert.Error(le.ForceHandover("r2"))
+
+	go func() {
+		r2ResultC <- le.Runner("r2").Run(func() error { close(r2StartedC); <-stopC; return nil })
+	}()
+	require.Eventually(func() bool { return le.ForceHandover("r2") == nil }, 1*time.Second, time.Millisecond)
+
+	// The old leader should lose the leadership and the new one should start.
+	select {
+	case err := <-r1ResultC:
+		assert.Error(err)
+	case <-time.After(1 * time.Second):
+		assert.Fail("old leader should lose the leadership")
+	}
+	select {
+	case <-r2StartedC:
+	case <-time.After(1 * time.Second):
+		assert.Fail("new leader should start")
+	}
+
+	// Ending the leader execution should release the leadership.
+	close(stopC)
+	assert.NoError(<-r2ResultC)
+	assert.Equal("", le.Leader())
+}
+
+func TestInMemoryRunDemotable(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	le := leaderelection.NewInMemory(log.Dummy)
+	leader := le.Runner("r1").(leaderelection.DemotableRunner)
+	candidate := le.Runner("r2").(leaderelection.DemotableRunner)
+
+	leaderCtxC := make(chan context.Context, 1)
+	leaderResultC := make(chan error, 1)
+	go func() {
+		leaderResultC <- leader.RunDemotable(context.Background(), func(ctx context.Context) error {
+			leaderCtxC <- ctx
+			<-ctx.Done()
+			return nil
+		})
+	}()
+	var leaderCtx context.Context
+	select {
+	case leaderCtx = <-leaderCtxC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the leadership")
+	}
+
+	// A candidate that never acquires the leadership should end when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	candidateResultC := make(chan error, 1)
+	run := false
+	go func() {
+		candidateResultC <- candidate.RunDemotable(ctx, func(context.Context) error { run = true; return nil })
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-candidateResultC:
+		assert.NoError(err)
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the candidate to end")
+	}
+	assert.False(run)
+	assert.Equal("r1", le.Leader())
+
+	// A demoted leader should have its run context cancelled.
+	r3ResultC := make(chan error, 1)
+	go func() {
+		r3ResultC <- le.Runner("r3").Run(func() error { return nil })
+	}()
+	require.Eventually(func() bool { return le.ForceHandover("r3") == nil }, 1*time.Second, time.Millisecond)
+	select {
+	case err := <-leaderResultC:
+		assert.Error(err)
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the demotion")
+	}
+	assert.Eventually(func() bool { return leaderCtx.Err() != nil }, 1*time.Second, time.Millisecond)
+	assert.NoError(<-r3ResultC)
+}
+
+func TestRunnerLockType(t *testing.T) {
+	tests := map[string]struct {
+		lockType leaderelection.LockType
+		expErr   bool
+		getLock  func(ctx context.Context, mc *fake.Clientset) error
+	}{
+		"By default the lock should be a lease.": {
+			getLock: func(ctx context.Context, mc *fake.Clientset) error {
+				_, err := mc.CoordinationV1().Leases("default").Get(ctx, "test", metav1.GetOptions{})
+				return err
+			},
+		},
+
+		"A configmap lock should use a configmap.": {
+			lockType: leaderelection.ConfigMapLock,
+			getLock: func(ctx context.Context, mc *fake.Clientset) error {
+				_, err := mc.CoreV1().ConfigMaps("default").Get(ctx, "test", metav1.GetOptions{})
+				return err
+			},
+		},
+
+		"An endpoints lock should use an endpoints.": {
+			lockType: leaderelection.EndpointsLock,
+			getLock: func(ctx context.Context, mc *fake.Clientset) error {
+				_, err := mc.CoreV1().Endpoints("default").Get(ctx, "test", metav1.GetOptions{})
+				return err
+			},
+		},
+
+		"An unknown lock type should fail.": {
+			lockType: leaderelection.LockType("unknown"),
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mc := fake.NewSimpleClientset()
+			r, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+				LockType:      test.lockType,
+				LeaseDuration: 9999 * time.Second,
+				RenewDeadline: 9998 * time.Second,
+				RetryPeriod:   10 * time.Millisecond,
+			}, mc, log.Dummy)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			startedC := make(chan struct{})
+			stopC := make(chan struct{})
+			defer close(stopC)
+			go func() {
+				_ = r.Run(func() error {
+					close(startedC)
+					<-stopC
+					return nil
+				})
+			}()
+
+			select {
+			case <-startedC:
+			case <-time.After(1 * time.Second):
+				require.FailNow("timeout waiting for the leadership")
+			}
+			assert.NoError(test.getLock(context.TODO(), mc))
+		})
+	}
+}
+
+func TestRunnerOnRenew(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var mu sync.Mutex
+	renewals := 0
+	mc := fake.NewSimpleClientset()
+	r, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+		LeaseDuration: 9999 * time.Second,
+		RenewDeadline: 9998 * time.Second,
+		RetryPeriod:   10 * time.Millisecond,
+		OnRenew: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			renewals++
+		},
+	}, mc, log.Dummy)
+	require.NoError(err)
+
+	startedC := make(chan struct{})
+	stopC := make(chan struct{})
+	defer close(stopC)
+	go func() {
+		_ = r.Run(func() error {
+			close(startedC)
+			<-stopC
+			return nil
+		})
+	}()
+
+	// Wait until we are the leader.
+	select {
+	case <-startedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the leadership")
+	}
+
+	// The leader should renew the lease every retry period.
+	assert.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return renewals >= 3
+	}, 1*time.Second, 5*time.Millisecond)
+}
+
+func TestRunnerLeadingCallbacks(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	startedC := make(chan context.Context, 1)
+	stoppedC := make(chan struct{}, 1)
+	mc := fake.NewSimpleClientset()
+	r, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+		LeaseDuration:    9999 * time.Second,
+		RenewDeadline:    9998 * time.Second,
+		RetryPeriod:      10 * time.Millisecond,
+		OnStartedLeading: func(ctx context.Context) { startedC <- ctx },
+		OnStoppedLeading: func() { stoppedC <- struct{}{} },
+	}, mc, log.Dummy)
+	require.NoError(err)
+
+	stopC := make(chan struct{})
+	resultC := make(chan error, 1)
+	go func() { resultC <- r.Run(func() error { <-stopC; return nil }) }()
+
+	// Acquiring the leadership should call the started callback.
+	var leadingCtx context.Context
+	select {
+	case leadingCtx = <-startedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the started leading callback")
+	}
+	assert.NoError(leadingCtx.Err())
+	assert.Empty(stoppedC)
+
+	// Ending the run should stop the leader election and call the stopped callback.
+	close(stopC)
+	assert.NoError(<-resultC)
+	select {
+	case <-stoppedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the stopped leading callback")
+	}
+	assert.Error(leadingCtx.Err())
+}
+
+func TestRunnerRunDemotableContextDoneWithoutLeadership(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mc := fake.NewSimpleClientset()
+	lockCfg := &leaderelection.LockConfig{
+		LeaseDuration: 9999 * time.Second,
+		RenewDeadline: 9998 * time.Second,
+		RetryPeriod:   10 * time.Millisecond,
+	}
+	leader, err := leaderelection.New("test", "default", lockCfg, mc, log.Dummy)
+	require.NoError(err)
+	candidate, err := leaderelection.New("test", "default", lockCfg, mc, log.Dummy)
+	require.NoError(err)
+
+	leadingC := make(chan struct{})
+	stopC := make(chan struct{})
+	defer close(stopC)
+	go func() { _ = leader.Run(func() error { close(leadingC); <-stopC; return nil }) }()
+	<-leadingC
+
+	// The candidate should stop waiting for the leadership when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	resultC := make(chan error, 1)
+	run := false
+	go func() {
+		resultC <- candidate.(leaderelection.DemotableRunner).RunDemotable(ctx, func(context.Context) error {
+			run = true
+			return nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-resultC:
+		assert.NoError(err)
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the run to end")
+	}
+	assert.False(run)
+}
+
+func TestRunnerLeaseExpiration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mc := fake.NewSimpleClientset()
+	r, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+		LeaseDuration: 9999 * time.Second,
+		RenewDeadline: 9998 * time.Second,
+		RetryPeriod:   10 * time.Millisecond,
+	}, mc, log.Dummy)
+	require.NoError(err)
+	lr := r.(leaderelection.LeaseRunner)
+
+	// Without the leadership there is no lease.
+	_, ok := lr.LeaseExpiration()
+	assert.False(ok)
+
+	startedC := make(chan struct{})
+	stopC := make(chan struct{})
+	resultC := make(chan error, 1)
+	go func() {
+		resultC <- r.Run(func() error {
+			close(startedC)
+			<-stopC
+			return nil
+		})
+	}()
+	select {
+	case <-startedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the leadership")
+	}
+
+	// The lease should expire a lease duration after the last renewal.
+	exp, ok := lr.LeaseExpiration()
+	require.True(ok)
+	assert.WithinDuration(time.Now().Add(9999*time.Second), exp, 5*time.Second)
+
+	// Ending the run releases the lease.
+	close(stopC)
+	assert.NoError(<-resultC)
+	assert.Eventually(func() bool {
+		_, ok := lr.LeaseExpiration()
+		return !ok
+	}, 1*time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,77 @@
+package leaderelection
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaseRunner is a Runner that knows when its leadership lease expires, the controllers use it
+// to bound the handlings to the remaining leadership time.
+type LeaseRunner interface {
+	Runner
+	// LeaseExpiration returns the time the leadership lease expires if it's not renewed, false if
+	// the instance doesn't hold the lease.
+	LeaseExpiration() (time.Time, bool)
+}
+
+// leaseExpirationLock is a resource lock wrapper that tracks the expiration of the lease held by us.
+// The lock records written by us are set with our clock, so the expiration is the renew time of the
+// last written record plus its lease duration.
+type leaseExpirationLock struct {
+	resourcelock.Interface
+
+	mu         sync.Mutex
+	expiration time.Time
+}
+
+func newLeaseExpirationLock(lock resourcelock.Interface) *leaseExpirationLock {
+	return &leaseExpirationLock{Interface: lock}
+}
+
+func (l *leaseExpirationLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Create(ctx, ler)
+	if err == nil {
+		l.track(ler)
+	}
+	return err
+}
+
+func (l *leaseExpirationLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Update(ctx, ler)
+	if err == nil {
+		l.track(ler)
+	}
+	return err
+}
+
+func (l *leaseExpirationLock) track(ler resourcelock.LeaderElectionRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A record of other holder (e.g a released lock) is not our lease.
+	if ler.HolderIdentity != l.Identity() {
+		l.expiration = time.Time{}
+		return
+	}
+	l.expiration = ler.RenewTime.Add(time.Duration(ler.LeaseDurationSeconds) * time.Second)
+}
+
+func (l *leaseExpirationLock) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expiration = time.Time{}
+}
+
+func (l *leaseExpirationLock) get() (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expiration, !l.expiration.IsZero()
+}
+
+// LeaseExpiration satisfies LeaseRunner interface.
+func (r *runner) LeaseExpiration() (time.Time, bool) {
+	return r.leaseLock.get()
+}
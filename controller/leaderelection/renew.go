@@ -0,0 +1,53 @@
+package leaderelection
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// renewNotifierLock is a resource lock wrapper that calls a hook on every successful lease renewal.
+// A renewal is an update of a lock record that was already held by us, the updates that acquire
+// the lock from other holders are not renewals.
+type renewNotifierLock struct {
+	resourcelock.Interface
+	onRenew func()
+
+	mu         sync.Mutex
+	lastHolder string
+}
+
+func newRenewNotifierLock(onRenew func(), lock resourcelock.Interface) resourcelock.Interface {
+	return &renewNotifierLock{
+		Interface: lock,
+		onRenew:   onRenew,
+	}
+}
+
+func (r *renewNotifierLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	record, raw, err := r.Interface.Get(ctx)
+	if err == nil && record != nil {
+		r.mu.Lock()
+		r.lastHolder = record.HolderIdentity
+		r.mu.Unlock()
+	}
+
+	return record, raw, err
+}
+
+func (r *renewNotifierLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := r.Interface.Update(ctx, ler)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	renewal := r.lastHolder == r.Identity()
+	r.mu.Unlock()
+	if renewal {
+		r.onRenew()
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// newLeaseDeadlineHandler returns a handler that caps the handling context deadline at the leadership
+// lease expiration, so the handlings don't outlive a leadership that could have been lost (e.g the
+// lease can't be renewed). The handlings that would start with an expired lease get a done context.
+func newLeaseDeadlineHandler(expiration func() (time.Time, bool), next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		exp, ok := expiration()
+		if !ok {
+			return handleWithResult(ctx, next, obj)
+		}
+
+		ctx, cancel := context.WithDeadline(ctx, exp)
+		defer cancel()
+		return handleWithResult(ctx, next, obj)
+	})
+}
@@ -0,0 +1,87 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/leaderelection"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerLeaseBoundDeadline(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mc := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}})
+	le, err := leaderelection.New("test", "default", &leaderelection.LockConfig{
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: 1 * time.Second,
+		RetryPeriod:   500 * time.Millisecond,
+	}, mc, log.Dummy)
+	require.NoError(err)
+
+	var mu sync.Mutex
+	var deadline time.Time
+	var hasDeadline bool
+	var handledAt time.Time
+	handledC := make(chan struct{})
+	h := controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if handledAt.IsZero() {
+			deadline, hasDeadline = ctx.Deadline()
+			handledAt = time.Now()
+			close(handledC)
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:               "test",
+		Handler:            h,
+		Retriever:          newNamespaceRetriever(mc),
+		LeaderElector:      le,
+		Logger:             log.Dummy,
+		LeaseBoundDeadline: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case <-handledC:
+	case <-time.After(2 * time.Second):
+		require.FailNow("timeout waiting for the handling")
+	}
+
+	// The handling deadline should be bounded by the remaining lease time.
+	mu.Lock()
+	defer mu.Unlock()
+	require.True(hasDeadline)
+	remaining := deadline.Sub(handledAt)
+	assert.True(remaining > 0, "remaining %s", remaining)
+	assert.True(remaining <= 2*time.Second, "remaining %s", remaining)
+}
+
+func TestGenericControllerLeaseBoundDeadlineWithoutLease(t *testing.T) {
+	_, err := controller.New(&controller.Config{
+		Name:               "test",
+		Handler:            controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:          newNamespaceRetriever(&fake.Clientset{}),
+		LeaderElector:      leaderelection.NewInMemory(log.Dummy).Runner("test"),
+		Logger:             log.Dummy,
+		LeaseBoundDeadline: true,
+	})
+	assert.Error(t, err)
+}
@@ -17,6 +17,35 @@ type MetricsRecorder interface {
 	// RegisterResourceQueueLengthFunc will register a function that will be called
 	// by the metrics recorder to get the length of a queue at a given point in time.
 	RegisterResourceQueueLengthFunc(controller string, f func(context.Context) int) error
+	// RegisterControllerLabels will register the labels of a controller, these labels should be added
+	// to all the metrics of the controller. It's called before any other metric is recorded for the controller.
+	// Returns an error if the labels are not valid for the recorder (e.g not allowed label keys).
+	RegisterControllerLabels(controller string, labels map[string]string) error
+	// IncResourceOversizedSkipped increments in one the metric records of a skipped object that exceeds the max object size.
+	IncResourceOversizedSkipped(ctx context.Context, controller string)
+	// IncResourceInvalidSkipped increments in one the metric records of a skipped object with invalid object meta.
+	IncResourceInvalidSkipped(ctx context.Context, controller string)
+	// IncResourceProcessingOutcome increments in one the metric records of the final outcome of an object
+	// processing (e.g `ProcessingOutcomeFirstTrySuccess`).
+	IncResourceProcessingOutcome(ctx context.Context, controller string, outcome string)
+	// IncResourceStaleCacheConflict increments in one the metric records of a handling conflict caused by a stale cache object.
+	IncResourceStaleCacheConflict(ctx context.Context, controller string)
+	// IncResourceWatchExpired increments in one the metric records of a watch that expired because its
+	// resource version was too old (410 Gone), frequent expirations indicate etcd compaction pressure.
+	IncResourceWatchExpired(ctx context.Context, controller string)
+	// IncResourceInformerRebuild increments in one the metric records of a forced informer rebuild (full relist).
+	IncResourceInformerRebuild(ctx context.Context, controller string)
+	// SetResourceDegraded sets the degraded state of a controller, a controller is degraded while it can't
+	// reach the API server (the lists and watches fail) and keeps handling the objects from its cache.
+	SetResourceDegraded(ctx context.Context, controller string, degraded bool)
+}
+
+// ResourceQueueLengthFuncUnregisterer is an optional interface of the `MetricsRecorder` to unregister the queue
+// length function of a controller. The controllers unregister it once stopped, so a controller recreated with the
+// same name (e.g `Supervise`) can register its queue again.
+type ResourceQueueLengthFuncUnregisterer interface {
+	// UnregisterResourceQueueLengthFunc unregisters the function registered with `RegisterResourceQueueLengthFunc`.
+	UnregisterResourceQueueLengthFunc(controller string)
 }
 
 // DummyMetricsRecorder is a dummy metrics recorder.
@@ -31,3 +60,11 @@ func (dummy) ObserveResourceProcessingDuration(context.Context, string, bool, ti
 func (dummy) RegisterResourceQueueLengthFunc(controller string, f func(context.Context) int) error {
 	return nil
 }
+func (dummy) RegisterControllerLabels(controller string, labels map[string]string) error { return nil }
+func (dummy) IncResourceOversizedSkipped(context.Context, string)                        {}
+func (dummy) IncResourceInvalidSkipped(context.Context, string)                          {}
+func (dummy) IncResourceProcessingOutcome(context.Context, string, string)               {}
+func (dummy) IncResourceStaleCacheConflict(context.Context, string)                      {}
+func (dummy) IncResourceWatchExpired(context.Context, string)                            {}
+func (dummy) IncResourceInformerRebuild(context.Context, string)                         {}
+func (dummy) SetResourceDegraded(context.Context, string, bool)                          {}
@@ -0,0 +1,167 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// counterRecorder is a custom metrics backend recorder that counts the queue and processing metrics.
+type counterRecorder struct {
+	controller.MetricsRecorder
+	mu        sync.Mutex
+	queued    int
+	processed map[bool]int
+	queueLen  func(context.Context) int
+}
+
+func (c *counterRecorder) IncResourceEventQueued(context.Context, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queued++
+}
+
+func (c *counterRecorder) ObserveResourceProcessingDuration(_ context.Context, _ string, success bool, _ time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processed[success]++
+}
+
+func (c *counterRecorder) RegisterResourceQueueLengthFunc(_ string, f func(context.Context) int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queueLen = f
+	return nil
+}
+
+func (c *counterRecorder) get() (queued int, processed map[bool]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	processed = map[bool]int{}
+	for k, v := range c.processed {
+		processed[k] = v
+	}
+	return c.queued, processed
+}
+
+func TestGenericControllerCustomMetricsRecorder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	blockC := make(chan struct{})
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+		<-blockC
+		return nil
+	})
+
+	mrec := &counterRecorder{MetricsRecorder: controller.DummyMetricsRecorder, processed: map[bool]int{}}
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         newNamespaceRetriever(mc),
+		Logger:            log.Dummy,
+		MetricsRecorder:   mrec,
+		ConcurrentWorkers: 1,
+	})
+	require.NoError(err)
+	require.NotNil(mrec.queueLen)
+	go func() { _ = c.Run(ctx) }()
+
+	// The queue depth should be measured with the registered func while the objects wait.
+	assert.Eventually(func() bool { return mrec.queueLen(ctx) == 2 }, 1*time.Second, 5*time.Millisecond)
+	close(blockC)
+
+	assert.Eventually(func() bool {
+		_, processed := mrec.get()
+		return processed[true] == 3
+	}, 1*time.Second, 5*time.Millisecond)
+	queued, processed := mrec.get()
+	assert.Equal(3, queued)
+	assert.Equal(map[bool]int{true: 3}, processed)
+	assert.Equal(0, mrec.queueLen(ctx))
+}
+
+// queueRecorder is a custom metrics backend recorder that records the queue metrics.
+type queueRecorder struct {
+	controller.MetricsRecorder
+	mu        sync.Mutex
+	queued    map[bool]int
+	durations []time.Duration
+}
+
+func (q *queueRecorder) IncResourceEventQueued(_ context.Context, _ string, isRequeue bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queued[isRequeue]++
+}
+
+func (q *queueRecorder) ObserveResourceInQueueDuration(_ context.Context, _ string, queuedAt time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.durations = append(q.durations, time.Since(queuedAt))
+}
+
+func (q *queueRecorder) get() (queued map[bool]int, durations []time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queued = map[bool]int{}
+	for k, v := range q.queued {
+		queued[k] = v
+	}
+	return queued, append([]time.Duration{}, q.durations...)
+}
+
+func TestGenericControllerQueueMetricsRequeueAfter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl, nss := createNamespaceList("testing", 1)
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	// Every handling schedules the next one far away, the object changes will be handled before.
+	h := controller.ResultHandlerFunc(func(context.Context, runtime.Object) (controller.Result, error) {
+		return controller.Result{RequeueAfter: time.Hour}, nil
+	})
+
+	mrec := &queueRecorder{MetricsRecorder: controller.DummyMetricsRecorder, queued: map[bool]int{}}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		Logger:          log.Dummy,
+		MetricsRecorder: mrec,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { _, d := mrec.get(); return len(d) == 1 }, 1*time.Second, 5*time.Millisecond)
+	ns := nss[0].DeepCopy()
+	ns.ResourceVersion = "2"
+	w.Modify(ns)
+	assert.Eventually(func() bool { _, d := mrec.get(); return len(d) == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	// The scheduled handlings are not requeues, and the object changed before the schedule
+	// should not have a negative time on the queue.
+	queued, durations := mrec.get()
+	assert.Equal(map[bool]int{false: 4}, queued)
+	for _, d := range durations {
+		assert.GreaterOrEqual(d, time.Duration(0))
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// ErrObjectMutated is the handling error when the handler mutates the received object (check
+// `Config.DetectObjectMutations`).
+var ErrObjectMutated = errors.New("handler mutated the received object")
+
+// newObjectMutationHandler returns a handler that detects the handlers that mutate the received
+// object, the objects are shared with the controller cache so they must be deep copied before
+// modifying them. The handler receives a copy of the object, that is compared with the original after
+// the handling, the handlings that mutated the copy will fail with `ErrObjectMutated`.
+func newObjectMutationHandler(logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		objCopy := obj.DeepCopyObject()
+		res, err := handleWithResult(ctx, next, objCopy)
+
+		if !equality.Semantic.DeepEqual(obj, objCopy) {
+			d := diff.ObjectReflectDiff(obj, objCopy)
+			logger.Errorf("handler mutated the received object, deep copy the objects before modifying them: %s", d)
+			return Result{}, fmt.Errorf("%w: %s", ErrObjectMutated, d)
+		}
+
+		return res, err
+	})
+}
@@ -0,0 +1,76 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDetectObjectMutations(t *testing.T) {
+	tests := map[string]struct {
+		handler   controller.HandlerFunc
+		expResult string
+		expErr    bool
+	}{
+		"A handler that mutates the received object should fail.": {
+			handler: func(_ context.Context, obj runtime.Object) error {
+				ns := obj.(*corev1.Namespace)
+				ns.Labels = map[string]string{"changed": "true"}
+				return nil
+			},
+			expResult: controller.ReportResultError,
+			expErr:    true,
+		},
+
+		"A handler that mutates a copy of the received object should not fail.": {
+			handler: func(_ context.Context, obj runtime.Object) error {
+				ns := obj.(*corev1.Namespace).DeepCopy()
+				ns.Labels = map[string]string{"changed": "true"}
+				return nil
+			},
+			expResult: controller.ReportResultSuccess,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList, _ := createNamespaceList("testing", 1)
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			reporter := controller.NewReporter()
+			c, err := controller.New(&controller.Config{
+				Name:                  "test",
+				Handler:               test.handler,
+				Retriever:             newNamespaceRetriever(mc),
+				Logger:                log.Dummy,
+				Reporter:              reporter,
+				DetectObjectMutations: true,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool { return len(reporter.Entries()) == 1 }, 1*time.Second, 5*time.Millisecond)
+			entry := reporter.Entries()[0]
+			assert.Equal(test.expResult, entry.Result)
+			if test.expErr {
+				assert.Contains(entry.Error, controller.ErrObjectMutated.Error())
+				assert.Contains(entry.Error, "changed") // The diff of the mutation.
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// newObjectDumpHandler returns a handler that logs at debug level the YAML of the objects
+// that failed the handling. The redact func receives a copy of the object that can be
+// mutated to strip the sensitive data before dumping it.
+func newObjectDumpHandler(redact func(obj runtime.Object) runtime.Object, logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		res, err := handleWithResult(ctx, next, obj)
+		if err == nil {
+			return res, err
+		}
+
+		// The object could be shared with the cache.
+		dump := obj.DeepCopyObject()
+		if redact != nil {
+			dump = redact(dump)
+		}
+
+		data, yamlErr := yaml.Marshal(dump)
+		if yamlErr != nil {
+			logger.Warningf("could not dump failed object: %s", yamlErr)
+			return res, err
+		}
+		logger.Debugf("failed object dump (error: %s):\n%s", err, data)
+
+		return res, err
+	})
+}
@@ -0,0 +1,148 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// debugLogger is a logger that stores the debug messages.
+type debugLogger struct {
+	log.Logger
+	mu       sync.Mutex
+	messages []string
+}
+
+func (d *debugLogger) Debugf(format string, args ...interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, fmt.Sprintf(format, args...))
+}
+
+func (d *debugLogger) WithKV(log.KV) log.Logger { return d }
+
+func (d *debugLogger) debugMessages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.messages...)
+}
+
+func TestGenericControllerDumpObjectOnError(t *testing.T) {
+	tests := map[string]struct {
+		handlerErr     error
+		redact         func(obj runtime.Object) runtime.Object
+		expDump        bool
+		expContains    []string
+		expNotContains []string
+	}{
+		"A successful handling should not dump the object.": {
+			expDump: false,
+		},
+
+		"A failed handling should dump the object.": {
+			handlerErr:  fmt.Errorf("wanted error"),
+			expDump:     true,
+			expContains: []string{"wanted error", "name: test-secret", "password: c3VwZXJzZWNyZXQ="},
+		},
+
+		"A failed handling should dump the redacted object.": {
+			handlerErr: fmt.Errorf("wanted error"),
+			redact: func(obj runtime.Object) runtime.Object {
+				s := obj.(*corev1.Secret)
+				for k := range s.Data {
+					s.Data[k] = []byte("redacted")
+				}
+				return s
+			},
+			expDump:        true,
+			expContains:    []string{"wanted error", "name: test-secret", "password: cmVkYWN0ZWQ="},
+			expNotContains: []string{"c3VwZXJzZWNyZXQ="},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			secret := corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test", ResourceVersion: "1"},
+				Data:       map[string][]byte{"password": []byte("supersecret")},
+			}
+			sl := &corev1.SecretList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: []corev1.Secret{secret}}
+			ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+				ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return sl, nil },
+				WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+			})
+
+			handledC := make(chan runtime.Object, 1)
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				handledC <- obj
+				return test.handlerErr
+			})
+
+			logger := &debugLogger{Logger: log.Dummy}
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            ret,
+				Logger:               logger,
+				DumpObjectOnError:    true,
+				DumpObjectRedactFunc: test.redact,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			var handled runtime.Object
+			select {
+			case handled = <-handledC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for handling")
+			}
+
+			dumps := func() []string {
+				dumps := []string{}
+				for _, m := range logger.debugMessages() {
+					if strings.HasPrefix(m, "failed object dump") {
+						dumps = append(dumps, m)
+					}
+				}
+				return dumps
+			}
+
+			if !test.expDump {
+				time.Sleep(20 * time.Millisecond)
+				assert.Empty(dumps())
+				return
+			}
+
+			assert.Eventually(func() bool { return len(dumps()) == 1 }, 1*time.Second, 5*time.Millisecond)
+			dump := dumps()[0]
+			for _, exp := range test.expContains {
+				assert.Contains(dump, exp)
+			}
+			for _, exp := range test.expNotContains {
+				assert.NotContains(dump, exp)
+			}
+
+			// The redaction should not mutate the handled object.
+			assert.Equal([]byte("supersecret"), handled.(*corev1.Secret).Data["password"])
+		})
+	}
+}
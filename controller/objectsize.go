@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// sizeDeletions tracks the objects removed from the cache because they grew beyond the max size,
+// their delete events are not real deletions, the objects still exist.
+type sizeDeletions struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newSizeDeletions() *sizeDeletions {
+	return &sizeDeletions{keys: map[string]bool{}}
+}
+
+func (s *sizeDeletions) add(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = true
+}
+
+// pop returns true if the delete event of the object has been emitted by the size filter.
+func (s *sizeDeletions) pop(obj interface{}) bool {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok := s.keys[key]
+	delete(s.keys, key)
+	return ok
+}
+
+// objectSizeFilter skips the objects that exceed the max serialized size.
+type objectSizeFilter struct {
+	name      string
+	maxSize   int
+	mrec      MetricsRecorder
+	logger    log.Logger
+	deletions *sizeDeletions
+}
+
+// oversized returns true if the object exceeds the max size, it will log and measure the skipped object.
+func (f objectSizeFilter) oversized(obj runtime.Object) bool {
+	data, err := json.Marshal(obj)
+	if err != nil || len(data) <= f.maxSize {
+		return false
+	}
+
+	key, _ := cache.MetaNamespaceKeyFunc(obj)
+	f.mrec.IncResourceOversizedSkipped(context.Background(), f.name)
+	f.logger.WithKV(log.KV{"object-key": key, "size": len(data)}).Warningf("object skipped, exceeds the max object size of %d bytes", f.maxSize)
+
+	return true
+}
+
+// wrap returns a ListerWatcher that removes the oversized objects from the lists and watches, so they
+// don't enter the cache nor the queue.
+//
+// When an object grows beyond the max size, the watch update will be converted to a delete so
+// the old version is removed from the cache. These deletions are tracked, so they are not handled
+// as real deletions (the object still exists).
+func (f objectSizeFilter) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+
+			objs, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			filtered := make([]runtime.Object, 0, len(objs))
+			for _, o := range objs {
+				if !f.oversized(o) {
+					filtered = append(filtered, o)
+				}
+			}
+			if len(filtered) == len(objs) {
+				return obj, nil
+			}
+
+			err = meta.SetList(obj, filtered)
+			if err != nil {
+				return nil, err
+			}
+			return obj, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				switch e.Type {
+				case watch.Added:
+					return e, !f.oversized(e.Object)
+				case watch.Modified:
+					if f.oversized(e.Object) {
+						e.Type = watch.Deleted
+						f.deletions.add(e.Object)
+					}
+				}
+				return e, true
+			}), nil
+		},
+	}
+}
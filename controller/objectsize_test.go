@@ -0,0 +1,168 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// oversizedRecorder is a metrics recorder that counts the oversized skipped objects.
+type oversizedRecorder struct {
+	controller.MetricsRecorder
+	skipped int64
+}
+
+func (o *oversizedRecorder) IncResourceOversizedSkipped(context.Context, string) {
+	atomic.AddInt64(&o.skipped, 1)
+}
+
+func newSizedNamespace(name string, size int) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: "1",
+			Annotations:     map[string]string{"data": strings.Repeat("x", size)},
+		},
+	}
+}
+
+func TestGenericControllerMaxObjectSize(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			*newSizedNamespace("small-1", 10),
+			*newSizedNamespace("big-1", 2000),
+			*newSizedNamespace("small-2", 10),
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[obj.(*corev1.Namespace).Name]++
+		return nil
+	})
+	handledNames := func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		res := map[string]int{}
+		for k, v := range handled {
+			res[k] = v
+		}
+		return res
+	}
+
+	mrec := &oversizedRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		Logger:          log.Dummy,
+		MetricsRecorder: mrec,
+		MaxObjectSize:   1000,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The oversized objects of the list should be skipped.
+	assert.Eventually(func() bool {
+		return len(handledNames()) == 2
+	}, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(map[string]int{"small-1": 1, "small-2": 1}, handledNames())
+	assert.Equal(int64(1), atomic.LoadInt64(&mrec.skipped))
+
+	// The oversized objects of the watch should be skipped.
+	w.Add(newSizedNamespace("big-2", 2000))
+	w.Add(newSizedNamespace("small-3", 10))
+	assert.Eventually(func() bool {
+		return handledNames()["small-3"] == 1
+	}, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(map[string]int{"small-1": 1, "small-2": 1, "small-3": 1}, handledNames())
+	assert.Equal(int64(2), atomic.LoadInt64(&mrec.skipped))
+}
+
+func TestGenericControllerMaxObjectSizeWithDeleteHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			*newSizedNamespace("ns-1", 10),
+			*newSizedNamespace("ns-2", 10),
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+	deleted := map[string]int{}
+	record := func(m map[string]int) controller.Handler {
+		return controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			mu.Lock()
+			defer mu.Unlock()
+			m[obj.(*corev1.Namespace).Name]++
+			return nil
+		})
+	}
+	count := func(m map[string]int, name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return m[name]
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       record(handled),
+		DeleteHandler: record(deleted),
+		Retriever:     ret,
+		Logger:        log.Dummy,
+		MaxObjectSize: 1000,
+		DisableResync: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool {
+		return count(handled, "ns-1") == 1 && count(handled, "ns-2") == 1
+	}, 1*time.Second, 5*time.Millisecond)
+
+	// An object that grows beyond the max size still exists, it should not be handled as deleted.
+	big := newSizedNamespace("ns-1", 2000)
+	big.ResourceVersion = "2"
+	w.Modify(big)
+
+	// A real deletion should be handled.
+	w.Delete(newSizedNamespace("ns-2", 10))
+	assert.Eventually(func() bool { return count(deleted, "ns-2") == 1 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(0, count(deleted, "ns-1"))
+
+	// The object should be handled again when it's back under the max size.
+	small := newSizedNamespace("ns-1", 10)
+	small.ResourceVersion = "3"
+	w.Modify(small)
+	assert.Eventually(func() bool { return count(handled, "ns-1") == 2 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(0, count(deleted, "ns-1"))
+}
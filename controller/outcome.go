@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// The outcomes of the processing of an object.
+const (
+	// ProcessingOutcomeFirstTrySuccess is the outcome of an object processed successfully on the first try.
+	ProcessingOutcomeFirstTrySuccess = "first-try-success"
+	// ProcessingOutcomeRetriedSuccess is the outcome of an object processed successfully after retries.
+	ProcessingOutcomeRetriedSuccess = "retried-success"
+	// ProcessingOutcomeGaveUp is the outcome of an object that failed and will not be retried.
+	ProcessingOutcomeGaveUp = "gaveup"
+)
+
+// newOutcomeProcessor returns a processor that classifies and measures the final outcome of the
+// processing of objects. It tracks the attempts of the objects that are being retried (`errRequeued`),
+// so it should decorate the retry processors. The attempts are reset when the object is gone from the
+// cache, the processing is then the processing of the object deletion.
+func newOutcomeProcessor(name string, mrec MetricsRecorder, indexer cache.Indexer, next processor) processor {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, exists, getErr := indexer.GetByKey(key); getErr == nil && !exists {
+			delete(attempts, key)
+		}
+
+		var outcome string
+		switch {
+		case err == nil && attempts[key] == 0:
+			outcome = ProcessingOutcomeFirstTrySuccess
+		case err == nil:
+			outcome = ProcessingOutcomeRetriedSuccess
+		case errors.Is(err, errRequeued):
+			attempts[key]++
+			return err
+		default:
+			outcome = ProcessingOutcomeGaveUp
+		}
+
+		delete(attempts, key)
+		mrec.IncResourceProcessingOutcome(ctx, name, outcome)
+		return err
+	})
+}
@@ -0,0 +1,175 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// outcomeRecorder is a metrics recorder that counts the processing outcomes.
+type outcomeRecorder struct {
+	controller.MetricsRecorder
+	mu       sync.Mutex
+	outcomes map[string]int
+}
+
+func (o *outcomeRecorder) IncResourceProcessingOutcome(_ context.Context, _ string, outcome string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.outcomes[outcome]++
+}
+
+func (o *outcomeRecorder) counts() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	res := map[string]int{}
+	for k, v := range o.outcomes {
+		res[k] = v
+	}
+	return res
+}
+
+func TestGenericControllerProcessingOutcomes(t *testing.T) {
+	tests := map[string]struct {
+		failures    map[string]int // Number of times that will fail the object.
+		retries     int
+		expOutcomes map[string]int
+	}{
+		"Objects processed on the first try should be measured as first try success.": {
+			retries:     2,
+			expOutcomes: map[string]int{controller.ProcessingOutcomeFirstTrySuccess: 3},
+		},
+
+		"Objects processed after retries should be measured as retried success.": {
+			failures: map[string]int{"obj-1": 2},
+			retries:  2,
+			expOutcomes: map[string]int{
+				controller.ProcessingOutcomeFirstTrySuccess: 2,
+				controller.ProcessingOutcomeRetriedSuccess:  1,
+			},
+		},
+
+		"Objects that fail after all the retries should be measured as gave up.": {
+			failures: map[string]int{"obj-1": 999, "obj-2": 1},
+			retries:  2,
+			expOutcomes: map[string]int{
+				controller.ProcessingOutcomeFirstTrySuccess: 1,
+				controller.ProcessingOutcomeRetriedSuccess:  1,
+				controller.ProcessingOutcomeGaveUp:          1,
+			},
+		},
+
+		"Objects that fail without retries should be measured as gave up.": {
+			failures: map[string]int{"obj-1": 1},
+			retries:  0,
+			expOutcomes: map[string]int{
+				controller.ProcessingOutcomeFirstTrySuccess: 2,
+				controller.ProcessingOutcomeGaveUp:          1,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList := &corev1.NamespaceList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}
+			for i := 0; i < 3; i++ {
+				nsList.Items = append(nsList.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("obj-%d", i)}})
+			}
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			var mu sync.Mutex
+			failures := map[string]int{}
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				name := obj.(*corev1.Namespace).Name
+				if failures[name] < test.failures[name] {
+					failures[name]++
+					return fmt.Errorf("wanted error")
+				}
+				return nil
+			})
+
+			mrec := &outcomeRecorder{MetricsRecorder: controller.DummyMetricsRecorder, outcomes: map[string]int{}}
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            newNamespaceRetriever(mc),
+				Logger:               log.Dummy,
+				MetricsRecorder:      mrec,
+				ProcessingJobRetries: test.retries,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool { return reflect.DeepEqual(test.expOutcomes, mrec.counts()) }, 1*time.Second, 5*time.Millisecond)
+			assert.Equal(test.expOutcomes, mrec.counts())
+		})
+	}
+}
+
+func TestGenericControllerProcessingOutcomesDeletedWhileRetrying(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, nss := createNamespaceList("obj", 3)
+	ret, w := newFakeNamespaceRetriever(nsList)
+
+	// The first object fails once and it's retried after a while.
+	var mu sync.Mutex
+	failed := false
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if obj.(*corev1.Namespace).Name == nss[0].Name && !failed {
+			failed = true
+			return fmt.Errorf("wanted error")
+		}
+		return nil
+	})
+
+	mrec := &outcomeRecorder{MetricsRecorder: controller.DummyMetricsRecorder, outcomes: map[string]int{}}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		Logger:          log.Dummy,
+		MetricsRecorder: mrec,
+		RetryPolicy: func(runtime.Object, error, int) controller.RetryAction {
+			return controller.RetryAction{RequeueAfter: 200 * time.Millisecond}
+		},
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	expOutcomes := map[string]int{controller.ProcessingOutcomeFirstTrySuccess: 2}
+	require.Eventually(func() bool { return reflect.DeepEqual(expOutcomes, mrec.counts()) }, 1*time.Second, 5*time.Millisecond)
+
+	// Delete the object while is waiting to be retried, the attempts of the deleted object
+	// should not be used on the processing of its deletion nor the pending retry.
+	w.Delete(nss[0])
+	expOutcomes = map[string]int{controller.ProcessingOutcomeFirstTrySuccess: 4}
+	assert.Eventually(func() bool { return reflect.DeepEqual(expOutcomes, mrec.counts()) }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(expOutcomes, mrec.counts())
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// OwnedResource is a secondary resource owned by the resources of the controller (check `Config.Owns`).
+type OwnedResource struct {
+	retriever    Retriever
+	ownerKeyFunc OwnerKeyFunc
+}
+
+// OwnerKeyFunc returns the keys of the controller objects that own an owned object, these objects will
+// be enqueued when the owned object changes.
+type OwnerKeyFunc func(obj runtime.Object) []string
+
+// OwnsKind returns an owned resource from the retriever of a secondary resource (e.g the pods of a
+// replicaset controller). The changes on the owned objects will enqueue their owners on the controller.
+func OwnsKind(r Retriever) OwnedResource {
+	return OwnedResource{retriever: r}
+}
+
+// WithOwnerKeyFunc returns the owned resource mapping its objects to their owners with the owner key func
+// instead of the owner references (e.g the owners referenced by a label or an annotation).
+func (o OwnedResource) WithOwnerKeyFunc(f OwnerKeyFunc) OwnedResource {
+	o.ownerKeyFunc = f
+	return o
+}
+
+// ownedSourcesCtxKey is the context key of the owned change sources.
+type ownedSourcesCtxKey struct{}
+
+// OwnedChangeSources returns the types (e.g `*v1.Deployment`) of the owned objects (check `Config.Owns`)
+// whose changes enqueued the handled object since its last processing. Returns false if the handling
+// was not caused by the owned objects (e.g the handled object itself changed).
+func OwnedChangeSources(ctx context.Context) ([]string, bool) {
+	sources, ok := ctx.Value(ownedSourcesCtxKey{}).([]string)
+	return sources, ok
+}
+
+// ownedInformers watch the owned resources and enqueue the owners of the changed objects. Only
+// the owner references that point to an object on the controller cache (same UID) are enqueued.
+type ownedInformers struct {
+	informers []cache.SharedIndexInformer
+	indexer   cache.Indexer
+	keyFunc   cache.KeyFunc
+	queue     blockingQueue
+	logger    log.Logger
+
+	mu      sync.Mutex
+	sources map[string]map[string]bool
+}
+
+func newOwnedInformers(owned []OwnedResource, indexer cache.Indexer, keyFunc cache.KeyFunc, queue blockingQueue, logger log.Logger) *ownedInformers {
+	o := &ownedInformers{
+		indexer: indexer,
+		keyFunc: keyFunc,
+		queue:   queue,
+		logger:  logger,
+		sources: map[string]map[string]bool{},
+	}
+
+	for _, res := range owned {
+		res := res
+		informer := cache.NewSharedIndexInformer(listerWatcherFromRetriever(res.retriever), nil, 0, cache.Indexers{})
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { o.enqueueOwners(res.ownerKeyFunc, obj) },
+			UpdateFunc: func(old, new interface{}) {
+				// The owners could have changed.
+				o.enqueueOwners(res.ownerKeyFunc, old, new)
+			},
+			DeleteFunc: func(obj interface{}) { o.enqueueOwners(res.ownerKeyFunc, obj) },
+		})
+		o.informers = append(o.informers, informer)
+	}
+
+	return o
+}
+
+// enqueueOwners enqueues once the owners of the objects, by default the owners are obtained from the
+// owner references.
+func (o *ownedInformers) enqueueOwners(ownerKeyFunc OwnerKeyFunc, objs ...interface{}) {
+	keys := map[string]bool{}
+	for _, obj := range objs {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		var ownerKeys []string
+		if ownerKeyFunc != nil {
+			rtobj, ok := obj.(runtime.Object)
+			if !ok {
+				continue
+			}
+			ownerKeys = ownerKeyFunc(rtobj)
+		} else {
+			ownerKeys = o.ownerKeys(obj)
+		}
+
+		for _, k := range ownerKeys {
+			if !keys[k] {
+				keys[k] = true
+				o.addSource(k, obj)
+				o.queue.Add(context.TODO(), k)
+			}
+		}
+	}
+}
+
+func (o *ownedInformers) addSource(key string, obj interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.sources[key] == nil {
+		o.sources[key] = map[string]bool{}
+	}
+	o.sources[key][fmt.Sprintf("%T", obj)] = true
+}
+
+// popSources returns the sorted owned change sources of a key and forgets them.
+func (o *ownedInformers) popSources(key string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	sources := []string{}
+	for s := range o.sources[key] {
+		sources = append(sources, s)
+	}
+	delete(o.sources, key)
+	sort.Strings(sources)
+	return sources
+}
+
+// processor returns a processor that passes the owned change sources of the processed key on the context.
+func (o *ownedInformers) processor(next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		if sources := o.popSources(key); len(sources) > 0 {
+			ctx = context.WithValue(ctx, ownedSourcesCtxKey{}, sources)
+		}
+		return next.Process(ctx, key)
+	})
+}
+
+// ownerKeys returns the keys of the owners of the object that are on the controller cache.
+func (o *ownedInformers) ownerKeys(obj interface{}) []string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		o.logger.Warningf("could not get owned object meta: %s", err)
+		return nil
+	}
+
+	ownerKeys := []string{}
+	for _, ref := range m.GetOwnerReferences() {
+		// The owner is on the same namespace or is cluster scoped.
+		keys := []string{ref.Name}
+		if m.GetNamespace() != "" {
+			keys = append([]string{m.GetNamespace() + "/" + ref.Name}, keys...)
+		}
+
+		for _, key := range keys {
+			owner, exists, err := o.indexer.GetByKey(key)
+			if err != nil || !exists {
+				continue
+			}
+			om, err := meta.Accessor(owner)
+			if err != nil || om.GetUID() != ref.UID {
+				continue
+			}
+			ownerKey, err := o.keyFunc(owner)
+			if err != nil {
+				continue
+			}
+			ownerKeys = append(ownerKeys, ownerKey)
+			break
+		}
+	}
+
+	return ownerKeys
+}
+
+func (o *ownedInformers) run(ctx context.Context) {
+	for _, i := range o.informers {
+		go i.Run(ctx.Done())
+	}
+}
+
+func (o *ownedInformers) hasSynced() bool {
+	for _, i := range o.informers {
+		if !i.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
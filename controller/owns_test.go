@@ -0,0 +1,191 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerOwnsKind(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Primary resource.
+	rsl := &appsv1.ReplicaSetList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []appsv1.ReplicaSet{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rs-1", Namespace: "test", UID: "rs-1-uid", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "rs-2", Namespace: "test", UID: "rs-2-uid", ResourceVersion: "1"}},
+		},
+	}
+	rsRet := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return rsl, nil },
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+	})
+
+	// Owned resource.
+	podW := watch.NewFake()
+	podRet := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}, nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return podW, nil },
+	})
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*appsv1.ReplicaSet).Name)
+		return nil
+	})
+	handledNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: rsRet,
+		Owns:      []controller.OwnedResource{controller.OwnsKind(podRet)},
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(handledNames()) == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	pod := func(name string, rv string, ownerName string, ownerUID string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "test",
+			ResourceVersion: rv,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: ownerName, UID: types.UID(ownerUID)}},
+		}}
+	}
+
+	// A pod not owned by the cached replicasets (different UID) should not enqueue anything.
+	podW.Add(pod("pod-0", "2", "rs-1", "other-uid"))
+	// The pod changes should enqueue their owner.
+	podW.Add(pod("pod-1", "3", "rs-2", "rs-2-uid"))
+	assert.Eventually(func() bool { return len(handledNames()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	podW.Modify(pod("pod-1", "4", "rs-2", "rs-2-uid"))
+	assert.Eventually(func() bool { return len(handledNames()) == 4 }, 1*time.Second, 5*time.Millisecond)
+	podW.Delete(pod("pod-1", "5", "rs-2", "rs-2-uid"))
+	assert.Eventually(func() bool { return len(handledNames()) == 5 }, 1*time.Second, 5*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal([]string{"rs-2", "rs-2", "rs-2"}, handledNames()[2:])
+}
+
+func TestGenericControllerOwnsKindWithOwnerKeyFunc(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Primary resource.
+	rsl := &appsv1.ReplicaSetList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []appsv1.ReplicaSet{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rs-1", Namespace: "test", ResourceVersion: "1"}},
+		},
+	}
+	rsRet := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return rsl, nil },
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+	})
+
+	// Owned resources.
+	cmW, svcW := watch.NewFake(), watch.NewFake()
+	cmRet := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.ConfigMapList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}, nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return cmW, nil },
+	})
+	svcRet := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.ServiceList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}, nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return svcW, nil },
+	})
+
+	// The owners are referenced with a label.
+	ownerLabel := func(obj runtime.Object) []string {
+		owner := obj.(metav1.Object).GetLabels()["owner"]
+		if owner == "" {
+			return nil
+		}
+		return []string{owner}
+	}
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		sources, ok := controller.OwnedChangeSources(ctx)
+		if !ok {
+			sources = []string{"self"}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*appsv1.ReplicaSet).Name+" "+strings.Join(sources, ","))
+		return nil
+	})
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: rsRet,
+		Owns: []controller.OwnedResource{
+			controller.OwnsKind(cmRet).WithOwnerKeyFunc(ownerLabel),
+			controller.OwnsKind(svcRet).WithOwnerKeyFunc(ownerLabel),
+		},
+		Logger: log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	meta := func(name, rv, owner string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "test", ResourceVersion: rv, Labels: map[string]string{"owner": owner}}
+	}
+	cmW.Add(&corev1.ConfigMap{ObjectMeta: meta("cm-1", "2", "test/rs-1")})
+	assert.Eventually(func() bool { return len(getHandled()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	svcW.Add(&corev1.Service{ObjectMeta: meta("svc-1", "3", "test/rs-1")})
+	assert.Eventually(func() bool { return len(getHandled()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	// Objects without owner should not enqueue anything.
+	svcW.Add(&corev1.Service{ObjectMeta: meta("svc-2", "4", "")})
+
+	time.Sleep(20 * time.Millisecond)
+	exp := []string{
+		"rs-1 self",
+		"rs-1 *v1.ConfigMap",
+		"rs-1 *v1.Service",
+	}
+	assert.Equal(exp, getHandled())
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// panicQuarantine counts the consecutive panics of every object and quarantines (excludes) the
+// objects that reach the threshold, so an object that always panics is not retried in a loop.
+type panicQuarantine struct {
+	threshold int
+	excluded  *exclusionSet
+	mu        sync.Mutex
+	panics    map[string]int
+}
+
+func newPanicQuarantine(threshold int, excluded *exclusionSet) *panicQuarantine {
+	return &panicQuarantine{
+		threshold: threshold,
+		excluded:  excluded,
+		panics:    map[string]int{},
+	}
+}
+
+// panicked counts a panic of the object and returns true if the object has been quarantined.
+func (p *panicQuarantine) panicked(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.panics[key]++
+	if p.panics[key] < p.threshold {
+		return false
+	}
+
+	delete(p.panics, key)
+	p.excluded.add(key)
+	return true
+}
+
+// handled resets the consecutive panics of the object.
+func (p *panicQuarantine) handled(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.panics, key)
+}
+
+// newPanicRecoveryHandler returns a handler that recovers the panics of the handling and returns
+// them as handling errors, so the object is retried like any other failed handling and the worker
+// keeps processing the next objects. If the quarantine is used, the objects that keep panicking
+// will be quarantined.
+func newPanicRecoveryHandler(panicHandler func(ctx context.Context, obj runtime.Object, r interface{}), quarantine *panicQuarantine, logger log.Logger, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (res Result, err error) {
+		key := ""
+		if quarantine != nil {
+			key, _ = cache.MetaNamespaceKeyFunc(obj)
+		}
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				if key != "" {
+					quarantine.handled(key)
+				}
+				return
+			}
+
+			logger.Errorf("handler panic recovered: %v\n%s", r, debug.Stack())
+			if panicHandler != nil {
+				panicHandler(ctx, obj, r)
+			}
+			if key != "" && quarantine.panicked(key) {
+				logger.WithKV(log.KV{"object-key": key}).
+					Warningf("object quarantined after %d consecutive panics, include it again to resume its processing", quarantine.threshold)
+			}
+			res, err = Result{}, fmt.Errorf("handler panicked: %v", r)
+		}()
+
+		return handleWithResult(ctx, next, obj)
+	})
+}
@@ -0,0 +1,129 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerPanicRecovery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 10)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// The first handling of an object panics, the next ones succeed.
+	var mu sync.Mutex
+	handled := map[string]int{}
+	panics := []interface{}{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		name := obj.(*corev1.Namespace).Name
+		mu.Lock()
+		handled[name]++
+		n := handled[name]
+		mu.Unlock()
+		if name == "testing-3" && n == 1 {
+			panic("wanted panic")
+		}
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            newNamespaceRetriever(mc),
+		Logger:               log.Dummy,
+		ConcurrentWorkers:    1,
+		ProcessingJobRetries: 2,
+		PanicHandler: func(_ context.Context, _ runtime.Object, r interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			panics = append(panics, r)
+		},
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The panic should be a processing error that is retried, and the rest of the objects processed.
+	exp := controller.Stats{Processed: 11, Errored: 1, Requeued: 1}
+	assert.Eventually(func() bool {
+		s := c.Stats()
+		s.ResyncInterval = 0
+		return s == exp
+	}, 1*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(handled, 10)
+	assert.Equal(2, handled["testing-3"])
+	assert.Equal([]interface{}{"wanted panic"}, panics)
+}
+
+func TestGenericControllerPanicQuarantine(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 5)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// An object that always panics.
+	var mu sync.Mutex
+	handled := map[string]int{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		name := obj.(*corev1.Namespace).Name
+		mu.Lock()
+		handled[name]++
+		mu.Unlock()
+		if name == "testing-3" {
+			panic("wanted panic")
+		}
+		return nil
+	})
+	getHandled := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled[name]
+	}
+
+	logger := &warningLogger{Logger: log.Dummy}
+	c, err := controller.New(&controller.Config{
+		Name:                     "test",
+		Handler:                  h,
+		Retriever:                newNamespaceRetriever(mc),
+		Logger:                   logger,
+		ProcessingJobRetries:     10,
+		PanicQuarantineThreshold: 3,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The object should be quarantined after the threshold, even having retries left.
+	assert.Eventually(func() bool { return getHandled("testing-3") == 3 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(3, getHandled("testing-3"))
+	assert.Equal(1, getHandled("testing-1"))
+	logger.mu.Lock()
+	assert.Contains(logger.warnings, "object quarantined after 3 consecutive panics, include it again to resume its processing")
+	logger.mu.Unlock()
+
+	// Including the object again should resume its processing.
+	c.Include("testing-3")
+	assert.Eventually(func() bool { return getHandled("testing-3") > 3 }, 1*time.Second, 5*time.Millisecond)
+}
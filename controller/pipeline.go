@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// PipelineStage is a single stage of a Pipeline.
+type PipelineStage struct {
+	// Name is the name of the stage.
+	Name string
+	// Handler is the handler that will handle the objects that reach the stage.
+	Handler Handler
+	// BufferSize is the number of objects that can be waiting to be handled by the stage,
+	// when the buffer is full the previous stage will block until there is room (backpressure).
+	// Ignored on the first stage. By default 1.
+	BufferSize int
+	// MaxRetries is the number of times a failed object will be handled again by the stage
+	// before dropping it. Ignored on the first stage, the controller retries it. By default 0.
+	MaxRetries int
+}
+
+// PipelineConfig is the pipeline configuration.
+type PipelineConfig struct {
+	// Stages are the stages of the pipeline in order of execution, at least one is required.
+	Stages []PipelineStage
+	// Logger will log messages of the pipeline.
+	Logger log.Logger
+}
+
+func (c *PipelineConfig) setDefaults() error {
+	if len(c.Stages) == 0 {
+		return fmt.Errorf("at least one stage is required")
+	}
+
+	for i, s := range c.Stages {
+		if s.Name == "" {
+			return fmt.Errorf("stage %d name is required", i)
+		}
+
+		if s.Handler == nil {
+			return fmt.Errorf("stage %q handler is required", s.Name)
+		}
+
+		if s.MaxRetries < 0 {
+			return fmt.Errorf("stage %q max retries can't be negative", s.Name)
+		}
+
+		if s.BufferSize <= 0 {
+			c.Stages[i].BufferSize = 1
+		}
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.NewStd(false)
+		c.Logger.Warningf("no logger specified, fallback to default logger, to disable logging use a explicit Noop logger")
+	}
+	c.Logger = c.Logger.WithKV(log.KV{
+		"service": "kooper.pipeline",
+	})
+
+	return nil
+}
+
+// Pipeline chains multiple handlers so the objects successfully handled by one stage
+// are handled afterwards by the next one, in the same order they finished the previous stage.
+//
+// The pipeline is a Handler that will execute the first stage, it should be used as
+// the handler of the controller that starts the workflow. The rest of the stages
+// are executed in the background while the pipeline is running (`Run`). The pipeline
+// is not a Controller, it needs to be run alongside the controller that uses it.
+//
+// The errors of the first stage are returned so the controller retries the object. The
+// rest of the stages retry the failed objects in place (`PipelineStage.MaxRetries`) and
+// after that the object is logged and dropped. The objects waiting on the stage buffers
+// are also dropped when the pipeline stops. So, the later stages handle the objects at
+// most once, the controller resyncs should be used to recover the dropped objects.
+type Pipeline struct {
+	cfg    PipelineConfig
+	queues []chan runtime.Object
+	logger log.Logger
+}
+
+// NewPipeline returns a new pipeline.
+func NewPipeline(cfg PipelineConfig) (*Pipeline, error) {
+	err := cfg.setDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("could no create pipeline: %w", err)
+	}
+
+	// Every stage except the first one has its own bounded queue.
+	queues := make([]chan runtime.Object, len(cfg.Stages))
+	for i := 1; i < len(cfg.Stages); i++ {
+		queues[i] = make(chan runtime.Object, cfg.Stages[i].BufferSize)
+	}
+
+	return &Pipeline{
+		cfg:    cfg,
+		queues: queues,
+		logger: cfg.Logger,
+	}, nil
+}
+
+// Handle satisfies controller.Handler interface. It handles the object with the first stage and
+// sends it to the next stage, blocking while the next stage is full.
+func (p *Pipeline) Handle(ctx context.Context, obj runtime.Object) error {
+	return p.handleStage(ctx, 0, obj)
+}
+
+// Run runs the pipeline stages (except the first one) and blocks until the context is `Done`.
+func (p *Pipeline) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 1; i < len(p.cfg.Stages); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.runStage(ctx, i)
+		}(i)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (p *Pipeline) runStage(ctx context.Context, stage int) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case obj := <-p.queues[stage]:
+			// Retry in place so the objects keep the order.
+			err := p.handleStage(ctx, stage, obj)
+			for i := 0; err != nil && i < p.cfg.Stages[stage].MaxRetries && ctx.Err() == nil; i++ {
+				err = p.handleStage(ctx, stage, obj)
+			}
+			if err != nil && ctx.Err() == nil {
+				p.logger.WithKV(log.KV{"stage": p.cfg.Stages[stage].Name}).Errorf("error on object processing, object dropped: %v", err)
+			}
+		}
+	}
+}
+
+// handleStage handles the object with the stage handler and if everything went ok it will
+// send the object to the next stage.
+func (p *Pipeline) handleStage(ctx context.Context, stage int, obj runtime.Object) error {
+	err := p.cfg.Stages[stage].Handler.Handle(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	next := stage + 1
+	if next >= len(p.cfg.Stages) {
+		return nil
+	}
+
+	// The object could be shared with a cache, the next stage runs concurrently so it receives a copy.
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("could not send object to %q stage: %w", p.cfg.Stages[next].Name, ctx.Err())
+	case p.queues[next] <- obj.DeepCopyObject():
+	}
+
+	return nil
+}
+
+var _ Handler = &Pipeline{}
@@ -0,0 +1,241 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestPipelineOrder(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 10)
+
+	tests := map[string]struct {
+		nsList *corev1.NamespaceList
+	}{
+		"Every object handled by the first stage should be handled afterwards by the next stage in the same order.": {
+			nsList: nsList,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			calls := []string{}
+			stageHandler := func(stage string, done func()) controller.Handler {
+				return controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+					mu.Lock()
+					defer mu.Unlock()
+					calls = append(calls, stage+"/"+obj.(*corev1.Namespace).Name)
+					if done != nil {
+						done()
+					}
+					return nil
+				})
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(len(test.nsList.Items))
+			p, err := controller.NewPipeline(controller.PipelineConfig{
+				Stages: []controller.PipelineStage{
+					{Name: "a", Handler: stageHandler("a", nil)},
+					{Name: "b", Handler: stageHandler("b", wg.Done)},
+				},
+				Logger: log.Dummy,
+			})
+			require.NoError(err)
+
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, test.nsList)
+			c, err := controller.New(&controller.Config{
+				Name:              "test",
+				Handler:           p,
+				Retriever:         newNamespaceRetriever(mc),
+				ConcurrentWorkers: 1,
+				Logger:            log.Dummy,
+			})
+			require.NoError(err)
+
+			go func() { _ = p.Run(ctx) }()
+			go func() { _ = c.Run(ctx) }()
+
+			doneC := make(chan struct{})
+			go func() { wg.Wait(); close(doneC) }()
+			select {
+			case <-doneC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for pipeline handling")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			gotA := []string{}
+			gotB := []string{}
+			for _, c := range calls {
+				switch c[0] {
+				case 'a':
+					gotA = append(gotA, c[2:])
+				case 'b':
+					gotB = append(gotB, c[2:])
+					// B can't handle an object before A.
+					assert.Contains(gotA, c[2:])
+				}
+			}
+			assert.Len(gotA, len(test.nsList.Items))
+			assert.Equal(gotA, gotB)
+		})
+	}
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The last stage will be blocked until we release it.
+	releaseC := make(chan struct{})
+	p, err := controller.NewPipeline(controller.PipelineConfig{
+		Stages: []controller.PipelineStage{
+			{Name: "a", Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })},
+			{Name: "b", BufferSize: 1, Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error {
+				<-releaseC
+				return nil
+			})},
+		},
+		Logger: log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = p.Run(ctx) }()
+
+	obj := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+
+	// First object is being handled by b and second one is on the buffer.
+	require.NoError(p.Handle(ctx, obj))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(p.Handle(ctx, obj))
+
+	// Third object should block until b has room.
+	handledC := make(chan error)
+	go func() { handledC <- p.Handle(ctx, obj) }()
+	select {
+	case <-handledC:
+		assert.Fail("first stage should be blocked by the next stage")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseC)
+	select {
+	case err := <-handledC:
+		assert.NoError(err)
+	case <-time.After(1 * time.Second):
+		assert.Fail("first stage should be unblocked after next stage has room")
+	}
+}
+
+func TestPipelineLaterStageError(t *testing.T) {
+	tests := map[string]struct {
+		maxRetries int
+		failures   int
+		expNext    []string
+	}{
+		"A failed object on a later stage without retries should be dropped and not reach the next stage.": {
+			maxRetries: 0,
+			failures:   1,
+			expNext:    []string{"ns-1"},
+		},
+		"A failed object on a later stage should be retried in place and keep the order.": {
+			maxRetries: 2,
+			failures:   2,
+			expNext:    []string{"ns-0", "ns-1"},
+		},
+		"A failed object on a later stage should be dropped after the retries.": {
+			maxRetries: 2,
+			failures:   3,
+			expNext:    []string{"ns-1"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The second stage fails the first object `failures` times.
+			var mu sync.Mutex
+			failures := 0
+			got := []string{}
+			doneC := make(chan struct{})
+			p, err := controller.NewPipeline(controller.PipelineConfig{
+				Stages: []controller.PipelineStage{
+					{Name: "a", Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })},
+					{Name: "b", MaxRetries: test.maxRetries, Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+						mu.Lock()
+						defer mu.Unlock()
+						if obj.(*corev1.Namespace).Name == "ns-0" && failures < test.failures {
+							failures++
+							return fmt.Errorf("wanted error")
+						}
+						return nil
+					})},
+					{Name: "c", Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, obj.(*corev1.Namespace).Name)
+						if obj.(*corev1.Namespace).Name == "ns-1" {
+							close(doneC)
+						}
+						return nil
+					})},
+				},
+				Logger: log.Dummy,
+			})
+			require.NoError(err)
+			go func() { _ = p.Run(ctx) }()
+
+			// The first stage doesn't know about the later stages errors.
+			require.NoError(p.Handle(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-0"}}))
+			require.NoError(p.Handle(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}}))
+
+			select {
+			case <-doneC:
+			case <-time.After(1 * time.Second):
+				require.Fail("timeout waiting for pipeline handling")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expNext, got)
+		})
+	}
+}
+
+func TestPipelineInvalidMaxRetries(t *testing.T) {
+	_, err := controller.NewPipeline(controller.PipelineConfig{
+		Stages: []controller.PipelineStage{
+			{Name: "a", Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })},
+			{Name: "b", MaxRetries: -1, Handler: controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })},
+		},
+		Logger: log.Dummy,
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package controller_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerPprofLabels(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	labelC := make(chan string, 1)
+	h := controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+		label, _ := pprof.Label(ctx, "controller")
+		labelC <- label
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test-pprof",
+		Handler:   h,
+		Retriever: newNamespaceRetriever(mc),
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case label := <-labelC:
+		assert.Equal("test-pprof", label)
+	case <-time.After(1 * time.Second):
+		require.Fail("timeout waiting for handling")
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// GenerationChangedPredicate is an update predicate (check `Config.UpdatePredicate`) that only enqueues
+// the updates that change the object generation, so the updates that only change the status or
+// the metadata (e.g labels, annotations) are ignored. The objects without generation are always enqueued.
+func GenerationChangedPredicate(oldObj, newObj runtime.Object) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return true
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return true
+	}
+
+	if newMeta.GetGeneration() == 0 {
+		return true
+	}
+
+	return oldMeta.GetGeneration() != newMeta.GetGeneration()
+}
+
+// isResync returns true if the update is a resync of the same object version.
+func isResync(oldObj, newObj interface{}) bool {
+	oldRT, ok := oldObj.(runtime.Object)
+	if !ok {
+		return false
+	}
+	newRT, ok := newObj.(runtime.Object)
+	if !ok {
+		return false
+	}
+
+	v := objectVersion(newRT)
+	return v != "" && objectVersion(oldRT) == v
+}
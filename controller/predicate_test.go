@@ -0,0 +1,103 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenerationChangedPredicate(t *testing.T) {
+	newPod := func(generation int64) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: generation}}
+	}
+
+	tests := map[string]struct {
+		oldObj runtime.Object
+		newObj runtime.Object
+		exp    bool
+	}{
+		"An update that changes the generation should be enqueued.": {
+			oldObj: newPod(1),
+			newObj: newPod(2),
+			exp:    true,
+		},
+
+		"An update that doesn't change the generation should not be enqueued.": {
+			oldObj: newPod(2),
+			newObj: newPod(2),
+			exp:    false,
+		},
+
+		"An update of an object without generation should be enqueued.": {
+			oldObj: newPod(0),
+			newObj: newPod(0),
+			exp:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.exp, controller.GenerationChangedPredicate(test.oldObj, test.newObj))
+		})
+	}
+}
+
+func TestGenericControllerUpdatePredicate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newNS := func(rv string, generation int64) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: rv, Generation: generation}}
+	}
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{*newNS("1", 1)},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	var mu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).ResourceVersion)
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		Logger:          log.Dummy,
+		DisableResync:   true,
+		UpdatePredicate: controller.GenerationChangedPredicate,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	// Only the updates that change the generation should be handled.
+	w.Modify(newNS("2", 1))
+	w.Modify(newNS("3", 2))
+	assert.Eventually(func() bool { return len(getHandled()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	w.Modify(newNS("4", 2))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal([]string{"1", "3"}, getHandled())
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// priorityQueue is a rate limiting workqueue that can move the queued items to the front of the queue.
+// It has the same semantics as the client-go workqueue: an item is never processed concurrently and
+// the items added while they are being processed will be queued again when they are done.
+type priorityQueue struct {
+	rl workqueue.RateLimiter
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	queue        []interface{}
+	dirty        map[interface{}]bool
+	processing   map[interface{}]bool
+	prioritized  map[interface{}]bool
+	shuttingDown bool
+}
+
+func newPriorityQueue(rl workqueue.RateLimiter) *priorityQueue {
+	q := &priorityQueue{
+		rl:          rl,
+		dirty:       map[interface{}]bool{},
+		processing:  map[interface{}]bool{},
+		prioritized: map[interface{}]bool{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *priorityQueue) Add(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shuttingDown || q.dirty[item] {
+		return
+	}
+
+	q.dirty[item] = true
+	if q.processing[item] {
+		return
+	}
+	q.queue = append(q.queue, item)
+	q.cond.Signal()
+}
+
+// promote moves a queued item to the front of the queue, if the item is being processed it
+// will be queued on the front when it's done.
+func (q *priorityQueue) promote(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.processing[item] {
+		q.prioritized[item] = true
+		return
+	}
+
+	for i, it := range q.queue {
+		if it == item {
+			q.queue = append(q.queue[:i], q.queue[i+1:]...)
+			q.queue = append([]interface{}{item}, q.queue...)
+			return
+		}
+	}
+}
+
+func (q *priorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+func (q *priorityQueue) Get() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		return nil, true
+	}
+
+	item := q.queue[0]
+	q.queue = q.queue[1:]
+	q.processing[item] = true
+	delete(q.dirty, item)
+
+	return item, false
+}
+
+func (q *priorityQueue) Done(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, item)
+	prioritized := q.prioritized[item]
+	delete(q.prioritized, item)
+	if !q.dirty[item] {
+		return
+	}
+
+	if prioritized {
+		q.queue = append([]interface{}{item}, q.queue...)
+	} else {
+		q.queue = append(q.queue, item)
+	}
+	q.cond.Signal()
+}
+
+func (q *priorityQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *priorityQueue) ShuttingDown() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shuttingDown
+}
+
+func (q *priorityQueue) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+func (q *priorityQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rl.When(item))
+}
+
+func (q *priorityQueue) Forget(item interface{}) {
+	q.rl.Forget(item)
+}
+
+func (q *priorityQueue) NumRequeues(item interface{}) int {
+	return q.rl.NumRequeues(item)
+}
+
+// Prioritize enqueues the object key on the front of the queue so it's processed as soon as possible,
+// ahead of the already queued objects. Returns an error if `Config.PriorityQueue` is not enabled.
+func (g *Generic) Prioritize(key string) error {
+	if g.priorityQueue == nil {
+		return fmt.Errorf("priority queue is not enabled")
+	}
+
+	g.queue.Add(context.Background(), key)
+	g.priorityQueue.promote(key)
+
+	return nil
+}
+
+var _ workqueue.RateLimitingInterface = &priorityQueue{}
@@ -0,0 +1,81 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerPrioritize(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 50)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// Block the only worker on the first object so the rest of the objects wait on the queue.
+	var mu sync.Mutex
+	handled := []string{}
+	blockC := make(chan struct{})
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		first := len(handled) == 0
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		mu.Unlock()
+		if first {
+			<-blockC
+		}
+		return nil
+	})
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         newNamespaceRetriever(mc),
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 1,
+		PriorityQueue:     true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return c.Stats().QueueLength == 49 }, 1*time.Second, 5*time.Millisecond)
+	require.NoError(c.Prioritize("testing-40"))
+	require.NoError(c.Prioritize("testing-45"))
+	close(blockC)
+
+	// The prioritized objects should be handled before the previously queued ones.
+	assert.Eventually(func() bool { return len(getHandled()) == 50 }, 1*time.Second, 5*time.Millisecond)
+	got := getHandled()
+	assert.Equal([]string{"testing-45", "testing-40"}, got[1:3])
+}
+
+func TestGenericControllerPrioritizeWithoutPriorityQueue(t *testing.T) {
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever: newNamespaceRetriever(&fake.Clientset{}),
+		Logger:    log.Dummy,
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, c.Prioritize("testing-1"))
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -24,7 +25,9 @@ func (p processorFunc) Process(ctx context.Context, key string) error { return p
 // newIndexerProcessor returns a processor that processes a key that will get the kubernetes object
 // from a cache called indexer were the kubernetes watch updates have been indexed and stored
 // by the listerwatchers from the informers.
-func newIndexerProcessor(indexer cache.Indexer, handler Handler) processor {
+//
+// If the handling succeeds, the result of the handling will be used to requeue the object.
+func newIndexerProcessor(indexer cache.Indexer, handler Handler, requeuer *resultRequeuer) processor {
 	return processorFunc(func(ctx context.Context, key string) error {
 		// Get the object
 		obj, exists, err := indexer.GetByKey(key)
@@ -33,10 +36,18 @@ func newIndexerProcessor(indexer cache.Indexer, handler Handler) processor {
 		}
 
 		if !exists {
+			requeuer.forget(key)
 			return nil
 		}
 
-		return handler.Handle(ctx, obj.(runtime.Object))
+		rtobj := obj.(runtime.Object)
+		res, err := handleWithResult(ctx, handler, rtobj)
+		if err != nil {
+			return err
+		}
+
+		requeuer.requeue(ctx, key, rtobj, res)
+		return nil
 	})
 }
 
@@ -50,14 +61,18 @@ var errRequeued = fmt.Errorf("requeued after receiving error")
 func newRetryProcessor(name string, queue blockingQueue, logger log.Logger, next processor) processor {
 	return processorFunc(func(ctx context.Context, key string) error {
 		err := next.Process(ctx, key)
+		if errors.Is(err, errRequeued) {
+			// Already requeued.
+			return err
+		}
+
 		if err != nil {
 			// Retry if possible.
 			requeueErr := queue.Requeue(ctx, key)
 			if requeueErr != nil {
 				return fmt.Errorf("could not retry: %s: %w", requeueErr, err)
 			}
-			logger.WithKV(log.KV{"object-key": key}).Warningf("item requeued due to processing error: %s", err)
-			return nil
+			return fmt.Errorf("%w: %s", errRequeued, err)
 		}
 
 		return nil
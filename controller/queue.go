@@ -16,6 +16,9 @@ import (
 type blockingQueue interface {
 	// Add will add an item to the queue.
 	Add(ctx context.Context, item interface{})
+	// AddAfter will add an item to the queue after the duration, requeue marks the addition
+	// as a requeue of the item (e.g a retry), only for the metrics.
+	AddAfter(ctx context.Context, item interface{}, duration time.Duration, requeue bool)
 	// Requeue will add an item to the queue in a requeue mode.
 	// If doesn't accept requeueing or max requeue have been reached
 	// it will return an error.
@@ -35,6 +38,12 @@ var (
 	errMaxRetriesReached = fmt.Errorf("max retries reached")
 )
 
+// DefaultRetryRateLimiter returns the rate limiter used by default to compute the delay of the processing retries,
+// a per object exponential backoff (5ms base and 1000s max) combined with an overall bucket limiter.
+func DefaultRetryRateLimiter() workqueue.RateLimiter {
+	return workqueue.DefaultControllerRateLimiter()
+}
+
 type rateLimitingBlockingQueue struct {
 	maxRetries int
 	queue      workqueue.RateLimitingInterface
@@ -51,6 +60,10 @@ func (r rateLimitingBlockingQueue) Add(_ context.Context, item interface{}) {
 	r.queue.Add(item)
 }
 
+func (r rateLimitingBlockingQueue) AddAfter(_ context.Context, item interface{}, duration time.Duration, _ bool) {
+	r.queue.AddAfter(item, duration)
+}
+
 func (r rateLimitingBlockingQueue) Requeue(_ context.Context, item interface{}) error {
 	// If there was an error and we have retries pending then requeue.
 	if r.queue.NumRequeues(item) < r.maxRetries {
@@ -105,22 +118,22 @@ func newMetricsBlockingQueue(name string, mrec MetricsRecorder, queue blockingQu
 }
 
 func (m *metricsBlockingQueue) Add(ctx context.Context, item interface{}) {
-	m.mu.Lock()
-	if _, ok := m.itemsQueuedAt[item]; !ok {
-		m.itemsQueuedAt[item] = time.Now()
-	}
-	m.mu.Unlock()
+	m.setQueuedAt(item, time.Now())
 
 	m.mrec.IncResourceEventQueued(ctx, m.name, false)
 	m.queue.Add(ctx, item)
 }
 
+func (m *metricsBlockingQueue) AddAfter(ctx context.Context, item interface{}, duration time.Duration, requeue bool) {
+	// The item will not be on the queue until the duration passes.
+	m.setQueuedAt(item, time.Now().Add(duration))
+
+	m.mrec.IncResourceEventQueued(ctx, m.name, requeue)
+	m.queue.AddAfter(ctx, item, duration, requeue)
+}
+
 func (m *metricsBlockingQueue) Requeue(ctx context.Context, item interface{}) error {
-	m.mu.Lock()
-	if _, ok := m.itemsQueuedAt[item]; !ok {
-		m.itemsQueuedAt[item] = time.Now()
-	}
-	m.mu.Unlock()
+	m.setQueuedAt(item, time.Now())
 
 	m.mrec.IncResourceEventQueued(ctx, m.name, true)
 	return m.queue.Requeue(ctx, item)
@@ -136,6 +149,10 @@ func (m *metricsBlockingQueue) Get(ctx context.Context) (interface{}, bool) {
 	m.mu.Lock()
 	queuedAt, ok := m.itemsQueuedAt[item]
 	if ok {
+		// A delayed item can be got before its delay if it was added again meanwhile.
+		if now := time.Now(); queuedAt.After(now) {
+			queuedAt = now
+		}
 		m.mrec.ObserveResourceInQueueDuration(ctx, m.name, queuedAt)
 		delete(m.itemsQueuedAt, item)
 	} else {
@@ -147,6 +164,16 @@ func (m *metricsBlockingQueue) Get(ctx context.Context) (interface{}, bool) {
 	return item, shutdown
 }
 
+// setQueuedAt sets the time the item is ready on the queue, if the item is already queued the
+// earliest time is kept (the queue deduplicates the items, so it will be ready at that time).
+func (m *metricsBlockingQueue) setQueuedAt(item interface{}, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if queuedAt, ok := m.itemsQueuedAt[item]; !ok || t.Before(queuedAt) {
+		m.itemsQueuedAt[item] = t
+	}
+}
+
 func (m *metricsBlockingQueue) Done(ctx context.Context, item interface{}) {
 	m.queue.Done(ctx, item)
 }
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// newRateLimitedHandler returns a handler that limits the aggregated rate of handlings of all the
+// objects and workers, blocking the handling until the limiter allows it.
+func newRateLimitedHandler(limiter flowcontrol.RateLimiter, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		err := limiter.Wait(ctx)
+		if err != nil {
+			return Result{}, fmt.Errorf("handling rate limit wait failed: %w", err)
+		}
+
+		return handleWithResult(ctx, next, obj)
+	})
+}
@@ -0,0 +1,112 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerHandleRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const (
+		qps      = 20
+		interval = time.Second / qps
+	)
+
+	nsList, _ := createNamespaceList("testing", 8)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	var mu sync.Mutex
+	calls := []time.Time{}
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, time.Now())
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:              "test",
+		Handler:           h,
+		Retriever:         newNamespaceRetriever(mc),
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 8,
+		HandleQPS:         qps,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return c.Stats().Processed == 8 }, 2*time.Second, 5*time.Millisecond)
+
+	// Even with a worker per object, the handlings should be spaced by the global limiter.
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Before(calls[j]) })
+	for i := 1; i < len(calls); i++ {
+		assert.GreaterOrEqual(int64(calls[i].Sub(calls[i-1])), int64(interval*8/10))
+	}
+}
+
+// countingRateLimiter is a rate limiter that counts the retry delays it computes.
+type countingRateLimiter struct {
+	workqueue.RateLimiter
+	mu    sync.Mutex
+	whens int
+}
+
+func (c *countingRateLimiter) When(item interface{}) time.Duration {
+	c.mu.Lock()
+	c.whens++
+	c.mu.Unlock()
+	return c.RateLimiter.When(item)
+}
+
+func (c *countingRateLimiter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.whens
+}
+
+func TestGenericControllerRetryRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	rl := &countingRateLimiter{RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, 10*time.Millisecond)}
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              controller.HandlerFunc(func(context.Context, runtime.Object) error { return fmt.Errorf("wanted error") }),
+		Retriever:            newNamespaceRetriever(mc),
+		Logger:               log.Dummy,
+		ProcessingJobRetries: 3,
+		RateLimiter:          rl,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Every retry delay should be computed by the configured rate limiter.
+	assert.Eventually(func() bool { return c.Stats().Forgotten == 1 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(3, rl.count())
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// RequiredRBAC returns the RBAC rules the controller requires, inferred from its configuration. The
+// retrieved resource is only known if the retriever declares it (check `RetrieverScope.Resource`).
+// Namespaced retrievers restricted to a namespace only need the rules on that namespace (Role).
+func (g *Generic) RequiredRBAC() []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{}
+
+	if sr, ok := g.cfg.Retriever.(ScopedRetriever); ok && sr.Scope().Resource.Resource != "" {
+		res := sr.Scope().Resource
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{res.Group},
+			Resources: []string{res.Resource},
+			Verbs:     []string{"list", "watch"},
+		})
+	}
+
+	if g.cfg.EventRecorder != nil && g.cfg.ErrorEventInterval > 0 {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"events"},
+			Verbs:     []string{"create", "patch"},
+		})
+	}
+
+	return rules
+}
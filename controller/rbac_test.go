@@ -0,0 +1,80 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerRequiredRBAC(t *testing.T) {
+	tests := map[string]struct {
+		scope    *controller.RetrieverScope
+		events   bool
+		expRules []rbacv1.PolicyRule
+	}{
+		"A retriever without declared resource should not require rules.": {
+			expRules: []rbacv1.PolicyRule{},
+		},
+
+		"A retriever of a core resource should require listing and watching it.": {
+			scope: &controller.RetrieverScope{Namespaced: true, Resource: schema.GroupResource{Resource: "pods"}},
+			expRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "watch"}},
+			},
+		},
+
+		"A retriever of a grouped resource should require listing and watching it.": {
+			scope: &controller.RetrieverScope{Namespaced: true, Resource: schema.GroupResource{Group: "apps", Resource: "deployments"}},
+			expRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"list", "watch"}},
+			},
+		},
+
+		"Posting error events should require creating events.": {
+			scope:  &controller.RetrieverScope{Resource: schema.GroupResource{Resource: "namespaces"}},
+			events: true,
+			expRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"list", "watch"}},
+				{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var ret controller.Retriever = newNamespaceRetriever(&fake.Clientset{})
+			if test.scope != nil {
+				ret = controller.RetrieverWithScope(ret, *test.scope)
+			}
+
+			cfg := &controller.Config{
+				Name:      "test",
+				Handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+				Retriever: ret,
+				Logger:    log.Dummy,
+			}
+			if test.events {
+				cfg.EventRecorder = record.NewFakeRecorder(10)
+				cfg.ErrorEventInterval = time.Minute
+			}
+			c, err := controller.New(cfg)
+			require.NoError(err)
+
+			assert.Equal(test.expRules, c.RequiredRBAC())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// DuplicateNameBehavior is the behavior of the controller creation when other controller of the
+// process has already used the same name.
+type DuplicateNameBehavior int
+
+const (
+	// DuplicateNameIgnore ignores the duplicated names.
+	DuplicateNameIgnore DuplicateNameBehavior = iota
+	// DuplicateNameWarn logs a warning on duplicated names.
+	DuplicateNameWarn
+	// DuplicateNameFail fails the controller creation on duplicated names.
+	DuplicateNameFail
+)
+
+// nameRegistry is the process wide registry of the live controller names, used to detect the
+// controllers with the same name, their metrics and logs would collide.
+var nameRegistry = struct {
+	mu    sync.Mutex
+	names map[string]int
+}{names: map[string]int{}}
+
+// registerName registers the controller name, if the name is already used by a live controller it
+// will act based on the behavior. Returns the function that releases the name.
+func registerName(name string, behavior DuplicateNameBehavior, logger log.Logger) (release func(), err error) {
+	nameRegistry.mu.Lock()
+	defer nameRegistry.mu.Unlock()
+
+	if nameRegistry.names[name] > 0 {
+		switch behavior {
+		case DuplicateNameWarn:
+			logger.Warningf("controller name already used by other controller, the metrics and logs will collide")
+		case DuplicateNameFail:
+			return nil, fmt.Errorf("controller name %q is already used", name)
+		}
+	}
+	nameRegistry.names[name]++
+
+	return func() {
+		nameRegistry.mu.Lock()
+		defer nameRegistry.mu.Unlock()
+		nameRegistry.names[name]--
+		if nameRegistry.names[name] <= 0 {
+			delete(nameRegistry.names, name)
+		}
+	}, nil
+}
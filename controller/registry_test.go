@@ -0,0 +1,165 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerDuplicateName(t *testing.T) {
+	tests := map[string]struct {
+		behavior    controller.DuplicateNameBehavior
+		expErr      bool
+		expWarnings int
+	}{
+		"A duplicated name should be ignored by default.": {},
+
+		"A duplicated name should warn if configured.": {
+			behavior:    controller.DuplicateNameWarn,
+			expWarnings: 1,
+		},
+
+		"A duplicated name should fail if configured.": {
+			behavior: controller.DuplicateNameFail,
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Unique per test run, the registry is process wide.
+			ctrlName := fmt.Sprintf("test-duplicated-%d", time.Now().UnixNano())
+			newController := func(logger *warningLogger) error {
+				_, err := controller.New(&controller.Config{
+					Name:                  ctrlName,
+					Handler:               controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+					Retriever:             newNamespaceRetriever(&fake.Clientset{}),
+					Logger:                logger,
+					MetricsRecorder:       controller.DummyMetricsRecorder,
+					DuplicateNameBehavior: test.behavior,
+				})
+				return err
+			}
+			duplicateWarnings := func(logger *warningLogger) int {
+				n := 0
+				for _, w := range logger.warnings {
+					if strings.Contains(w, "name already used") {
+						n++
+					}
+				}
+				return n
+			}
+
+			// The first one should be ok.
+			logger := &warningLogger{Logger: log.Dummy}
+			require.NoError(newController(logger))
+			assert.Equal(0, duplicateWarnings(logger))
+
+			// The second one is a duplicate.
+			logger = &warningLogger{Logger: log.Dummy}
+			err := newController(logger)
+			if test.expErr {
+				assert.True(errors.Is(err, controller.ErrControllerNotValid))
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expWarnings, duplicateWarnings(logger))
+		})
+	}
+}
+
+func TestGenericControllerDuplicateNameReleased(t *testing.T) {
+	tests := map[string]struct {
+		release func(ctx context.Context, cancel context.CancelFunc, c *controller.Generic)
+	}{
+		"The name should be released when the controller run ends.": {
+			release: func(ctx context.Context, cancel context.CancelFunc, c *controller.Generic) {
+				cancel()
+				_ = c.Run(ctx)
+			},
+		},
+
+		"The name should be released when the controller is stopped.": {
+			release: func(_ context.Context, _ context.CancelFunc, c *controller.Generic) {
+				c.Stop()
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Unique per test run, the registry is process wide.
+			ctrlName := fmt.Sprintf("test-duplicated-released-%d", time.Now().UnixNano())
+			newController := func() (*controller.Generic, error) {
+				return controller.New(&controller.Config{
+					Name:                  ctrlName,
+					Handler:               controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+					Retriever:             newNamespaceRetriever(&fake.Clientset{}),
+					Logger:                log.Dummy,
+					MetricsRecorder:       controller.DummyMetricsRecorder,
+					DuplicateNameBehavior: controller.DuplicateNameFail,
+				})
+			}
+
+			c, err := newController()
+			require.NoError(err)
+			_, err = newController()
+			require.Error(err)
+
+			// Once released, the name can be used again.
+			test.release(ctx, cancel, c)
+			_, err = newController()
+			require.NoError(err)
+		})
+	}
+}
+
+// invalidLabelsRecorder is a metrics recorder that rejects the controller labels.
+type invalidLabelsRecorder struct {
+	controller.MetricsRecorder
+}
+
+func (invalidLabelsRecorder) RegisterControllerLabels(string, map[string]string) error {
+	return fmt.Errorf("wanted error")
+}
+
+func TestGenericControllerDuplicateNameFailedCreation(t *testing.T) {
+	require := require.New(t)
+
+	// Unique per test run, the registry is process wide.
+	ctrlName := fmt.Sprintf("test-duplicated-failed-%d", time.Now().UnixNano())
+	newController := func(mrec controller.MetricsRecorder) error {
+		_, err := controller.New(&controller.Config{
+			Name:                  ctrlName,
+			Handler:               controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+			Retriever:             newNamespaceRetriever(&fake.Clientset{}),
+			Logger:                log.Dummy,
+			MetricsRecorder:       mrec,
+			DuplicateNameBehavior: controller.DuplicateNameFail,
+		})
+		return err
+	}
+
+	// A failed creation should not keep the name.
+	require.Error(newController(invalidLabelsRecorder{MetricsRecorder: controller.DummyMetricsRecorder}))
+	require.NoError(newController(controller.DummyMetricsRecorder))
+}
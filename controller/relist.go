@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// ErrInconsistentCache can be returned (wrapped) by the handlers to signal that the object is inconsistent
+// with the cache, the informer will be rebuilt automatically with a full relist (check `RebuildInformer`).
+var ErrInconsistentCache = errors.New("inconsistent informer cache")
+
+// minAutoInformerRebuildInterval is the min interval between the automatic informer rebuilds, the
+// objects that triggered a rebuild could be on the fresh list too.
+const minAutoInformerRebuildInterval = time.Minute
+
+// relister is a ListerWatcher that can force the informer to make a full relist, this replaces
+// all the objects of the informer cache with the ones of the fresh list.
+//
+// To force the relist it expires the current watch, the same way the API server does when the
+// watch resource version is too old, so the informer recovers by relisting.
+type relister struct {
+	lw      cache.ListerWatcher
+	mu      sync.Mutex
+	current *expirableWatch
+}
+
+func newRelister(lw cache.ListerWatcher) *relister {
+	return &relister{lw: lw}
+}
+
+func (r *relister) List(options metav1.ListOptions) (runtime.Object, error) {
+	return r.lw.List(options)
+}
+
+func (r *relister) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, err := r.lw.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+
+	ew := newExpirableWatch(w)
+	r.mu.Lock()
+	r.current = ew
+	r.mu.Unlock()
+
+	return ew, nil
+}
+
+// relist forces a relist, returns false if there isn't a watch to expire.
+func (r *relister) relist() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil {
+		return false
+	}
+	r.current.expire()
+	r.current = nil
+
+	return true
+}
+
+// expirableWatch is a watch that can be expired on demand.
+type expirableWatch struct {
+	inner      watch.Interface
+	resultC    chan watch.Event
+	expireC    chan struct{}
+	stopC      chan struct{}
+	expireOnce sync.Once
+	stopOnce   sync.Once
+}
+
+func newExpirableWatch(inner watch.Interface) *expirableWatch {
+	w := &expirableWatch{
+		inner:   inner,
+		resultC: make(chan watch.Event),
+		expireC: make(chan struct{}),
+		stopC:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *expirableWatch) run() {
+	defer close(w.resultC)
+	defer w.inner.Stop()
+
+	in := w.inner.ResultChan()
+	for {
+		select {
+		case <-w.stopC:
+			return
+		case <-w.expireC:
+			status := apierrors.NewResourceExpired("relist forced").ErrStatus
+			select {
+			case w.resultC <- watch.Event{Type: watch.Error, Object: &status}:
+			case <-w.stopC:
+			}
+			return
+		case e, ok := <-in:
+			if !ok {
+				return
+			}
+			select {
+			case w.resultC <- e:
+			case <-w.stopC:
+				return
+			}
+		}
+	}
+}
+
+func (w *expirableWatch) expire() { w.expireOnce.Do(func() { close(w.expireC) }) }
+
+// Stop satisfies watch.Interface.
+func (w *expirableWatch) Stop() { w.stopOnce.Do(func() { close(w.stopC) }) }
+
+// ResultChan satisfies watch.Interface.
+func (w *expirableWatch) ResultChan() <-chan watch.Event { return w.resultC }
+
+// informerRebuilder rebuilds the informer with a full relist, automatically when the objects can't be
+// resolved to a key repeatedly or when the handlers signal an inconsistent cache.
+type informerRebuilder struct {
+	name      string
+	relister  *relister
+	threshold int
+	clock     clock.PassiveClock
+	mrec      MetricsRecorder
+	logger    log.Logger
+
+	mu          sync.Mutex
+	keyFailures int
+	lastAuto    time.Time
+}
+
+// rebuild forces the relist, returns false if the informer is not watching yet.
+func (i *informerRebuilder) rebuild(reason string) bool {
+	if !i.relister.relist() {
+		return false
+	}
+
+	i.mrec.IncResourceInformerRebuild(context.Background(), i.name)
+	i.logger.Warningf("rebuilding informer cache with a full relist: %s", reason)
+
+	return true
+}
+
+// autoRebuild rebuilds the informer if the last automatic rebuild is old enough.
+func (i *informerRebuilder) autoRebuild(reason string) bool {
+	if !i.lastAuto.IsZero() && i.clock.Since(i.lastAuto) < minAutoInformerRebuildInterval {
+		return false
+	}
+	if !i.rebuild(reason) {
+		return false
+	}
+	i.lastAuto = i.clock.Now()
+
+	return true
+}
+
+// keyFailed counts an object that couldn't be resolved to a key, rebuilding the informer once the
+// threshold is reached.
+func (i *informerRebuilder) keyFailed() {
+	if i == nil || i.threshold <= 0 {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keyFailures++
+	if i.keyFailures < i.threshold {
+		return
+	}
+	if i.autoRebuild(fmt.Sprintf("%d objects couldn't be resolved to a key", i.keyFailures)) {
+		i.keyFailures = 0
+	}
+}
+
+// processor returns a processor that rebuilds the informer when the handling signals an inconsistent
+// cache (`ErrInconsistentCache`).
+func (i *informerRebuilder) processor(next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+		if errors.Is(err, ErrInconsistentCache) {
+			i.mu.Lock()
+			i.autoRebuild(fmt.Sprintf("handler signaled an inconsistent cache on %q", key))
+			i.mu.Unlock()
+		}
+		return err
+	})
+}
+
+// RebuildInformer forces a full relist of the resources, replacing the informer cache with the fresh
+// list. Used to recover from an inconsistent cache. Returns an error if the controller is not watching.
+func (g *Generic) RebuildInformer() error {
+	if !g.isRunning() {
+		return fmt.Errorf("controller not running")
+	}
+
+	if !g.rebuilder.rebuild("forced") {
+		return fmt.Errorf("informer is not watching yet")
+	}
+
+	return nil
+}
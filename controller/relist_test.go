@@ -0,0 +1,257 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// informerRebuildRecorder is a metrics recorder that counts the informer rebuilds.
+type informerRebuildRecorder struct {
+	controller.MetricsRecorder
+	mu       sync.Mutex
+	rebuilds int
+}
+
+func (i *informerRebuildRecorder) IncResourceInformerRebuild(context.Context, string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rebuilds++
+}
+
+func TestGenericControllerRebuildInformer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every list and watch returns the latest ones.
+	var mu sync.Mutex
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}}},
+	}
+	var w *watch.FakeWatcher
+	lists := 0
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			lists++
+			return nsl.DeepCopy(), nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			w = watch.NewFake()
+			return w, nil
+		},
+	})
+	currentWatcher := func() *watch.FakeWatcher {
+		mu.Lock()
+		defer mu.Unlock()
+		return w
+	}
+
+	var hmu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		hmu.Lock()
+		defer hmu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		return nil
+	})
+	handledNames := func() []string {
+		hmu.Lock()
+		defer hmu.Unlock()
+		return append([]string{}, handled...)
+	}
+
+	mrec := &informerRebuildRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+	c, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         h,
+		Retriever:       ret,
+		MetricsRecorder: mrec,
+		Logger:          log.Dummy,
+	})
+	require.NoError(err)
+
+	// Not running, can't rebuild.
+	assert.Error(c.RebuildInformer())
+
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(handledNames()) == 1 && currentWatcher() != nil }, 1*time.Second, 5*time.Millisecond)
+
+	// The source of truth changed and the watch missed it, the cache is inconsistent.
+	mu.Lock()
+	nsl = &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "5"},
+		Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-2", ResourceVersion: "5"}}},
+	}
+	mu.Unlock()
+	oldWatcher := currentWatcher()
+	require.NoError(c.RebuildInformer())
+
+	// The cache should be rebuilt from the fresh list.
+	assert.Eventually(func() bool { return len(handledNames()) == 2 }, 3*time.Second, 5*time.Millisecond)
+	assert.Equal([]string{"ns-1", "ns-2"}, handledNames())
+	assert.Equal(controller.EventCounts{Add: 2, Delete: 1}, c.EventCounts())
+	mu.Lock()
+	assert.Equal(2, lists)
+	mu.Unlock()
+	mrec.mu.Lock()
+	assert.Equal(1, mrec.rebuilds)
+	mrec.mu.Unlock()
+
+	// The events should resume on the new watch.
+	assert.Eventually(func() bool { return currentWatcher() != oldWatcher }, 1*time.Second, 5*time.Millisecond)
+	currentWatcher().Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-3", ResourceVersion: "6"}})
+	assert.Eventually(func() bool { return len(handledNames()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal("ns-3", handledNames()[2])
+}
+
+func TestGenericControllerAutoRebuildInformer(t *testing.T) {
+	tests := map[string]struct {
+		threshold  int
+		keyFunc    cache.KeyFunc
+		handlerErr func(obj runtime.Object) error
+		events     []*corev1.Namespace
+		expLists   int
+	}{
+		"Objects with invalid meta below the threshold should not rebuild the informer.": {
+			threshold: 3,
+			events:    []*corev1.Namespace{{}, {}},
+			expLists:  1,
+		},
+
+		"Objects with invalid meta on the threshold should rebuild the informer.": {
+			threshold: 2,
+			events:    []*corev1.Namespace{{}, {}},
+			expLists:  2,
+		},
+
+		"Objects that can't be resolved by the key func on the threshold should rebuild the informer.": {
+			threshold: 1,
+			keyFunc: func(obj interface{}) (string, error) {
+				if obj.(*corev1.Namespace).Name == "ns-bad" {
+					return "", fmt.Errorf("wanted error")
+				}
+				return cache.MetaNamespaceKeyFunc(obj)
+			},
+			events:   []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-bad"}}},
+			expLists: 2,
+		},
+
+		"Key failures without threshold should not rebuild the informer.": {
+			events:   []*corev1.Namespace{{}, {}},
+			expLists: 1,
+		},
+
+		"A handler signaling an inconsistent cache should rebuild the informer.": {
+			handlerErr: func(obj runtime.Object) error {
+				if obj.(*corev1.Namespace).Name == "ns-2" {
+					return fmt.Errorf("stale object: %w", controller.ErrInconsistentCache)
+				}
+				return nil
+			},
+			events:   []*corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-2"}}},
+			expLists: 2,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var w *watch.FakeWatcher
+			lists := 0
+			ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+				ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					lists++
+					return &corev1.NamespaceList{
+						ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+						Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}}},
+					}, nil
+				},
+				WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					w = watch.NewFake()
+					return w, nil
+				},
+			})
+			getLists := func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return lists
+			}
+			currentWatcher := func() *watch.FakeWatcher {
+				mu.Lock()
+				defer mu.Unlock()
+				return w
+			}
+
+			var hmu sync.Mutex
+			handled := 0
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				hmu.Lock()
+				defer hmu.Unlock()
+				handled++
+				if test.handlerErr != nil {
+					return test.handlerErr(obj)
+				}
+				return nil
+			})
+
+			mrec := &informerRebuildRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+			c, err := controller.New(&controller.Config{
+				Name:                     "test",
+				Handler:                  h,
+				Retriever:                ret,
+				KeyFunc:                  test.keyFunc,
+				InformerRebuildThreshold: test.threshold,
+				MetricsRecorder:          mrec,
+				Logger:                   log.Dummy,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+			require.Eventually(func() bool { return currentWatcher() != nil }, 1*time.Second, 5*time.Millisecond)
+
+			for i, e := range test.events {
+				e.ResourceVersion = fmt.Sprintf("%d", i+2)
+				currentWatcher().Add(e)
+			}
+
+			if test.expLists > 1 {
+				assert.Eventually(func() bool { return getLists() == test.expLists }, 3*time.Second, 5*time.Millisecond)
+			} else {
+				time.Sleep(100 * time.Millisecond)
+			}
+			assert.Equal(test.expLists, getLists())
+			mrec.mu.Lock()
+			assert.Equal(test.expLists-1, mrec.rebuilds)
+			mrec.mu.Unlock()
+		})
+	}
+}
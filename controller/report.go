@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Report results.
+const (
+	ReportResultSuccess = "success"
+	ReportResultError   = "error"
+)
+
+// ReportEntry is the result of processing an object.
+type ReportEntry struct {
+	Key      string        `json:"key"`
+	Result   string        `json:"result"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Reporter collects the results of the objects processed by a controller, so they can
+// be rendered afterwards (e.g CLIs that need a summary of what has been reconciled).
+type Reporter struct {
+	mu      sync.Mutex
+	entries []ReportEntry
+}
+
+// NewReporter returns a new Reporter.
+func NewReporter() *Reporter {
+	return &Reporter{}
+}
+
+func (r *Reporter) add(e ReportEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// Entries returns the collected entries in processing order.
+func (r *Reporter) Entries() []ReportEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]ReportEntry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// WriteTable writes the collected entries as a human readable table.
+func (r *Reporter) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tRESULT\tDURATION\tERROR")
+	for _, e := range r.Entries() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Key, e.Result, e.Duration, e.Error)
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes the collected entries in JSON format.
+func (r *Reporter) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Entries())
+}
+
+// newReportProcessor returns a processor that reports the result of every processing.
+func newReportProcessor(reporter *Reporter, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		start := time.Now()
+		err := next.Process(ctx, key)
+
+		e := ReportEntry{Key: key, Result: ReportResultSuccess, Duration: time.Since(start)}
+		if err != nil {
+			e.Result = ReportResultError
+			e.Error = err.Error()
+		}
+		reporter.add(e)
+
+		return err
+	})
+}
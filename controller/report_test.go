@@ -0,0 +1,72 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestReporter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	ret, _ := newFakeNamespaceRetriever(nsList)
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		if obj.(*corev1.Namespace).Name == "testing-1" {
+			return fmt.Errorf("wanted error")
+		}
+		return nil
+	})
+
+	reporter := controller.NewReporter()
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: ret,
+		Reporter:  reporter,
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(reporter.Entries()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	cancel()
+
+	// Check the collected rows.
+	got := map[string]controller.ReportEntry{}
+	for _, e := range reporter.Entries() {
+		got[e.Key] = e
+	}
+	assert.Equal(controller.ReportResultSuccess, got["testing-0"].Result)
+	assert.Equal(controller.ReportResultError, got["testing-1"].Result)
+	assert.Equal("wanted error", got["testing-1"].Error)
+	assert.Equal(controller.ReportResultSuccess, got["testing-2"].Result)
+
+	// Check the table rendering.
+	var table bytes.Buffer
+	require.NoError(reporter.WriteTable(&table))
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	require.Len(lines, 4)
+	assert.Equal([]string{"KEY", "RESULT", "DURATION", "ERROR"}, strings.Fields(lines[0]))
+
+	// Check the JSON rendering.
+	var js bytes.Buffer
+	require.NoError(reporter.WriteJSON(&js))
+	gotJSON := []controller.ReportEntry{}
+	require.NoError(json.Unmarshal(js.Bytes(), &gotJSON))
+	assert.Equal(reporter.Entries(), gotJSON)
+}
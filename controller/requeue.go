@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// resultRequeuer knows how to requeue the objects based on the handling result.
+//
+// The objects that ask to be requeued without an explicit delay will be requeued using
+// a per object capped exponential delay, this delay is reset when the object version changes.
+type resultRequeuer struct {
+	queue      blockingQueue
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	mu         sync.Mutex
+	objBackoff map[string]requeueBackoff
+}
+
+type requeueBackoff struct {
+	version string
+	delay   time.Duration
+}
+
+func newResultRequeuer(queue blockingQueue, baseDelay, maxDelay time.Duration) *resultRequeuer {
+	return &resultRequeuer{
+		queue:      queue,
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+		objBackoff: map[string]requeueBackoff{},
+	}
+}
+
+// requeue will requeue the object key if the result requires it.
+func (r *resultRequeuer) requeue(ctx context.Context, key string, obj runtime.Object, res Result) {
+	switch {
+	case res.RequeueAfter > 0:
+		r.forget(key)
+		// Scheduled by the handler, not a retry.
+		r.queue.AddAfter(ctx, key, res.RequeueAfter, false)
+	case res.Requeue:
+		r.queue.AddAfter(ctx, key, r.nextDelay(key, objectVersion(obj)), true)
+	default:
+		r.forget(key)
+	}
+}
+
+// nextDelay returns the delay for the object requeue, doubling the previous one if the object
+// version is the same.
+func (r *resultRequeuer) nextDelay(key, version string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.objBackoff[key]
+	switch {
+	case !ok || b.version != version:
+		b = requeueBackoff{version: version, delay: r.baseDelay}
+	default:
+		b.delay *= 2
+		if b.delay > r.maxDelay {
+			b.delay = r.maxDelay
+		}
+	}
+	r.objBackoff[key] = b
+
+	return b.delay
+}
+
+func (r *resultRequeuer) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.objBackoff, key)
+}
+
+// objectVersion returns the resource version of an object, empty if it can't be obtained.
+func objectVersion(obj runtime.Object) string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return m.GetResourceVersion()
+}
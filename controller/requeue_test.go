@@ -0,0 +1,143 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerResultRequeueBackoff(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const (
+		base = 20 * time.Millisecond
+		max  = 80 * time.Millisecond
+	)
+
+	nsList, _ := createNamespaceList("testing", 1)
+	ret, w := newFakeNamespaceRetriever(nsList)
+
+	// The handler always asks for a requeue, after some calls we update the object.
+	var mu sync.Mutex
+	calls := []time.Time{}
+	doneC := make(chan struct{})
+	h := controller.ResultHandlerFunc(func(_ context.Context, obj runtime.Object) (controller.Result, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, time.Now())
+		switch len(calls) {
+		case 5:
+			ns := obj.DeepCopyObject().(*corev1.Namespace)
+			ns.ResourceVersion = "999"
+			go w.Modify(ns)
+		case 7:
+			close(doneC)
+		}
+		return controller.Result{Requeue: true}, nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:               "test",
+		Handler:            h,
+		Retriever:          ret,
+		RequeueBackoffBase: base,
+		RequeueBackoffMax:  max,
+		Logger:             log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case <-doneC:
+	case <-time.After(2 * time.Second):
+		require.Fail("timeout waiting for requeues")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	interval := func(i int) time.Duration { return calls[i].Sub(calls[i-1]) }
+
+	// Same version, the delays escalate until the max.
+	assert.GreaterOrEqual(int64(interval(1)), int64(base))
+	assert.GreaterOrEqual(int64(interval(2)), int64(2*base))
+	assert.GreaterOrEqual(int64(interval(3)), int64(max))
+	assert.GreaterOrEqual(int64(interval(4)), int64(max))
+
+	// The object changed (6th call is the update event), the delay is reset.
+	assert.Less(int64(interval(6)), int64(max))
+
+	// Requeues on success are not retries.
+	assert.Equal(int64(0), c.Stats().Requeued)
+}
+
+func TestGenericControllerResultRequeueAfterPolling(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const pollInterval = 30 * time.Millisecond
+
+	nsList, _ := createNamespaceList("testing", 1)
+	ret, _ := newFakeNamespaceRetriever(nsList)
+
+	// The handler polls until the condition is met (4th call).
+	var mu sync.Mutex
+	calls := []time.Time{}
+	doneC := make(chan struct{})
+	h := controller.ResultHandlerFunc(func(_ context.Context, obj runtime.Object) (controller.Result, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, time.Now())
+		if len(calls) < 4 {
+			return controller.Result{RequeueAfter: pollInterval}, nil
+		}
+		close(doneC)
+		return controller.Result{}, nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            ret,
+		ProcessingJobRetries: 1,
+		Logger:               log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case <-doneC:
+	case <-time.After(2 * time.Second):
+		require.Fail("timeout waiting for polling")
+	}
+
+	// Once the condition is met it should not be requeued again.
+	time.Sleep(2 * pollInterval)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(calls, 4)
+	for i := 1; i < len(calls); i++ {
+		assert.GreaterOrEqual(int64(calls[i].Sub(calls[i-1])), int64(pollInterval))
+	}
+
+	// Polling requeues are successful handlings, not retries (even being more than the max retries).
+	assert.Equal(controller.Stats{Processed: 4}, func() controller.Stats {
+		s := c.Stats()
+		s.ResyncInterval = 0
+		return s
+	}())
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// inFlightHandling is a handling that is running.
+type inFlightHandling struct {
+	version   string
+	cancel    context.CancelFunc
+	restarted bool
+}
+
+// changeRestarter cancels the in-flight handlings of the objects that receive a newer version, the
+// object is already queued again by the change so it will be handled again with the latest state.
+type changeRestarter struct {
+	mu       sync.Mutex
+	inFlight map[string]*inFlightHandling
+}
+
+func newChangeRestarter() *changeRestarter {
+	return &changeRestarter{inFlight: map[string]*inFlightHandling{}}
+}
+
+// changed notifies a new version of an object, if the object is being handled with an
+// older version the handling will be cancelled.
+func (c *changeRestarter) changed(key string, obj runtime.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.inFlight[key]
+	if !ok || h.version == objectVersion(obj) {
+		return
+	}
+	h.restarted = true
+	h.cancel()
+}
+
+// handler returns a handler that cancels its context when a newer version of the object is received.
+// The cancelled handlings will not return their error, so they are not retried nor count as failed.
+func (c *changeRestarter) handler(next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return handleWithResult(ctx, next, obj)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		h := &inFlightHandling{version: objectVersion(obj), cancel: cancel}
+		c.mu.Lock()
+		c.inFlight[key] = h
+		c.mu.Unlock()
+
+		res, err := handleWithResult(ctx, next, obj)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.inFlight, key)
+		if h.restarted {
+			return Result{}, nil
+		}
+
+		return res, err
+	})
+}
@@ -0,0 +1,89 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerRestartOnChange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}},
+		},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	// The first version handling will block until is cancelled.
+	var mu sync.Mutex
+	handlings := []string{}
+	getHandlings := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handlings...)
+	}
+	startedC := make(chan struct{}, 1)
+	h := controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		rv := obj.(*corev1.Namespace).ResourceVersion
+		if rv != "1" {
+			mu.Lock()
+			handlings = append(handlings, "handled "+rv)
+			mu.Unlock()
+			return nil
+		}
+
+		startedC <- struct{}{}
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			handlings = append(handlings, "cancelled "+rv)
+			mu.Unlock()
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            ret,
+		Logger:               log.Dummy,
+		RestartOnChange:      true,
+		DisableResync:        true,
+		ProcessingJobRetries: 3,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	select {
+	case <-startedC:
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the handling to start")
+	}
+
+	// Update the object while it's being handled.
+	w.Modify(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(getHandlings()) == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	// The cancelled handling should not be retried.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal([]string{"cancelled 1", "handled 2"}, getHandlings())
+	assert.Zero(c.Stats().Errored)
+}
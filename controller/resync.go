@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// apiLatencyObserver measures the latency of the informer list and watch requests, so the
+// apiserver pressure is measured without sending extra requests.
+type apiLatencyObserver struct {
+	mu      sync.Mutex
+	latency time.Duration
+	err     error
+}
+
+// wrap returns a ListerWatcher that observes the latency of the list and watch requests.
+func (o *apiLatencyObserver) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			start := time.Now()
+			obj, err := lw.List(options)
+			o.observe(time.Since(start), err)
+			return obj, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			start := time.Now()
+			w, err := lw.Watch(options)
+			o.observe(time.Since(start), err)
+			return w, err
+		},
+	}
+}
+
+func (o *apiLatencyObserver) observe(latency time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.latency = latency
+	o.err = err
+}
+
+// last returns the latency of the last request and its error.
+func (o *apiLatencyObserver) last() (time.Duration, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.latency, o.err
+}
+
+// adaptiveResyncer resyncs all the objects of the cache at an interval that adapts to the
+// apiserver pressure. Before every resync the last latency of the informer requests is checked,
+// if the latency is greater than the threshold the interval is doubled (up to the max interval),
+// otherwise the interval goes back to the base interval.
+type adaptiveResyncer struct {
+	observer         *apiLatencyObserver
+	indexer          cache.Indexer
+	handler          cache.ResourceEventHandler
+	baseInterval     time.Duration
+	maxInterval      time.Duration
+	latencyThreshold time.Duration
+	logger           log.Logger
+
+	mu       sync.Mutex
+	interval time.Duration
+}
+
+func newAdaptiveResyncer(observer *apiLatencyObserver, indexer cache.Indexer, handler cache.ResourceEventHandler, baseInterval, maxInterval, latencyThreshold time.Duration, logger log.Logger) *adaptiveResyncer {
+	return &adaptiveResyncer{
+		observer:         observer,
+		indexer:          indexer,
+		handler:          handler,
+		baseInterval:     baseInterval,
+		maxInterval:      maxInterval,
+		latencyThreshold: latencyThreshold,
+		logger:           logger,
+		interval:         baseInterval,
+	}
+}
+
+// run resyncs the objects until the context is done.
+func (a *adaptiveResyncer) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.currentInterval()):
+		}
+
+		a.adapt(ctx, a.lastLatency())
+
+		// Resync the same way the informers do, using the cached objects as updates.
+		for _, obj := range a.indexer.List() {
+			a.handler.OnUpdate(obj, obj)
+		}
+	}
+}
+
+func (a *adaptiveResyncer) lastLatency() time.Duration {
+	latency, err := a.observer.last()
+	if err != nil {
+		// A failing apiserver is an apiserver under pressure.
+		a.logger.Warningf("apiserver request failed: %s", err)
+		return a.latencyThreshold + 1
+	}
+
+	return latency
+}
+
+func (a *adaptiveResyncer) adapt(_ context.Context, latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	old := a.interval
+	switch {
+	case latency > a.latencyThreshold:
+		a.interval *= 2
+		if a.interval > a.maxInterval {
+			a.interval = a.maxInterval
+		}
+	default:
+		a.interval = a.baseInterval
+	}
+
+	if old != a.interval {
+		a.logger.WithKV(log.KV{"latency": latency}).Infof("resync interval changed from %s to %s", old, a.interval)
+	}
+}
+
+func (a *adaptiveResyncer) currentInterval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.interval
+}
+
+// resyncJitterDelay returns a random delay inside the jitter window of the resync interval, so the
+// resync enqueues are smeared across the window instead of enqueuing all the objects at once.
+func resyncJitterDelay(interval time.Duration, jitter float64) time.Duration {
+	return time.Duration(rand.Float64() * jitter * float64(interval))
+}
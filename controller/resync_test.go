@@ -0,0 +1,167 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerAdaptiveResync(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const resync = 10 * time.Millisecond
+
+	// Fake apiserver with injected latency, the watchers are kept so the test can
+	// restart the watches.
+	var latency int64
+	var mu sync.Mutex
+	var watcher *watch.FakeWatcher
+	var lists int32
+	nsList, _ := createNamespaceList("testing", 2)
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
+			atomic.AddInt32(&lists, 1)
+			time.Sleep(time.Duration(atomic.LoadInt64(&latency)))
+			return nsList, nil
+		},
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) {
+			time.Sleep(time.Duration(atomic.LoadInt64(&latency)))
+			mu.Lock()
+			defer mu.Unlock()
+			watcher = watch.NewFake()
+			return watcher, nil
+		},
+	})
+	rewatch := func() {
+		mu.Lock()
+		w := watcher
+		mu.Unlock()
+		w.Modify(&nsList.Items[0])
+		w.Stop()
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:                           "test",
+		Handler:                        controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:                      ret,
+		ResyncInterval:                 resync,
+		AdaptiveResyncLatencyThreshold: 5 * time.Millisecond,
+		AdaptiveResyncMaxInterval:      80 * time.Millisecond,
+		Logger:                         log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Without latency the resync is the regular one.
+	assert.Eventually(func() bool { return c.Stats().Processed > 4 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal(resync, c.Stats().ResyncInterval)
+
+	// With high latency on the informer requests the resync interval lengthens.
+	atomic.StoreInt64(&latency, int64(10*time.Millisecond))
+	rewatch()
+	assert.Eventually(func() bool { return c.Stats().ResyncInterval == 80*time.Millisecond }, 1*time.Second, 5*time.Millisecond)
+
+	// Once recovered is back to normal.
+	atomic.StoreInt64(&latency, 0)
+	rewatch()
+	assert.Eventually(func() bool { return c.Stats().ResyncInterval == resync }, 1*time.Second, 5*time.Millisecond)
+
+	// The latency should be measured without extra list requests.
+	assert.Equal(int32(1), atomic.LoadInt32(&lists))
+}
+
+func TestGenericControllerResyncJitter(t *testing.T) {
+	tests := map[string]struct {
+		jitter    float64
+		expSmear  bool
+		expNewErr bool
+	}{
+		"Without jitter the resync should enqueue all the objects at once.": {
+			jitter:   0,
+			expSmear: false,
+		},
+
+		"With jitter the resync should smear the objects across the interval window.": {
+			jitter:   1,
+			expSmear: true,
+		},
+
+		"An invalid jitter should fail.": {
+			jitter:    1.5,
+			expNewErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			const (
+				objs   = 20
+				resync = 500 * time.Millisecond
+			)
+			nsList, _ := createNamespaceList("testing", objs)
+			ret, _ := newFakeNamespaceRetriever(nsList)
+
+			var mu sync.Mutex
+			handledAt := []time.Time{}
+			h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handledAt = append(handledAt, time.Now())
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:           "test",
+				Handler:        h,
+				Retriever:      ret,
+				Logger:         log.Dummy,
+				ResyncInterval: resync,
+				ResyncJitter:   test.jitter,
+			})
+			if test.expNewErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			// Wait for the initial sync and the first resync handlings.
+			assert.Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(handledAt) >= 2*objs
+			}, 3*time.Second, 5*time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			resyncs := handledAt[objs : 2*objs]
+			spread := resyncs[len(resyncs)-1].Sub(resyncs[0])
+			if test.expSmear {
+				assert.True(spread > 100*time.Millisecond, "spread %s", spread)
+			} else {
+				assert.True(spread < 100*time.Millisecond, "spread %s", spread)
+			}
+		})
+	}
+}
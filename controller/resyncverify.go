@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// resyncVerifier verifies that the resynced objects still exist on the apiserver before handling
+// them. An object deleted while the delete event was missed lingers on the cache until the next
+// relist, and the resyncs would handle it as if it still existed.
+type resyncVerifier struct {
+	retriever Retriever
+	logger    log.Logger
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+func newResyncVerifier(ret Retriever, logger log.Logger) *resyncVerifier {
+	return &resyncVerifier{
+		retriever: ret,
+		logger:    logger,
+		pending:   map[string]bool{},
+	}
+}
+
+// resynced marks the key as enqueued by a resync.
+func (r *resyncVerifier) resynced(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[key] = true
+}
+
+func (r *resyncVerifier) popResynced(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resynced := r.pending[key]
+	delete(r.pending, key)
+	return resynced
+}
+
+// exists checks the object existence on the apiserver with a list of the object only.
+func (r *resyncVerifier) exists(ctx context.Context, obj interface{}) (bool, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return false, fmt.Errorf("could not get object metadata: %w", err)
+	}
+
+	set := fields.Set{"metadata.name": m.GetName()}
+	if ns := m.GetNamespace(); ns != "" {
+		set["metadata.namespace"] = ns
+	}
+	l, err := r.retriever.List(ctx, metav1.ListOptions{FieldSelector: fields.SelectorFromSet(set).String()})
+	if err != nil {
+		return false, err
+	}
+	items, err := meta.ExtractList(l)
+	if err != nil {
+		return false, fmt.Errorf("could not extract list items: %w", err)
+	}
+
+	return len(items) > 0, nil
+}
+
+// processor returns a processor that drops the resynced objects that don't exist anymore.
+func (r *resyncVerifier) processor(indexer cache.Indexer, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		if !r.popResynced(key) {
+			return next.Process(ctx, key)
+		}
+
+		obj, exists, err := indexer.GetByKey(key)
+		if err != nil || !exists {
+			return next.Process(ctx, key)
+		}
+
+		ok, err := r.exists(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("could not verify resynced object existence: %w", err)
+		}
+		if !ok {
+			r.logger.WithKV(log.KV{"object-key": key}).Warningf("resynced object doesn't exist anymore, stale cache, dropping it")
+			return nil
+		}
+
+		return next.Process(ctx, key)
+	})
+}
@@ -0,0 +1,109 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerVerifyOnResync(t *testing.T) {
+	tests := map[string]struct {
+		verify         bool
+		expStaleHandle bool
+	}{
+		"Trusting the cache should handle the stale cached objects on resync.": {
+			verify:         false,
+			expStaleHandle: true,
+		},
+
+		"Verifying on resync should drop the stale cached objects.": {
+			verify:         true,
+			expStaleHandle: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The apiserver objects, the watch will never notify the deletions (missed delete events).
+			var mu sync.Mutex
+			server := map[string]bool{"ns-1": true, "ns-2": true}
+			ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					selector, err := fields.ParseSelector(options.FieldSelector)
+					if err != nil {
+						return nil, err
+					}
+					nsl := &corev1.NamespaceList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}
+					for _, name := range []string{"ns-1", "ns-2"} {
+						if server[name] && selector.Matches(fields.Set{"metadata.name": name}) {
+							nsl.Items = append(nsl.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: "1"}})
+						}
+					}
+					return nsl, nil
+				},
+				WatchFunc: func(metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+			})
+
+			handled := map[string]int{}
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handled[obj.(*corev1.Namespace).Name]++
+				return nil
+			})
+			getHandled := func(name string) int {
+				mu.Lock()
+				defer mu.Unlock()
+				return handled[name]
+			}
+
+			c, err := controller.New(&controller.Config{
+				Name:           "test",
+				Handler:        h,
+				Retriever:      ret,
+				Logger:         log.Dummy,
+				ResyncInterval: 1 * time.Second,
+				VerifyOnResync: test.verify,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+			assert.Eventually(func() bool { return getHandled("ns-1") == 1 && getHandled("ns-2") == 1 }, 1*time.Second, 5*time.Millisecond)
+
+			// Delete the object on the apiserver, it will stay on the cache.
+			mu.Lock()
+			delete(server, "ns-2")
+			mu.Unlock()
+			before := getHandled("ns-2")
+
+			// Wait for a resync.
+			assert.Eventually(func() bool { return getHandled("ns-1") >= 2 }, 3*time.Second, 5*time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+			if test.expStaleHandle {
+				assert.Greater(getHandled("ns-2"), before)
+			} else {
+				assert.Equal(before, getHandled("ns-2"))
+			}
+		})
+	}
+}
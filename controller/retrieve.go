@@ -5,8 +5,12 @@ import (
 	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -48,3 +52,101 @@ func (l listerWatcherRetriever) List(_ context.Context, options metav1.ListOptio
 func (l listerWatcherRetriever) Watch(_ context.Context, options metav1.ListOptions) (watch.Interface, error) {
 	return l.lw.Watch(options)
 }
+
+type fieldSelectorRetriever struct {
+	lw       cache.ListerWatcher
+	selector string
+}
+
+// NewRetrieverFromListerWatcherWithFieldSelector returns a Retriever from a Kubernetes client-go cache.ListerWatcher
+// that only lists and watches the objects that match the field selector (e.g `spec.nodeName=<node>` to only
+// retrieve the pods of a node), so the server does the filtering. If the received lister watcher is nil or the
+// field selector is not valid it will error.
+func NewRetrieverFromListerWatcherWithFieldSelector(lw cache.ListerWatcher, fieldSelector string) (Retriever, error) {
+	if lw == nil {
+		return nil, fmt.Errorf("listerWatcher can't be nil")
+	}
+	selector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector: %w", err)
+	}
+	return fieldSelectorRetriever{lw: lw, selector: selector.String()}, nil
+}
+
+// mergeFieldSelector returns the field selector of the options restricted by the selector,
+// both selectors need to match.
+func (f fieldSelectorRetriever) mergeFieldSelector(options metav1.ListOptions) metav1.ListOptions {
+	if options.FieldSelector == "" {
+		options.FieldSelector = f.selector
+		return options
+	}
+	if f.selector != "" {
+		options.FieldSelector = options.FieldSelector + "," + f.selector
+	}
+	return options
+}
+
+func (f fieldSelectorRetriever) List(_ context.Context, options metav1.ListOptions) (runtime.Object, error) {
+	return f.lw.List(f.mergeFieldSelector(options))
+}
+func (f fieldSelectorRetriever) Watch(_ context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	return f.lw.Watch(f.mergeFieldSelector(options))
+}
+
+type dynamicRetriever struct {
+	client dynamic.ResourceInterface
+}
+
+// NewRetrieverFromDynamic returns a Retriever that lists and watches the resources of the GVR using
+// a dynamic client, the retrieved objects will be `*unstructured.Unstructured`. Useful for CRDs or
+// generic controllers that don't have typed clients. If the namespace is empty, it will retrieve
+// the resources of all the namespaces (or the cluster scoped resources).
+func NewRetrieverFromDynamic(client dynamic.Interface, gvr schema.GroupVersionResource, namespace string) (Retriever, error) {
+	if client == nil {
+		return nil, fmt.Errorf("dynamic client can't be nil")
+	}
+
+	var rc dynamic.ResourceInterface = client.Resource(gvr)
+	if namespace != "" {
+		rc = client.Resource(gvr).Namespace(namespace)
+	}
+
+	return dynamicRetriever{client: rc}, nil
+}
+
+func (d dynamicRetriever) List(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+	return d.client.List(ctx, options)
+}
+func (d dynamicRetriever) Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	return d.client.Watch(ctx, options)
+}
+
+type metadataRetriever struct {
+	client metadata.ResourceInterface
+}
+
+// NewRetrieverFromMetadata returns a Retriever that lists and watches only the metadata of the resources
+// of the GVR using a metadata client, the retrieved objects will be `*metav1.PartialObjectMetadata`. The
+// API server sends only the object metadata (name, labels, annotations, owner references...) instead of
+// the full objects, useful to reduce the bandwidth and memory of the controllers that watch very large
+// objects and only care about their metadata. If the namespace is empty, it will retrieve the resources
+// of all the namespaces (or the cluster scoped resources).
+func NewRetrieverFromMetadata(client metadata.Interface, gvr schema.GroupVersionResource, namespace string) (Retriever, error) {
+	if client == nil {
+		return nil, fmt.Errorf("metadata client can't be nil")
+	}
+
+	var rc metadata.ResourceInterface = client.Resource(gvr)
+	if namespace != "" {
+		rc = client.Resource(gvr).Namespace(namespace)
+	}
+
+	return metadataRetriever{client: rc}, nil
+}
+
+func (m metadataRetriever) List(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+	return m.client.List(ctx, options)
+}
+func (m metadataRetriever) Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	return m.client.Watch(ctx, options)
+}
@@ -3,14 +3,22 @@ package controller_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -112,3 +120,188 @@ func TestRetrieverFromListerWatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRetrieverFromDynamic(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	newWidget := func(ns, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("example.com/v1")
+		u.SetKind("Widget")
+		u.SetNamespace(ns)
+		u.SetName(name)
+		return u
+	}
+
+	tests := map[string]struct {
+		namespace string
+		expNames  []string
+	}{
+		"Without namespace it should retrieve the objects of all the namespaces.": {
+			expNames: []string{"ns1/w1", "ns1/w2", "ns2/w3"},
+		},
+
+		"With namespace it should retrieve the objects of the namespace.": {
+			namespace: "ns1",
+			expNames:  []string{"ns1/w1", "ns1/w2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				newWidget("ns1", "w1"), newWidget("ns1", "w2"), newWidget("ns2", "w3"))
+			ret, err := controller.NewRetrieverFromDynamic(client, gvr, test.namespace)
+			require.NoError(err)
+
+			// Test list.
+			obj, err := ret.List(context.TODO(), metav1.ListOptions{})
+			require.NoError(err)
+			l, ok := obj.(*unstructured.UnstructuredList)
+			require.True(ok)
+			gotNames := []string{}
+			for _, u := range l.Items {
+				gotNames = append(gotNames, u.GetNamespace()+"/"+u.GetName())
+			}
+			assert.ElementsMatch(test.expNames, gotNames)
+
+			// Test watch.
+			w, err := ret.Watch(context.TODO(), metav1.ListOptions{})
+			require.NoError(err)
+			defer w.Stop()
+			_, err = client.Resource(gvr).Namespace("ns1").Create(context.TODO(), newWidget("ns1", "w4"), metav1.CreateOptions{})
+			require.NoError(err)
+			ev := <-w.ResultChan()
+			assert.Equal(watch.Added, ev.Type)
+			u, ok := ev.Object.(*unstructured.Unstructured)
+			require.True(ok)
+			assert.Equal("w4", u.GetName())
+		})
+	}
+}
+
+func TestNewRetrieverFromMetadata(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	newWidget := func(ns, name string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{APIVersion: "example.com/v1", Kind: "Widget"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   ns,
+				Name:        name,
+				Labels:      map[string]string{"app": name},
+				Annotations: map[string]string{"owner": "team-" + name},
+			},
+		}
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(metav1.AddMetaToScheme(scheme))
+	client := metadatafake.NewSimpleMetadataClient(scheme, newWidget("ns1", "w1"), newWidget("ns2", "w2"))
+	ret, err := controller.NewRetrieverFromMetadata(client, gvr, "ns1")
+	require.NoError(err)
+
+	// The handler should receive only the metadata of the objects.
+	var mu sync.Mutex
+	handled := map[string]string{}
+	getHandled := func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		res := map[string]string{}
+		for k, v := range handled {
+			res[k] = v
+		}
+		return res
+	}
+	c, err := controller.New(&controller.Config{
+		Name: "test",
+		Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			m, ok := obj.(*metav1.PartialObjectMetadata)
+			if !ok {
+				return fmt.Errorf("unexpected object type %T", obj)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			handled[m.Namespace+"/"+m.Name] = m.Labels["app"] + " " + m.Annotations["owner"]
+			return nil
+		}),
+		Retriever: ret,
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	_, err = client.Resource(gvr).Namespace("ns1").(metadatafake.MetadataClient).CreateFake(newWidget("ns1", "w3"), metav1.CreateOptions{})
+	require.NoError(err)
+	assert.Eventually(func() bool { return len(getHandled()) == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	exp := map[string]string{
+		"ns1/w1": "w1 team-w1",
+		"ns1/w3": "w3 team-w3",
+	}
+	assert.Equal(exp, getHandled())
+}
+
+func TestNewRetrieverFromListerWatcherWithFieldSelector(t *testing.T) {
+	tests := map[string]struct {
+		fieldSelector    string
+		options          metav1.ListOptions
+		expErr           bool
+		expFieldSelector string
+	}{
+		"An invalid field selector should fail.": {
+			fieldSelector: "spec.nodeName==node1==",
+			expErr:        true,
+		},
+
+		"The field selector should be set on the list and watch calls.": {
+			fieldSelector:    "spec.nodeName=node1",
+			expFieldSelector: "spec.nodeName=node1",
+		},
+
+		"The field selector should be merged with the field selector of the calls.": {
+			fieldSelector:    "spec.nodeName=node1",
+			options:          metav1.ListOptions{FieldSelector: "status.phase=Running"},
+			expFieldSelector: "status.phase=Running,spec.nodeName=node1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var gotList, gotWatch string
+			lw := &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					gotList = options.FieldSelector
+					return testPodList, nil
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					gotWatch = options.FieldSelector
+					return watch.NewFake(), nil
+				},
+			}
+
+			ret, err := controller.NewRetrieverFromListerWatcherWithFieldSelector(lw, test.fieldSelector)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = ret.List(context.TODO(), test.options)
+			require.NoError(err)
+			_, err = ret.Watch(context.TODO(), test.options)
+			require.NoError(err)
+			assert.Equal(test.expFieldSelector, gotList)
+			assert.Equal(test.expFieldSelector, gotWatch)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// RetryAction is the action to take after a failed handling, by default (zero value) the
+// object will be forgotten.
+type RetryAction struct {
+	// Requeue will process the object again after a rate limited delay (check `Config.RateLimiter`).
+	Requeue bool
+	// RequeueAfter will process the object again after the duration, it has precedence over `Requeue`.
+	RequeueAfter time.Duration
+}
+
+// RetryPolicy decides the action to take when the handling of an object fails, attempt is the number
+// of consecutive failed handlings of the object (starts at 1).
+type RetryPolicy func(obj runtime.Object, err error, attempt int) RetryAction
+
+// retryDecider is the internal form of the retry policies, failingFor is the time elapsed since
+// the first of the consecutive failures of the object.
+type retryDecider func(obj runtime.Object, err error, attempt int, failingFor time.Duration) RetryAction
+
+func (r RetryPolicy) decider() retryDecider {
+	return func(obj runtime.Object, err error, attempt int, _ time.Duration) RetryAction {
+		return r(obj, err, attempt)
+	}
+}
+
+// maxRetryDurationDecider retries the objects until they have been failing for the max duration.
+func maxRetryDurationDecider(max time.Duration) retryDecider {
+	return func(_ runtime.Object, _ error, _ int, failingFor time.Duration) RetryAction {
+		if failingFor >= max {
+			return RetryAction{}
+		}
+		return RetryAction{Requeue: true}
+	}
+}
+
+type retryState struct {
+	attempts     int
+	firstFailure time.Time
+}
+
+// newRetryPolicyProcessor returns a processor that on processing errors will use the retry decider
+// to decide if the object is requeued or forgotten.
+//
+// If the processing errored and has been requeued, it will return a `errRequeued` error.
+func newRetryPolicyProcessor(decide retryDecider, rl workqueue.RateLimiter, clk clock.PassiveClock, indexer cache.Indexer, queue blockingQueue, st *stats, next processor) processor {
+	var mu sync.Mutex
+	states := map[string]retryState{}
+
+	forget := func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(states, key)
+		rl.Forget(key)
+	}
+
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+		if err == nil {
+			forget(key)
+			return nil
+		}
+
+		obj, exists, getErr := indexer.GetByKey(key)
+		if getErr != nil || !exists {
+			forget(key)
+			return err
+		}
+
+		mu.Lock()
+		state, ok := states[key]
+		if !ok {
+			state.firstFailure = clk.Now()
+		}
+		state.attempts++
+		states[key] = state
+		mu.Unlock()
+
+		action := decide(obj.(runtime.Object), err, state.attempts, clk.Since(state.firstFailure))
+		switch {
+		case action.RequeueAfter > 0:
+			queue.AddAfter(ctx, key, action.RequeueAfter, true)
+		case action.Requeue:
+			queue.AddAfter(ctx, key, rl.When(key), true)
+		default:
+			forget(key)
+			atomic.AddInt64(&st.forgotten, 1)
+			return err
+		}
+
+		atomic.AddInt64(&st.requeued, 1)
+		return fmt.Errorf("%w: %s", errRequeued, err)
+	})
+}
@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerRetryPolicy(t *testing.T) {
+	nsList := &corev1.NamespaceList{
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "forget"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "requeue"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "requeue-after"}},
+		},
+	}
+
+	// Every object fails except on the second handling of `requeue-after`.
+	policy := func(obj runtime.Object, err error, attempt int) controller.RetryAction {
+		switch obj.(*corev1.Namespace).Name {
+		case "requeue":
+			if attempt < 3 {
+				return controller.RetryAction{Requeue: true}
+			}
+		case "requeue-after":
+			return controller.RetryAction{RequeueAfter: 50 * time.Millisecond}
+		}
+		return controller.RetryAction{}
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	var mu sync.Mutex
+	handled := map[string][]time.Time{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		name := obj.(*corev1.Namespace).Name
+		handled[name] = append(handled[name], time.Now())
+		if name == "requeue-after" && len(handled[name]) == 2 {
+			return nil
+		}
+		return fmt.Errorf("wanted error")
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            newNamespaceRetriever(mc),
+		RetryPolicy:          policy,
+		ProcessingJobRetries: 10, // Ignored when a retry policy is set.
+		Logger:               log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	expStats := controller.Stats{
+		Processed: 6, // forget: 1, requeue: 3, requeue-after: 2.
+		Errored:   5,
+		Requeued:  3,
+		Forgotten: 2,
+	}
+	counters := func() controller.Stats {
+		s := c.Stats()
+		s.ResyncInterval = 0
+		return s
+	}
+	assert.Eventually(func() bool {
+		return counters() == expStats
+	}, 1*time.Second, 5*time.Millisecond)
+
+	// Give some time to check nothing else is retried.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(expStats, counters())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(handled["forget"], 1)
+	assert.Len(handled["requeue"], 3)
+	require.Len(handled["requeue-after"], 2)
+	assert.GreaterOrEqual(int64(handled["requeue-after"][1].Sub(handled["requeue-after"][0])), int64(50*time.Millisecond))
+}
+
+func TestGenericControllerMaxRetryDuration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// Every handling fails and takes 10 minutes of the fake clock.
+	clk := clock.NewFakeClock(time.Now())
+	var mu sync.Mutex
+	calls := 0
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		clk.Step(10 * time.Minute)
+		return fmt.Errorf("wanted error")
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		Retriever:            newNamespaceRetriever(mc),
+		MaxRetryDuration:     time.Hour,
+		ProcessingJobRetries: 2, // Ignored when a max retry duration is set.
+		Clock:                clk,
+		Logger:               log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The first failure is at 10m, the retries stop once it has been failing for 1h (at 70m).
+	expStats := controller.Stats{
+		Processed: 7,
+		Errored:   7,
+		Requeued:  6,
+		Forgotten: 1,
+	}
+	counters := func() controller.Stats {
+		s := c.Stats()
+		s.ResyncInterval = 0
+		return s
+	}
+	assert.Eventually(func() bool {
+		return counters() == expStats
+	}, 2*time.Second, 5*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(expStats, counters())
+}
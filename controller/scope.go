@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RetrieverScope is the scope of the resources returned by a retriever.
+type RetrieverScope struct {
+	// Namespaced is true if the resources are namespaced, false if they are cluster scoped.
+	Namespaced bool
+	// Namespace is the namespace the retriever is restricted to, empty for all the namespaces.
+	// Ignored on cluster scoped resources.
+	Namespace string
+	// Resource is the retrieved resource (e.g `pods`, `deployments.apps`), used to infer
+	// the RBAC rules required by the controller (check `Controller.RequiredRBAC`). Optional.
+	Resource schema.GroupResource
+}
+
+// ScopedRetriever is an optional interface that a Retriever can implement to declare the
+// scope of the retrieved resources. The controller will use it to validate the configuration.
+type ScopedRetriever interface {
+	Retriever
+	Scope() RetrieverScope
+}
+
+type scopedRetriever struct {
+	Retriever
+	scope RetrieverScope
+}
+
+// RetrieverWithScope returns a Retriever that declares the scope of the resources
+// it retrieves (check `ScopedRetriever`).
+func RetrieverWithScope(r Retriever, scope RetrieverScope) ScopedRetriever {
+	return scopedRetriever{Retriever: r, scope: scope}
+}
+
+func (s scopedRetriever) Scope() RetrieverScope { return s.scope }
+
+// validateScope checks that the namespace filter is compatible with the scope of the retriever,
+// otherwise the controller would not receive any event.
+func validateScope(r Retriever, namespace string) error {
+	sr, ok := r.(ScopedRetriever)
+	if !ok {
+		return nil
+	}
+	scope := sr.Scope()
+
+	switch {
+	case namespace == "":
+		return nil
+	case !scope.Namespaced:
+		return fmt.Errorf("namespace filter %q can't be used with a cluster scoped retriever", namespace)
+	case scope.Namespace != "" && scope.Namespace != namespace:
+		return fmt.Errorf("namespace filter %q doesn't match the retriever namespace %q", namespace, scope.Namespace)
+	}
+
+	return nil
+}
+
+// namespaceMatch returns true if the object is on the namespace, an empty namespace matches every object.
+func namespaceMatch(namespace string, obj runtime.Object) bool {
+	if namespace == "" {
+		return true
+	}
+
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+
+	return m.GetNamespace() == namespace
+}
@@ -0,0 +1,134 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerScopeValidation(t *testing.T) {
+	tests := map[string]struct {
+		scope     *controller.RetrieverScope
+		namespace string
+		expErr    bool
+	}{
+		"A retriever without scope should not be validated.": {
+			namespace: "test",
+		},
+
+		"A cluster scoped retriever without namespace filter should not fail.": {
+			scope: &controller.RetrieverScope{Namespaced: false},
+		},
+
+		"A namespace filter on a cluster scoped retriever should fail.": {
+			scope:     &controller.RetrieverScope{Namespaced: false},
+			namespace: "test",
+			expErr:    true,
+		},
+
+		"A namespace filter on a namespaced retriever of all namespaces should not fail.": {
+			scope:     &controller.RetrieverScope{Namespaced: true},
+			namespace: "test",
+		},
+
+		"A namespace filter on a namespaced retriever of the same namespace should not fail.": {
+			scope:     &controller.RetrieverScope{Namespaced: true, Namespace: "test"},
+			namespace: "test",
+		},
+
+		"A namespace filter on a namespaced retriever of a different namespace should fail.": {
+			scope:     &controller.RetrieverScope{Namespaced: true, Namespace: "other"},
+			namespace: "test",
+			expErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var ret controller.Retriever = newNamespaceRetriever(&fake.Clientset{})
+			if test.scope != nil {
+				ret = controller.RetrieverWithScope(ret, *test.scope)
+			}
+
+			_, err := controller.New(&controller.Config{
+				Name:      "test",
+				Handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+				Retriever: ret,
+				Namespace: test.namespace,
+				Logger:    log.Dummy,
+			})
+
+			if test.expErr {
+				assert.True(errors.Is(err, controller.ErrControllerNotValid))
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestGenericControllerNamespaceFilter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pl := &corev1.PodList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "test", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "other", ResourceVersion: "1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "pod-3", Namespace: "test", ResourceVersion: "1"}},
+		},
+	}
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc:  func(_ metav1.ListOptions) (runtime.Object, error) { return pl, nil },
+		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) { return watch.NewFake(), nil },
+	})
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, obj.(*corev1.Pod).Name)
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: controller.RetrieverWithScope(ret, controller.RetrieverScope{Namespaced: true}),
+		Namespace: "test",
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 2
+	}, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch([]string{"pod-1", "pod-3"}, handled)
+}
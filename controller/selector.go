@@ -0,0 +1,34 @@
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// mergeLabelSelector returns the label selector of the options restricted by the selector,
+// both selectors need to match.
+func mergeLabelSelector(options metav1.ListOptions, selector labels.Selector) metav1.ListOptions {
+	if options.LabelSelector == "" {
+		options.LabelSelector = selector.String()
+		return options
+	}
+	options.LabelSelector = options.LabelSelector + "," + selector.String()
+	return options
+}
+
+// newLabelSelectorListerWatcher returns a ListerWatcher that only lists and watches the objects that
+// match the label selector, so the server does the filtering and the unrelated objects never
+// enter the cache.
+func newLabelSelectorListerWatcher(selector labels.Selector, lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return lw.List(mergeLabelSelector(options, selector))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return lw.Watch(mergeLabelSelector(options, selector))
+		},
+	}
+}
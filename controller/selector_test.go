@@ -0,0 +1,100 @@
+package controller_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerLabelSelector(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newNS := func(name string, lbls map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lbls}}
+	}
+	mc := fake.NewSimpleClientset(
+		newNS("ns-0", map[string]string{"app.kubernetes.io/managed-by": "my-operator", "tier": "web"}),
+		newNS("ns-1", map[string]string{"app.kubernetes.io/managed-by": "my-operator", "tier": "db"}),
+		newNS("ns-2", map[string]string{"app.kubernetes.io/managed-by": "other", "tier": "web"}),
+		newNS("ns-3", nil),
+	)
+
+	// The retriever has its own label selector that should be merged with the controller one.
+	var mu sync.Mutex
+	selectors := map[string]bool{}
+	track := func(options metav1.ListOptions) metav1.ListOptions {
+		mu.Lock()
+		defer mu.Unlock()
+		selectors[options.LabelSelector] = true
+		if options.LabelSelector != "" {
+			options.LabelSelector += ","
+		}
+		options.LabelSelector += "tier=web"
+		return options
+	}
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return mc.CoreV1().Namespaces().List(context.TODO(), track(options))
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return mc.CoreV1().Namespaces().Watch(context.TODO(), track(options))
+		},
+	})
+
+	var hmu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		hmu.Lock()
+		defer hmu.Unlock()
+		h := append([]string{}, handled...)
+		sort.Strings(h)
+		return h
+	}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		hmu.Lock()
+		defer hmu.Unlock()
+		handled = append(handled, obj.(*corev1.Namespace).Name)
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       h,
+		Retriever:     ret,
+		Logger:        log.Dummy,
+		DisableResync: true,
+		LabelSelector: labels.SelectorFromSet(labels.Set{"app.kubernetes.io/managed-by": "my-operator"}),
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Only the objects matching both selectors should be handled.
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+	_, err = mc.CoreV1().Namespaces().Create(ctx, newNS("ns-4", map[string]string{"app.kubernetes.io/managed-by": "my-operator", "tier": "web"}), metav1.CreateOptions{})
+	require.NoError(err)
+	assert.Eventually(func() bool { return len(getHandled()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal([]string{"ns-0", "ns-4"}, getHandled())
+
+	// The fake client watches don't filter by labels, so we check the selector sent on the lists and watches.
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(map[string]bool{"app.kubernetes.io/managed-by=my-operator": true}, selectors)
+}
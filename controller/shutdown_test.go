@@ -0,0 +1,112 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerShutdownGracePeriod(t *testing.T) {
+	tests := map[string]struct {
+		gracePeriod    time.Duration
+		handlingTime   time.Duration
+		expMinRunTime  time.Duration
+		expMaxRunTime  time.Duration
+		expInterrupted bool
+	}{
+		"Without grace period the controller should not wait for the in-flight handlings.": {
+			handlingTime:   500 * time.Millisecond,
+			expMaxRunTime:  200 * time.Millisecond,
+			expInterrupted: true,
+		},
+
+		"With a grace period the controller should wait for the in-flight handlings.": {
+			gracePeriod:   2 * time.Second,
+			handlingTime:  300 * time.Millisecond,
+			expMinRunTime: 300 * time.Millisecond,
+			expMaxRunTime: 1 * time.Second,
+		},
+
+		"With a grace period the controller should not wait more than the grace period.": {
+			gracePeriod:    200 * time.Millisecond,
+			handlingTime:   2 * time.Second,
+			expMinRunTime:  200 * time.Millisecond,
+			expMaxRunTime:  1 * time.Second,
+			expInterrupted: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsList, _ := createNamespaceList("testing", 5)
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+
+			var mu sync.Mutex
+			started, finished := 0, 0
+			startedC := make(chan struct{}, 5)
+			h := controller.HandlerFunc(func(context.Context, runtime.Object) error {
+				mu.Lock()
+				started++
+				mu.Unlock()
+				startedC <- struct{}{}
+
+				time.Sleep(test.handlingTime)
+
+				mu.Lock()
+				finished++
+				mu.Unlock()
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:                "test",
+				Handler:             h,
+				Retriever:           newNamespaceRetriever(mc),
+				Logger:              log.Dummy,
+				ConcurrentWorkers:   1,
+				ShutdownGracePeriod: test.gracePeriod,
+			})
+			require.NoError(err)
+
+			resultC := make(chan error, 1)
+			go func() { resultC <- c.Run(ctx) }()
+
+			// Stop the controller while the first object is being handled.
+			select {
+			case <-startedC:
+			case <-time.After(1 * time.Second):
+				require.FailNow("timeout waiting for the handling to start")
+			}
+			t0 := time.Now()
+			cancel()
+			require.NoError(<-resultC)
+			runTime := time.Since(t0)
+
+			assert.GreaterOrEqual(int64(runTime), int64(test.expMinRunTime))
+			assert.Less(int64(runTime), int64(test.expMaxRunTime))
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expInterrupted, finished == 0)
+			if test.gracePeriod > 0 {
+				// The queued objects should not be handled after the stop.
+				assert.Equal(1, started)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// snapshotBlockingQueue tracks the pending keys of the queue (queued or waiting to be queued after a
+// delay), so they can be snapshotted (e.g handed over to a new process on upgrades).
+type snapshotBlockingQueue struct {
+	blockingQueue
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+func newSnapshotBlockingQueue(queue blockingQueue) *snapshotBlockingQueue {
+	return &snapshotBlockingQueue{
+		blockingQueue: queue,
+		pending:       map[string]bool{},
+	}
+}
+
+func (s *snapshotBlockingQueue) setPending(item interface{}, pending bool) {
+	key, ok := item.(string)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pending {
+		s.pending[key] = true
+	} else {
+		delete(s.pending, key)
+	}
+}
+
+func (s *snapshotBlockingQueue) Add(ctx context.Context, item interface{}) {
+	s.setPending(item, true)
+	s.blockingQueue.Add(ctx, item)
+}
+
+func (s *snapshotBlockingQueue) AddAfter(ctx context.Context, item interface{}, duration time.Duration, requeue bool) {
+	s.setPending(item, true)
+	s.blockingQueue.AddAfter(ctx, item, duration, requeue)
+}
+
+func (s *snapshotBlockingQueue) Requeue(ctx context.Context, item interface{}) error {
+	s.setPending(item, true)
+	err := s.blockingQueue.Requeue(ctx, item)
+	if err != nil {
+		s.setPending(item, false)
+	}
+	return err
+}
+
+func (s *snapshotBlockingQueue) Get(ctx context.Context) (interface{}, bool) {
+	item, shutdown := s.blockingQueue.Get(ctx)
+	if !shutdown {
+		s.setPending(item, false)
+	}
+	return item, shutdown
+}
+
+// snapshot returns the sorted pending keys.
+func (s *snapshotBlockingQueue) snapshot() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.pending))
+	for k := range s.pending {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SnapshotQueue returns the pending object keys of the queue, including the ones waiting to be requeued
+// after a delay. Used to hand over the pending work to a new controller (check `Config.InitialQueue`).
+func (g *Generic) SnapshotQueue() []string {
+	return g.snapshotQueue.snapshot()
+}
@@ -0,0 +1,91 @@
+package controller_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerSnapshotQueue(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	nsList, _ := createNamespaceList("testing", 4)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	// The old controller blocks its only worker on the first object so the rest wait on the queue.
+	oldCtx, oldCancel := context.WithCancel(context.Background())
+	defer oldCancel()
+	blockC := make(chan struct{})
+	defer close(blockC)
+	blocked := ""
+	var mu sync.Mutex
+	oldC, err := controller.New(&controller.Config{
+		Name: "test-old",
+		Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			mu.Lock()
+			blocked = obj.(*corev1.Namespace).Name
+			mu.Unlock()
+			<-blockC
+			return nil
+		}),
+		Retriever:         newNamespaceRetriever(mc),
+		Logger:            log.Dummy,
+		ConcurrentWorkers: 1,
+	})
+	require.NoError(err)
+	go func() { _ = oldC.Run(oldCtx) }()
+	assert.Eventually(func() bool { return len(oldC.SnapshotQueue()) == 3 }, 1*time.Second, 5*time.Millisecond)
+
+	// The snapshot should have the pending keys, not the one being handled.
+	snapshot := oldC.SnapshotQueue()
+	mu.Lock()
+	assert.NotContains(snapshot, blocked)
+	mu.Unlock()
+	oldCancel()
+
+	// The new controller only handles the changes after starting, so it only handles the seeded keys.
+	var handledMu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		handledMu.Lock()
+		defer handledMu.Unlock()
+		res := append([]string{}, handled...)
+		sort.Strings(res)
+		return res
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newC, err := controller.New(&controller.Config{
+		Name: "test-new",
+		Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+			handledMu.Lock()
+			defer handledMu.Unlock()
+			handled = append(handled, obj.(*corev1.Namespace).Name)
+			return nil
+		}),
+		Retriever:    newNamespaceRetriever(mc),
+		Logger:       log.Dummy,
+		WatchOnly:    true,
+		InitialQueue: snapshot,
+	})
+	require.NoError(err)
+	go func() { _ = newC.Run(ctx) }()
+
+	assert.Eventually(func() bool { return len(getHandled()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(snapshot, getHandled())
+	assert.Empty(newC.SnapshotQueue())
+}
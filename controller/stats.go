@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the internal counters of a controller. Unlike the metrics these are
+// local to the controller instance and can be reset, useful for tests and tooling.
+type Stats struct {
+	// Processed is the number of times an object has been processed (successfully or not).
+	Processed int64
+	// Errored is the number of times the processing of an object errored.
+	Errored int64
+	// Requeued is the number of times an object has been requeued to retry its processing.
+	Requeued int64
+	// Forgotten is the number of objects that have been dropped after reaching the max retries.
+	Forgotten int64
+	// QueueLength is the current number of objects waiting to be processed.
+	QueueLength int
+	// ResyncInterval is the current resync interval (could change if the resync is adaptive).
+	ResyncInterval time.Duration
+}
+
+// EventCounts are the number of raw events received from the informer, before any filtering
+// or enqueueing. Useful to know if the events are not arriving or are being filtered out.
+type EventCounts struct {
+	// Add is the number of received add events.
+	Add int64
+	// Update is the number of received update events (resyncs included).
+	Update int64
+	// Delete is the number of received delete events.
+	Delete int64
+}
+
+// stats is the concurrency safe implementation of the controller internal counters.
+type stats struct {
+	processed int64
+	errored   int64
+	requeued  int64
+	forgotten int64
+
+	addEvents    int64
+	updateEvents int64
+	deleteEvents int64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Processed: atomic.LoadInt64(&s.processed),
+		Errored:   atomic.LoadInt64(&s.errored),
+		Requeued:  atomic.LoadInt64(&s.requeued),
+		Forgotten: atomic.LoadInt64(&s.forgotten),
+	}
+}
+
+func (s *stats) reset() {
+	atomic.StoreInt64(&s.processed, 0)
+	atomic.StoreInt64(&s.errored, 0)
+	atomic.StoreInt64(&s.requeued, 0)
+	atomic.StoreInt64(&s.forgotten, 0)
+	atomic.StoreInt64(&s.addEvents, 0)
+	atomic.StoreInt64(&s.updateEvents, 0)
+	atomic.StoreInt64(&s.deleteEvents, 0)
+}
+
+func (s *stats) eventCounts() EventCounts {
+	return EventCounts{
+		Add:    atomic.LoadInt64(&s.addEvents),
+		Update: atomic.LoadInt64(&s.updateEvents),
+		Delete: atomic.LoadInt64(&s.deleteEvents),
+	}
+}
+
+// newStatsProcessor returns a processor that counts the processed and errored objects.
+func newStatsProcessor(st *stats, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		err := next.Process(ctx, key)
+		atomic.AddInt64(&st.processed, 1)
+		if err != nil {
+			atomic.AddInt64(&st.errored, 1)
+		}
+		return err
+	})
+}
+
+// statsBlockingQueue is a wrapper for a queue that counts the requeued and forgotten objects.
+type statsBlockingQueue struct {
+	blockingQueue
+	st *stats
+}
+
+func newStatsBlockingQueue(st *stats, queue blockingQueue) blockingQueue {
+	return statsBlockingQueue{blockingQueue: queue, st: st}
+}
+
+func (s statsBlockingQueue) Requeue(ctx context.Context, item interface{}) error {
+	err := s.blockingQueue.Requeue(ctx, item)
+	switch {
+	case err == nil:
+		atomic.AddInt64(&s.st.requeued, 1)
+	case errors.Is(err, errMaxRetriesReached):
+		atomic.AddInt64(&s.st.forgotten, 1)
+	}
+	return err
+}
+
+// Stats returns a snapshot of the controller internal counters.
+func (g *Generic) Stats() Stats {
+	s := g.stats.snapshot()
+	s.QueueLength = g.queue.Len(context.Background())
+	s.ResyncInterval = g.cfg.ResyncInterval
+	if g.resyncer != nil {
+		s.ResyncInterval = g.resyncer.currentInterval()
+	}
+	return s
+}
+
+// EventCounts returns the number of raw events received from the informer.
+func (g *Generic) EventCounts() EventCounts {
+	return g.stats.eventCounts()
+}
+
+// ResetStats resets the controller internal counters (event counts included).
+func (g *Generic) ResetStats() {
+	g.stats.reset()
+}
@@ -0,0 +1,135 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerStats(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 10)
+
+	tests := map[string]struct {
+		nsList   *corev1.NamespaceList
+		failing  map[string]bool
+		retries  int
+		expStats controller.Stats
+	}{
+		"Processing objects without errors should only count the processed objects.": {
+			nsList:   nsList,
+			retries:  2,
+			expStats: controller.Stats{Processed: 10},
+		},
+
+		"Processing objects with errors should count the errors, requeues and forgotten objects.": {
+			nsList:  nsList,
+			failing: map[string]bool{"testing-1": true, "testing-5": true, "testing-8": true},
+			retries: 2,
+			expStats: controller.Stats{
+				Processed: 16, // 10 + 3 failing * 2 retries.
+				Errored:   9,  // 3 failing * (1 + 2 retries).
+				Requeued:  6,  // 3 failing * 2 retries.
+				Forgotten: 3,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, test.nsList)
+			h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				if test.failing[obj.(*corev1.Namespace).Name] {
+					return fmt.Errorf("wanted error")
+				}
+				return nil
+			})
+
+			c, err := controller.New(&controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            newNamespaceRetriever(mc),
+				ProcessingJobRetries: test.retries,
+				Logger:               log.Dummy,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			// We only want to check the counters.
+			counters := func() controller.Stats {
+				s := c.Stats()
+				s.ResyncInterval = 0
+				return s
+			}
+
+			// Wait until everything has been processed.
+			assert.Eventually(func() bool {
+				return counters() == test.expStats
+			}, 1*time.Second, 5*time.Millisecond)
+			assert.Equal(test.expStats, counters())
+
+			// Reset should set the counters to zero.
+			c.ResetStats()
+			assert.Equal(controller.Stats{}, counters())
+		})
+	}
+}
+
+func TestGenericControllerStatsSample(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const interval = 20 * time.Millisecond
+
+	nsList, _ := createNamespaceList("testing", 5)
+	mc := &fake.Clientset{}
+	onKubeClientListNamespaceReturn(mc, nsList)
+
+	samplesC := make(chan controller.Stats, 100)
+	c, err := controller.New(&controller.Config{
+		Name:                "test",
+		Handler:             controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:           newNamespaceRetriever(mc),
+		Logger:              log.Dummy,
+		OnStatsSample:       func(s controller.Stats) { samplesC <- s },
+		StatsSampleInterval: interval,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Wait until we have a sample with all the objects processed.
+	times := []time.Time{}
+	var last controller.Stats
+	timeoutC := time.After(1 * time.Second)
+	for last.Processed < 5 || len(times) < 3 {
+		select {
+		case last = <-samplesC:
+			times = append(times, time.Now())
+		case <-timeoutC:
+			require.FailNow("timeout waiting for stats samples")
+		}
+	}
+
+	assert.Equal(int64(5), last.Processed)
+	assert.Equal(controller.DefaultConfig().ResyncInterval, last.ResyncInterval)
+	for i := 1; i < len(times); i++ {
+		assert.GreaterOrEqual(int64(times[i].Sub(times[i-1])), int64(interval*9/10))
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+type timelineCtxKey struct{}
+
+// timelineStep is a handling sub-step marker.
+type timelineStep struct {
+	name string
+	at   time.Time
+}
+
+// timeline is the timeline of the sub-steps of a single object handling.
+type timeline struct {
+	mu    sync.Mutex
+	start time.Time
+	steps []timelineStep
+}
+
+func (t *timeline) mark(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps = append(t.steps, timelineStep{name: name, at: time.Now()})
+}
+
+// String returns the timeline with the duration of each step, a step lasts until the next one starts.
+func (t *timeline) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := time.Now()
+	steps := make([]string, 0, len(t.steps))
+	for i, s := range t.steps {
+		next := end
+		if i+1 < len(t.steps) {
+			next = t.steps[i+1].at
+		}
+		steps = append(steps, fmt.Sprintf("%s: +%s (%s)", s.name, s.at.Sub(t.start), next.Sub(s.at)))
+	}
+
+	return strings.Join(steps, ", ")
+}
+
+// Step marks the start of a handling sub-step (the previous step ends when a new one starts), the controller
+// collects the steps into the handling timeline, that will be logged when the handling is slow
+// (check `Config.SlowHandlingThreshold`), and traced as child spans of the handling span (check `Config.TraceSteps`).
+// It's safe to call it when the timeline and the step tracing are disabled.
+func Step(ctx context.Context, name string) {
+	if ss, ok := ctx.Value(stepSpansCtxKey{}).(*stepSpans); ok {
+		ss.start(name)
+	}
+	if t, ok := ctx.Value(timelineCtxKey{}).(*timeline); ok {
+		t.mark(name)
+	}
+}
+
+// newTimelineProcessor returns a processor that collects the handling sub-steps timeline and
+// logs it if the processing takes more than the threshold.
+func newTimelineProcessor(threshold time.Duration, logger log.Logger, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		t := &timeline{start: time.Now()}
+		err := next.Process(context.WithValue(ctx, timelineCtxKey{}, t), key)
+
+		if d := time.Since(t.start); d > threshold {
+			logger.WithKV(log.KV{"object-key": key, "duration": d.String()}).
+				Warningf("slow object handling, timeline: %s", t)
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,94 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerSlowHandlingTimeline(t *testing.T) {
+	nsList, _ := createNamespaceList("testing", 2)
+
+	tests := map[string]struct {
+		slow       map[string]bool
+		expSlowLog []string
+	}{
+		"Fast handlings should not log the timeline.": {
+			slow: map[string]bool{},
+		},
+
+		"Handlings exceeding the threshold should log the recorded sub-steps timeline.": {
+			slow:       map[string]bool{"testing-1": true},
+			expSlowLog: []string{"fetch-deps: +", "update: +"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mc := &fake.Clientset{}
+			onKubeClientListNamespaceReturn(mc, nsList)
+			handledC := make(chan struct{}, len(nsList.Items))
+			h := controller.HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+				defer func() { handledC <- struct{}{} }()
+				controller.Step(ctx, "fetch-deps")
+				if test.slow[obj.(*corev1.Namespace).Name] {
+					time.Sleep(60 * time.Millisecond)
+				}
+				controller.Step(ctx, "update")
+				return nil
+			})
+
+			logger := &warningLogger{Logger: log.Dummy}
+			c, err := controller.New(&controller.Config{
+				Name:                  "test",
+				Handler:               h,
+				Retriever:             newNamespaceRetriever(mc),
+				Logger:                logger,
+				MetricsRecorder:       controller.DummyMetricsRecorder,
+				SlowHandlingThreshold: 50 * time.Millisecond,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			for range nsList.Items {
+				select {
+				case <-handledC:
+				case <-time.After(1 * time.Second):
+					require.Fail("timeout waiting for handling")
+				}
+			}
+			// Give time to log after the handling.
+			time.Sleep(10 * time.Millisecond)
+
+			logger.mu.Lock()
+			defer logger.mu.Unlock()
+			if len(test.expSlowLog) == 0 {
+				assert.Empty(logger.warnings)
+				return
+			}
+			require.Len(logger.warnings, 1)
+			for _, exp := range test.expSlowLog {
+				assert.Contains(logger.warnings[0], exp)
+			}
+		})
+	}
+}
+
+func TestStepWithoutTimeline(t *testing.T) {
+	// Should not panic when the timeline is not enabled.
+	controller.Step(context.Background(), "test")
+}
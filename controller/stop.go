@@ -0,0 +1,35 @@
+package controller
+
+import "context"
+
+// Stop stops the controller without cancelling the `Run` context, so a controller can be stopped
+// independently of the others that share the same context. `Run` returns once stopped, a stopped
+// controller can't be run again. It's idempotent and safe to call before or after `Run`.
+func (g *Generic) Stop() {
+	g.stopOnce.Do(func() { close(g.stopC) })
+	g.release()
+}
+
+// release releases the controller name and unregisters its queue metrics, so a new controller
+// with the same name can be created.
+func (g *Generic) release() {
+	g.releaseOnce.Do(func() {
+		g.releaseName()
+		if u, ok := g.metrics.(ResourceQueueLengthFuncUnregisterer); ok {
+			u.UnregisterResourceQueueLengthFunc(g.cfg.Name)
+		}
+	})
+}
+
+// stoppable returns a context that is cancelled when the controller is stopped (check `Stop`).
+func (g *Generic) stoppable(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-g.stopC:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
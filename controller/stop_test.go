@@ -0,0 +1,81 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerStop(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Both controllers share the same context.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsl := &corev1.NamespaceList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}
+	var mu sync.Mutex
+	handled := map[string][]string{}
+	getHandled := func(name string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled[name]...)
+	}
+	newController := func(name string) (*controller.Generic, *watch.FakeWatcher) {
+		ret, w := newFakeNamespaceRetriever(nsl)
+		c, err := controller.New(&controller.Config{
+			Name: name,
+			Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handled[name] = append(handled[name], obj.(*corev1.Namespace).Name)
+				return nil
+			}),
+			Retriever: ret,
+			Logger:    log.Dummy,
+		})
+		require.NoError(err)
+		return c, w
+	}
+
+	c1, w1 := newController("test-1")
+	c2, w2 := newController("test-2")
+	result1C := make(chan error, 1)
+	go func() { result1C <- c1.Run(ctx) }()
+	go func() { _ = c2.Run(ctx) }()
+	assert.Eventually(func() bool { return c1.Ready() == nil && c2.Ready() == nil }, 1*time.Second, 5*time.Millisecond)
+
+	// Stopping a controller should end its run without stopping the other.
+	c1.Stop()
+	c1.Stop()
+	select {
+	case err := <-result1C:
+		assert.NoError(err)
+	case <-time.After(1 * time.Second):
+		require.FailNow("timeout waiting for the stopped controller run")
+	}
+	assert.Error(c1.Ready())
+
+	// The stopped controller informer should not watch anymore.
+	assert.Eventually(func() bool { return w1.IsStopped() }, 1*time.Second, 5*time.Millisecond)
+	w2.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "2"}})
+	assert.Eventually(func() bool { return len(getHandled("test-2")) == 1 }, 1*time.Second, 5*time.Millisecond)
+	assert.Empty(getHandled("test-1"))
+	assert.NoError(ctx.Err())
+
+	// Stopping after the run ended should be safe, and a stopped controller can't run again.
+	c1.Stop()
+	assert.Error(c1.Run(ctx))
+}
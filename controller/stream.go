@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultEventStreamBuffer = 100
+
+// EventStream streams the results of the objects processed by a controller to the subscribers
+// over HTTP using server-sent events, useful for live debugging of a running controller. Every
+// event is a JSON `ReportEntry`.
+//
+// The subscribers that don't keep up with the stream (their buffer is full) are dropped so
+// they never block the processing.
+type EventStream struct {
+	buffer int
+
+	mu          sync.Mutex
+	subscribers map[chan ReportEntry]struct{}
+}
+
+// NewEventStream returns a new EventStream, buffer is the number of events buffered for every
+// subscriber before it's dropped, if 0 it will use 100.
+func NewEventStream(buffer int) *EventStream {
+	if buffer <= 0 {
+		buffer = defaultEventStreamBuffer
+	}
+	return &EventStream{
+		buffer:      buffer,
+		subscribers: map[chan ReportEntry]struct{}{},
+	}
+}
+
+func (s *EventStream) subscribe() chan ReportEntry {
+	c := make(chan ReportEntry, s.buffer)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[c] = struct{}{}
+	return c
+}
+
+func (s *EventStream) unsubscribe(c chan ReportEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[c]; ok {
+		delete(s.subscribers, c)
+		close(c)
+	}
+}
+
+func (s *EventStream) publish(e ReportEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.subscribers {
+		select {
+		case c <- e:
+		default:
+			// Slow subscriber, drop it.
+			delete(s.subscribers, c)
+			close(c)
+		}
+	}
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c := s.subscribe()
+	defer s.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-c:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: processed\ndata: %s\n\n", data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// newEventStreamProcessor returns a processor that publishes the result of every processing on the stream.
+func newEventStreamProcessor(stream *EventStream, next processor) processor {
+	return processorFunc(func(ctx context.Context, key string) error {
+		start := time.Now()
+		err := next.Process(ctx, key)
+
+		e := ReportEntry{Key: key, Result: ReportResultSuccess, Duration: time.Since(start)}
+		if err != nil {
+			e.Result = ReportResultError
+			e.Error = err.Error()
+		}
+		stream.publish(e)
+
+		return err
+	})
+}
+
+var _ http.Handler = &EventStream{}
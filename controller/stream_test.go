@@ -0,0 +1,95 @@
+package controller_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestEventStream(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	ret, w := newFakeNamespaceRetriever(nsList)
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		if obj.(*corev1.Namespace).Name == "testing-1" {
+			return fmt.Errorf("wanted error")
+		}
+		return nil
+	})
+
+	// Subscribe to the stream.
+	stream := controller.NewEventStream(0)
+	srv := httptest.NewServer(stream)
+	defer srv.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	eventsC := make(chan controller.ReportEntry, 10)
+	go func() {
+		s := bufio.NewScanner(resp.Body)
+		for s.Scan() {
+			data := strings.TrimPrefix(s.Text(), "data: ")
+			if data == s.Text() {
+				continue
+			}
+			var e controller.ReportEntry
+			if json.Unmarshal([]byte(data), &e) == nil {
+				eventsC <- e
+			}
+		}
+	}()
+	nextEvent := func() controller.ReportEntry {
+		select {
+		case e := <-eventsC:
+			return e
+		case <-time.After(1 * time.Second):
+			require.FailNow("timeout waiting for the stream event")
+		}
+		return controller.ReportEntry{}
+	}
+
+	c, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       h,
+		Retriever:     ret,
+		EventStream:   stream,
+		Logger:        log.Dummy,
+		DisableResync: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// The events should be received as the objects are processed.
+	e := nextEvent()
+	assert.Equal("testing-0", e.Key)
+	assert.Equal(controller.ReportResultSuccess, e.Result)
+
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "testing-1", ResourceVersion: "2"}})
+	e = nextEvent()
+	assert.Equal("testing-1", e.Key)
+	assert.Equal(controller.ReportResultError, e.Result)
+	assert.Equal("wanted error", e.Error)
+}
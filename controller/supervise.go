@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Supervise runs the controller created by the factory and restarts it when its run fails (e.g a fatal
+// error), creating a new controller every time so the informers are rebuilt. The restarts wait the
+// backoff: starting with `Duration`, multiplied by `Factor` on every restart (capped at `Cap` if set)
+// and with the `Jitter`. `Steps` is the max number of restarts, with 0 it will restart forever.
+// The factory errors are restarted in the same way.
+//
+// Supervise blocks until the context is done (returns nil) or the max restarts are reached (returns
+// the last error).
+func Supervise(ctx context.Context, factory func() (Controller, error), backoff wait.Backoff) error {
+	run := func() error {
+		c, err := factory()
+		if err != nil {
+			return fmt.Errorf("could not create controller: %w", err)
+		}
+		return c.Run(ctx)
+	}
+
+	delay := backoff.Duration
+	for restarts := 0; ; restarts++ {
+		err := run()
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+
+		if backoff.Steps > 0 && restarts >= backoff.Steps {
+			return fmt.Errorf("controller failed after %d restarts: %w", restarts, err)
+		}
+
+		d := delay
+		if backoff.Jitter > 0 {
+			d = wait.Jitter(d, backoff.Jitter)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d):
+		}
+
+		if backoff.Factor > 0 {
+			delay = time.Duration(float64(delay) * backoff.Factor)
+		}
+		if backoff.Cap > 0 && delay > backoff.Cap {
+			delay = backoff.Cap
+		}
+	}
+}
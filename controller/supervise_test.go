@@ -0,0 +1,100 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+func TestSupervise(t *testing.T) {
+	errWanted := fmt.Errorf("wanted error")
+
+	tests := map[string]struct {
+		failRuns     int
+		failFactory  bool
+		steps        int
+		expErr       bool
+		expRuns      int
+		expMinGaps   []time.Duration
+		expCancelled bool
+	}{
+		"A controller that fails should be restarted with backoff until it runs successfully.": {
+			failRuns:     2,
+			expRuns:      3,
+			expMinGaps:   []time.Duration{20 * time.Millisecond, 40 * time.Millisecond},
+			expCancelled: true,
+		},
+
+		"A controller that fails more than the max restarts should return the error.": {
+			failRuns: 10,
+			steps:    2,
+			expRuns:  3,
+			expErr:   true,
+		},
+
+		"The factory errors should be restarted too.": {
+			failRuns:    10,
+			failFactory: true,
+			steps:       2,
+			expRuns:     3,
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			runs := []time.Time{}
+			factory := func() (controller.Controller, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				runs = append(runs, time.Now())
+				run := len(runs)
+				if test.failFactory {
+					return nil, errWanted
+				}
+
+				return runFuncController{run: func(ctx context.Context) error {
+					if run <= test.failRuns {
+						return errWanted
+					}
+					// Successful run, stop when running.
+					cancel()
+					<-ctx.Done()
+					return nil
+				}}, nil
+			}
+
+			err := controller.Supervise(ctx, factory, wait.Backoff{
+				Duration: 20 * time.Millisecond,
+				Factor:   2,
+				Steps:    test.steps,
+			})
+
+			if test.expErr {
+				assert.ErrorIs(err, errWanted)
+			} else {
+				assert.NoError(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Len(runs, test.expRuns)
+			for i, gap := range test.expMinGaps {
+				assert.GreaterOrEqual(int64(runs[i+1].Sub(runs[i])), int64(gap))
+			}
+		})
+	}
+}
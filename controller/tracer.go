@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer knows how to trace the handlings of a controller (e.g OpenTelemetry, check `tracing/opentelemetry`).
+type Tracer interface {
+	// StartSpan starts a span, the returned context carries the span so the spans started with it
+	// are its children.
+	StartSpan(ctx context.Context, name string, opts SpanOptions) (context.Context, Span)
+	// TraceID returns the trace ID of the span carried by the context, empty if there is no span.
+	TraceID(ctx context.Context) string
+	// ContextWithBaggage returns the context with the members of the baggage (W3C baggage format) added
+	// to the baggage already present on the context.
+	ContextWithBaggage(ctx context.Context, baggage string) (context.Context, error)
+}
+
+// SpanOptions are the options of a span.
+type SpanOptions struct {
+	// Attributes are the attributes of the span.
+	Attributes []SpanAttribute
+	// Links are the links to other spans (check `Span.Link`).
+	Links []SpanLink
+	// StartTime is the start time of the span, if zero the span starts now.
+	StartTime time.Time
+}
+
+// SpanAttribute is an attribute of a span, the values are strings or bools.
+type SpanAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanLink identifies a span so other spans can be linked to it, its value depends on the tracer.
+type SpanLink interface{}
+
+// Span is a started span.
+type Span interface {
+	// End ends the span.
+	End()
+	// RecordError records the error on the span and marks the span as failed.
+	RecordError(err error)
+	// Link returns the link to the span.
+	Link() SpanLink
+}
+
+// DummyTracer is a dummy tracer.
+var DummyTracer = dummyTracer(0)
+var _ Tracer = DummyTracer
+
+type dummyTracer int
+
+func (dummyTracer) StartSpan(ctx context.Context, _ string, _ SpanOptions) (context.Context, Span) {
+	return ctx, dummySpan(0)
+}
+func (dummyTracer) TraceID(context.Context) string { return "" }
+func (dummyTracer) ContextWithBaggage(ctx context.Context, _ string) (context.Context, error) {
+	return ctx, nil
+}
+
+type dummySpan int
+
+func (dummySpan) End()              {}
+func (dummySpan) RecordError(error) {}
+func (dummySpan) Link() SpanLink    { return nil }
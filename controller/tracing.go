@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// lifecycleLinks keeps the last handling span of every object UID, so the handling spans of the
+// same object are linked across its lifecycle (creation, updates and deletion).
+type lifecycleLinks struct {
+	mu   sync.Mutex
+	last map[types.UID]SpanLink
+}
+
+func newLifecycleLinks() *lifecycleLinks {
+	return &lifecycleLinks{last: map[types.UID]SpanLink{}}
+}
+
+// links returns the links to the previous handling span of the object.
+func (l *lifecycleLinks) links(uid types.UID) []SpanLink {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, ok := l.last[uid]
+	if !ok {
+		return nil
+	}
+	return []SpanLink{prev}
+}
+
+// set stores the last handling span of the object.
+func (l *lifecycleLinks) set(uid types.UID, link SpanLink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.last[uid] = link
+}
+
+// forget removes the last handling span of a deleted object, the object can be a tombstone.
+func (l *lifecycleLinks) forget(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.last, m.GetUID())
+}
+
+type stepSpansCtxKey struct{}
+
+// stepSpans creates a child span of the handling span for every handling sub-step (check `Step`),
+// a step span lasts until the next step starts or the handling ends.
+type stepSpans struct {
+	tracer  Tracer
+	ctx     context.Context
+	mu      sync.Mutex
+	current Span
+}
+
+func (s *stepSpans) start(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil {
+		s.current.End()
+	}
+	_, s.current = s.tracer.StartSpan(s.ctx, name, SpanOptions{})
+}
+
+func (s *stepSpans) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil {
+		s.current.End()
+		s.current = nil
+	}
+}
+
+// newTracingHandler returns a handler that wraps every handling with a span named after the controller,
+// the span context is passed to the handler so the downstream calls are correlated with the handling.
+// If lifecycle links are used the span will be linked to the previous handling span of the same object.
+// If steps are traced, every handling sub-step will be a child span of the handling span.
+//
+// Only a fraction (sample rate) of the handlings is traced (head-based sampling), the failed handlings that were
+// not sampled are always traced with a span created once the handling ends, these spans don't have
+// children (the handler didn't receive a span context).
+func newTracingHandler(name string, tracer Tracer, sampleRate float64, lifecycle *lifecycleLinks, steps, deletes bool, next Handler) Handler {
+	return ResultHandlerFunc(func(ctx context.Context, obj runtime.Object) (Result, error) {
+		attrs := []SpanAttribute{{Key: "kooper.controller", Value: name}}
+		var uid types.UID
+		if m, err := meta.Accessor(obj); err == nil {
+			uid = m.GetUID()
+			attrs = append(attrs,
+				SpanAttribute{Key: "kooper.object.namespace", Value: m.GetNamespace()},
+				SpanAttribute{Key: "kooper.object.name", Value: m.GetName()},
+				SpanAttribute{Key: "kooper.object.resource_version", Value: m.GetResourceVersion()},
+			)
+			if lifecycle != nil {
+				attrs = append(attrs, SpanAttribute{Key: "kooper.object.uid", Value: string(uid)})
+			}
+		}
+		if deletes {
+			attrs = append(attrs, SpanAttribute{Key: "kooper.object.deleted", Value: true})
+		}
+
+		trackLifecycle := lifecycle != nil && uid != ""
+		if trackLifecycle && deletes {
+			// Once the deletion is handled the object lifecycle ends, whether the handling was traced or not.
+			defer lifecycle.forget(obj)
+		}
+		startSpan := func(ctx context.Context, start time.Time) (context.Context, Span) {
+			opts := SpanOptions{Attributes: attrs, StartTime: start}
+			if trackLifecycle {
+				opts.Links = lifecycle.links(uid)
+			}
+			ctx, span := tracer.StartSpan(ctx, name, opts)
+			if trackLifecycle {
+				lifecycle.set(uid, span.Link())
+			}
+			return ctx, span
+		}
+
+		if sampleRate < 1 && rand.Float64() >= sampleRate {
+			start := time.Now()
+			res, err := handleWithResult(ctx, next, obj)
+			if err != nil {
+				_, span := startSpan(ctx, start)
+				span.RecordError(err)
+				span.End()
+			}
+			return res, err
+		}
+
+		ctx, span := startSpan(ctx, time.Time{})
+		defer span.End()
+		if steps {
+			ss := &stepSpans{tracer: tracer, ctx: ctx}
+			defer ss.end()
+			ctx = context.WithValue(ctx, stepSpansCtxKey{}, ss)
+		}
+
+		res, err := handleWithResult(ctx, next, obj)
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		return res, err
+	})
+}
@@ -0,0 +1,358 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+	"github.com/adevjoe/kooper/v2/tracing/opentelemetry"
+)
+
+func TestGenericControllerTracing(t *testing.T) {
+	tests := map[string]struct {
+		handlerErr error
+		expStatus  codes.Code
+	}{
+		"A successful handling should end the span without error status.": {
+			expStatus: codes.Unset,
+		},
+
+		"A failed handling should record the error on the span.": {
+			handlerErr: fmt.Errorf("wanted error"),
+			expStatus:  codes.Error,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsl := &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items: []corev1.Namespace{
+					{ObjectMeta: metav1.ObjectMeta{Name: "test-ns", ResourceVersion: "42"}},
+				},
+			}
+			ret, _ := newFakeNamespaceRetriever(nsl)
+
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+			var mu sync.Mutex
+			var handlingSpan trace.SpanContext
+			c, err := controller.New(&controller.Config{
+				Name: "test",
+				Handler: controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+					mu.Lock()
+					defer mu.Unlock()
+					handlingSpan = trace.SpanContextFromContext(ctx)
+					return test.handlerErr
+				}),
+				Retriever: ret,
+				Logger:    log.Dummy,
+				Tracer:    opentelemetry.New(opentelemetry.Config{TracerProvider: tp}),
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool { return len(sr.Ended()) == 1 }, 1*time.Second, 5*time.Millisecond)
+			spans := sr.Ended()
+			require.Len(spans, 1)
+			span := spans[0]
+
+			assert.Equal("test", span.Name())
+			assert.Equal(test.expStatus, span.Status().Code)
+			assert.ElementsMatch([]attribute.KeyValue{
+				attribute.String("kooper.controller", "test"),
+				attribute.String("kooper.object.namespace", ""),
+				attribute.String("kooper.object.name", "test-ns"),
+				attribute.String("kooper.object.resource_version", "42"),
+			}, span.Attributes())
+
+			// The handler should receive the span context.
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(span.SpanContext(), handlingSpan)
+		})
+	}
+}
+
+func TestGenericControllerTraceObjectLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newNS := func(rv string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns", UID: "test-uid", ResourceVersion: rv}}
+	}
+	nsl := &corev1.NamespaceList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    []corev1.Namespace{*newNS("1")},
+	}
+	ret, w := newFakeNamespaceRetriever(nsl)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	h := controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })
+	c, err := controller.New(&controller.Config{
+		Name:                 "test",
+		Handler:              h,
+		DeleteHandler:        h,
+		Retriever:            ret,
+		Logger:               log.Dummy,
+		Tracer:               opentelemetry.New(opentelemetry.Config{TracerProvider: tp}),
+		TraceObjectLifecycle: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Create, update and delete the object.
+	assert.Eventually(func() bool { return len(sr.Ended()) == 1 }, 1*time.Second, 5*time.Millisecond)
+	w.Modify(newNS("2"))
+	assert.Eventually(func() bool { return len(sr.Ended()) == 2 }, 1*time.Second, 5*time.Millisecond)
+	w.Delete(newNS("3"))
+	assert.Eventually(func() bool { return len(sr.Ended()) == 3 }, 1*time.Second, 5*time.Millisecond)
+
+	// Every span should be linked to the previous span of the object.
+	spans := sr.Ended()
+	require.Len(spans, 3)
+	assert.Empty(spans[0].Links())
+	for i := 1; i < len(spans); i++ {
+		links := spans[i].Links()
+		require.Len(links, 1)
+		assert.Equal(spans[i-1].SpanContext(), links[0].SpanContext)
+	}
+	assert.Contains(spans[2].Attributes(), attribute.Bool("kooper.object.deleted", true))
+}
+
+func TestGenericControllerTraceObjectLifecycleDeleted(t *testing.T) {
+	tests := map[string]struct {
+		deleteHandler bool
+		expSpans      int
+	}{
+		"Without delete handler, the lifecycle of the deleted objects should be forgotten.": {
+			deleteHandler: false,
+			expSpans:      2,
+		},
+
+		"With delete handler, the lifecycle of the deleted objects should be forgotten once the deletion is handled.": {
+			deleteHandler: true,
+			expSpans:      3,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			newNS := func(rv string) *corev1.Namespace {
+				return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns", UID: "test-uid", ResourceVersion: rv}}
+			}
+			nsl := &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    []corev1.Namespace{*newNS("1")},
+			}
+			ret, w := newFakeNamespaceRetriever(nsl)
+
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			h := controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil })
+			cfg := &controller.Config{
+				Name:                 "test",
+				Handler:              h,
+				Retriever:            ret,
+				Logger:               log.Dummy,
+				Tracer:               opentelemetry.New(opentelemetry.Config{TracerProvider: tp}),
+				TraceObjectLifecycle: true,
+			}
+			if test.deleteHandler {
+				cfg.DeleteHandler = h
+			}
+			c, err := controller.New(cfg)
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			// Create, delete and add again an object with the same UID.
+			assert.Eventually(func() bool { return len(sr.Ended()) == 1 }, 1*time.Second, 5*time.Millisecond)
+			w.Delete(newNS("2"))
+			if test.deleteHandler {
+				assert.Eventually(func() bool { return len(sr.Ended()) == 2 }, 1*time.Second, 5*time.Millisecond)
+			}
+			w.Add(newNS("3"))
+			assert.Eventually(func() bool { return len(sr.Ended()) == test.expSpans }, 1*time.Second, 5*time.Millisecond)
+
+			// The last span should not be linked to the spans before the deletion.
+			spans := sr.Ended()
+			require.Len(spans, test.expSpans)
+			assert.Empty(spans[len(spans)-1].Links())
+		})
+	}
+}
+
+func TestGenericControllerTraceSteps(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 1)
+	ret, _ := newFakeNamespaceRetriever(nsList)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	h := controller.HandlerFunc(func(ctx context.Context, _ runtime.Object) error {
+		controller.Step(ctx, "fetch")
+		time.Sleep(20 * time.Millisecond)
+		controller.Step(ctx, "apply")
+		time.Sleep(40 * time.Millisecond)
+		return nil
+	})
+	c, err := controller.New(&controller.Config{
+		Name:       "test",
+		Handler:    h,
+		Retriever:  ret,
+		Logger:     log.Dummy,
+		Tracer:     opentelemetry.New(opentelemetry.Config{TracerProvider: tp}),
+		TraceSteps: true,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return len(sr.Ended()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range sr.Ended() {
+		spans[s.Name()] = s
+	}
+	require.Len(spans, 3)
+
+	// The steps should be child spans of the handling span and last until the next step or the handling end.
+	handling := spans["test"]
+	for _, step := range []string{"fetch", "apply"} {
+		assert.Equal(handling.SpanContext().SpanID(), spans[step].Parent().SpanID())
+		assert.Equal(handling.SpanContext().TraceID(), spans[step].SpanContext().TraceID())
+	}
+	fetch, apply := spans["fetch"], spans["apply"]
+	assert.GreaterOrEqual(int64(fetch.EndTime().Sub(fetch.StartTime())), int64(20*time.Millisecond))
+	assert.GreaterOrEqual(int64(apply.EndTime().Sub(apply.StartTime())), int64(40*time.Millisecond))
+	assert.Less(int64(fetch.EndTime().Sub(fetch.StartTime())), int64(apply.EndTime().Sub(apply.StartTime())))
+	assert.False(apply.StartTime().Before(fetch.EndTime()))
+	assert.False(handling.EndTime().Before(apply.EndTime()))
+}
+
+func TestGenericControllerTraceSampleRate(t *testing.T) {
+	tests := map[string]struct {
+		sampleRate      float64
+		expMinSuccesses int
+		expMaxSuccesses int
+	}{
+		"Without sampling all the handlings should be traced.": {
+			sampleRate:      1,
+			expMinSuccesses: 150,
+			expMaxSuccesses: 150,
+		},
+
+		"With sampling the successful handlings should be traced at the sample rate.": {
+			sampleRate:      0.3,
+			expMinSuccesses: 15,
+			expMaxSuccesses: 80,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// 1 of every 4 objects fails: 150 successes and 50 errors.
+			nsl, _ := createNamespaceList("testing", 200)
+			ret, _ := newFakeNamespaceRetriever(nsl)
+			failed := map[string]bool{}
+			for i, ns := range nsl.Items {
+				if i%4 == 0 {
+					failed[ns.Name] = true
+				}
+			}
+
+			var mu sync.Mutex
+			handlings := 0
+			sr := tracetest.NewSpanRecorder()
+			c, err := controller.New(&controller.Config{
+				Name: "test",
+				Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+					mu.Lock()
+					defer mu.Unlock()
+					handlings++
+					if failed[obj.(*corev1.Namespace).Name] {
+						return fmt.Errorf("wanted error")
+					}
+					return nil
+				}),
+				Retriever:       ret,
+				Logger:          log.Dummy,
+				Tracer:          opentelemetry.New(opentelemetry.Config{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))}),
+				TraceSampleRate: test.sampleRate,
+				DisableResync:   true,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			assert.Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return handlings == 200
+			}, 2*time.Second, 5*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+
+			successes, errs := 0, 0
+			for _, span := range sr.Ended() {
+				if span.Status().Code == codes.Error {
+					errs++
+				} else {
+					successes++
+				}
+			}
+
+			// The failed handlings should always be traced.
+			assert.Equal(50, errs)
+			assert.GreaterOrEqual(successes, test.expMinSuccesses)
+			assert.LessOrEqual(successes, test.expMaxSuccesses)
+		})
+	}
+}
+
+func TestGenericControllerTraceSampleRateInvalid(t *testing.T) {
+	_, err := controller.New(&controller.Config{
+		Name:            "test",
+		Handler:         controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever:       newNamespaceRetriever(nil),
+		Logger:          log.Dummy,
+		TraceSampleRate: 1.5,
+	})
+	assert.Error(t, err)
+}
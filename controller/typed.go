@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TypedHandlerFunc knows how to handle resources of the type `T`.
+type TypedHandlerFunc[T runtime.Object] func(ctx context.Context, obj T) error
+
+// NewTypedHandler returns a Handler that asserts the type of the objects once before calling the
+// typed handler, the objects that are not of the type `T` will error instead of panicking.
+func NewTypedHandler[T runtime.Object](h TypedHandlerFunc[T]) Handler {
+	return HandlerFunc(func(ctx context.Context, obj runtime.Object) error {
+		if h == nil {
+			return fmt.Errorf("handle func is required")
+		}
+
+		tobj, ok := obj.(T)
+		if !ok {
+			var zero T
+			return fmt.Errorf("unexpected object type %T, expected %T", obj, zero)
+		}
+
+		return h(ctx, tobj)
+	})
+}
+
+// TypedConfig is the configuration of a controller that handles resources of the type `T`.
+type TypedConfig[T runtime.Object] struct {
+	// Config is the controller configuration, its `Handler`, `DeleteHandler` and `EventHandler`
+	// must not be set, the typed handlers are used instead.
+	Config
+	// Handler is the typed handler of the adds, updates and resyncs.
+	Handler TypedHandlerFunc[T]
+	// DeleteHandler if set, is the typed handler of the deletions (check `Config.DeleteHandler`).
+	DeleteHandler TypedHandlerFunc[T]
+}
+
+// NewTyped returns a new controller that handles the resources of the type `T` with typed
+// handlers, so the handlers don't need to cast the objects.
+func NewTyped[T runtime.Object](cfg TypedConfig[T]) (Controller, error) {
+	if cfg.Config.Handler != nil || cfg.Config.DeleteHandler != nil || cfg.Config.EventHandler != nil {
+		return nil, fmt.Errorf("could no create controller: %w: untyped handlers can't be used on typed controllers", ErrControllerNotValid)
+	}
+
+	c := cfg.Config
+	if cfg.Handler != nil {
+		c.Handler = NewTypedHandler(cfg.Handler)
+	}
+	if cfg.DeleteHandler != nil {
+		c.DeleteHandler = NewTypedHandler(cfg.DeleteHandler)
+	}
+
+	return New(&c)
+}
@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestTypedController(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	ret, _ := newFakeNamespaceRetriever(nsList)
+
+	var mu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		h := append([]string{}, handled...)
+		sort.Strings(h)
+		return h
+	}
+
+	c, err := controller.NewTyped(controller.TypedConfig[*corev1.Namespace]{
+		Config: controller.Config{
+			Name:      "test",
+			Retriever: ret,
+			Logger:    log.Dummy,
+		},
+		Handler: func(_ context.Context, ns *corev1.Namespace) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, ns.Name)
+			return nil
+		},
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	assert.Eventually(func() bool { return len(getHandled()) == 3 }, 1*time.Second, 5*time.Millisecond)
+	assert.Equal([]string{"testing-0", "testing-1", "testing-2"}, getHandled())
+}
+
+func TestTypedControllerWithUntypedHandler(t *testing.T) {
+	_, err := controller.NewTyped(controller.TypedConfig[*corev1.Namespace]{
+		Config: controller.Config{
+			Name:      "test",
+			Handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+			Retriever: newNamespaceRetriever(nil),
+			Logger:    log.Dummy,
+		},
+		Handler: func(context.Context, *corev1.Namespace) error { return nil },
+	})
+	assert.ErrorIs(t, err, controller.ErrControllerNotValid)
+}
+
+func TestTypedHandler(t *testing.T) {
+	tests := map[string]struct {
+		obj    runtime.Object
+		expErr bool
+	}{
+		"An object of the handler type should be handled.": {
+			obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		},
+
+		"An object of a different type should error.": {
+			obj:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			called := false
+			h := controller.NewTypedHandler(func(_ context.Context, pod *corev1.Pod) error {
+				called = pod.Name == "test"
+				return nil
+			})
+			err := h.Handle(context.TODO(), test.obj)
+
+			if test.expErr {
+				assert.Error(err)
+				assert.False(called)
+			} else {
+				assert.NoError(err)
+				assert.True(called)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// versionNormalizer converts the objects to their canonical version before they enter the cache. The
+// representations of the same stored object received under multiple API versions (e.g during a CRD
+// version migration) share the key and the resource version, so only the first one is kept.
+type versionNormalizer struct {
+	convert func(obj runtime.Object) (runtime.Object, error)
+	logger  log.Logger
+
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+func newVersionNormalizer(convert func(obj runtime.Object) (runtime.Object, error), logger log.Logger) *versionNormalizer {
+	return &versionNormalizer{
+		convert:  convert,
+		logger:   logger,
+		versions: map[string]string{},
+	}
+}
+
+// normalize converts the object to the canonical version, if the conversion fails the object will be
+// skipped (logged).
+func (v *versionNormalizer) normalize(obj runtime.Object) (string, runtime.Object, bool) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return "", obj, true
+	}
+
+	converted, err := v.convert(obj)
+	if err != nil {
+		v.logger.WithKV(log.KV{"object-key": key}).Warningf("object skipped, could not convert the object to the canonical version: %s", err)
+		return key, nil, false
+	}
+
+	return key, converted, true
+}
+
+// wrap returns a ListerWatcher that converts the listed and watched objects to the canonical version,
+// skipping the duplicated representations of the same object version.
+func (v *versionNormalizer) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+
+			objs, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			// A list has the complete state, so we start tracking again.
+			versions := map[string]string{}
+			normalized := make([]runtime.Object, 0, len(objs))
+			for _, o := range objs {
+				key, converted, ok := v.normalize(o)
+				if !ok {
+					continue
+				}
+				if _, dup := versions[key]; dup {
+					continue
+				}
+				versions[key] = objectVersion(converted)
+				normalized = append(normalized, converted)
+			}
+
+			v.mu.Lock()
+			v.versions = versions
+			v.mu.Unlock()
+
+			err = meta.SetList(obj, normalized)
+			if err != nil {
+				return nil, err
+			}
+			return obj, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				if e.Type != watch.Added && e.Type != watch.Modified && e.Type != watch.Deleted {
+					return e, true
+				}
+
+				key, converted, ok := v.normalize(e.Object)
+				if !ok {
+					return e, false
+				}
+				e.Object = converted
+
+				v.mu.Lock()
+				defer v.mu.Unlock()
+				version, tracked := v.versions[key]
+				if e.Type == watch.Deleted {
+					// The deletion of the object under the other versions is a duplicate.
+					delete(v.versions, key)
+					return e, tracked
+				}
+				if tracked && version == objectVersion(converted) {
+					return e, false
+				}
+				v.versions[key] = objectVersion(converted)
+
+				return e, true
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func newWidget(apiVersion, name, resourceVersion string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind("Widget")
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func TestGenericControllerNormalizeVersion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The same objects are received in both versions.
+	w := watch.NewFake()
+	ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			l := &unstructured.UnstructuredList{}
+			l.SetResourceVersion("1")
+			l.Items = []unstructured.Unstructured{
+				*newWidget("example.com/v1beta1", "widget-1", "1"),
+				*newWidget("example.com/v1", "widget-1", "1"),
+			}
+			return l, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) { return w, nil },
+	})
+
+	var mu sync.Mutex
+	handled := []string{}
+	getHandled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, handled...)
+	}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		u := obj.(*unstructured.Unstructured)
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, u.GetAPIVersion()+"/"+u.GetName()+"@"+u.GetResourceVersion())
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:          "test",
+		Handler:       h,
+		Retriever:     ret,
+		Logger:        log.Dummy,
+		DisableResync: true,
+		NormalizeVersionFunc: func(obj runtime.Object) (runtime.Object, error) {
+			u := obj.(*unstructured.Unstructured).DeepCopy()
+			u.SetAPIVersion("example.com/v1")
+			return u, nil
+		},
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+	assert.Eventually(func() bool { return len(getHandled()) == 1 }, 1*time.Second, 5*time.Millisecond)
+
+	w.Modify(newWidget("example.com/v1beta1", "widget-1", "2"))
+	w.Modify(newWidget("example.com/v1", "widget-1", "2"))
+	w.Add(newWidget("example.com/v1", "widget-2", "3"))
+	w.Add(newWidget("example.com/v1beta1", "widget-2", "3"))
+	assert.Eventually(func() bool { return len(getHandled()) == 3 }, 1*time.Second, 5*time.Millisecond)
+
+	// Give time to the duplicated representations to be handled if they were not collapsed.
+	time.Sleep(100 * time.Millisecond)
+	exp := []string{
+		"example.com/v1/widget-1@1",
+		"example.com/v1/widget-1@2",
+		"example.com/v1/widget-2@3",
+	}
+	assert.Equal(exp, getHandled())
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// watchExpiredDetector detects the watches that expired because the resource version is too
+// old (410 Gone). The informer will relist to recover, but frequent expirations are a symptom
+// of etcd compaction pressure so they are measured and notified.
+type watchExpiredDetector struct {
+	name   string
+	mrec   MetricsRecorder
+	hook   func(err error)
+	logger log.Logger
+}
+
+// isWatchExpired returns true if the error is a watch expiration, the API server could return
+// `Expired` or `Gone` reasons depending on the version.
+func isWatchExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+func (d watchExpiredDetector) expired(err error) {
+	d.mrec.IncResourceWatchExpired(context.Background(), d.name)
+	d.logger.Warningf("watch expired, the resource version is too old: %s", err)
+	if d.hook != nil {
+		d.hook(err)
+	}
+}
+
+// wrap returns a ListerWatcher that detects the watch expirations, these can happen when
+// starting the watch or as an error event on an established watch.
+func (d watchExpiredDetector) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: lw.List,
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				if isWatchExpired(err) {
+					d.expired(err)
+				}
+				return nil, err
+			}
+
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				if e.Type == watch.Error {
+					if err := apierrors.FromObject(e.Object); isWatchExpired(err) {
+						d.expired(err)
+					}
+				}
+				return e, true
+			}), nil
+		},
+	}
+}
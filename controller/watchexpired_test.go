@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// watchExpiredRecorder is a metrics recorder that counts the expired watches.
+type watchExpiredRecorder struct {
+	controller.MetricsRecorder
+	expired int64
+}
+
+func (w *watchExpiredRecorder) IncResourceWatchExpired(context.Context, string) {
+	atomic.AddInt64(&w.expired, 1)
+}
+
+func TestGenericControllerWatchExpired(t *testing.T) {
+	expiredErr := apierrors.NewResourceExpired("too old resource version: 1 (100)")
+
+	tests := map[string]struct {
+		watchErr   error
+		eventErr   error
+		expExpired int64
+	}{
+		"A 410 Gone error event on the watch should be measured.": {
+			eventErr:   expiredErr,
+			expExpired: 1,
+		},
+
+		"A 410 Gone error starting the watch should be measured.": {
+			watchErr:   expiredErr,
+			expExpired: 1,
+		},
+
+		"Other watch errors should not be measured.": {
+			eventErr:   apierrors.NewInternalError(fmt.Errorf("wanted error")),
+			expExpired: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			nsl := &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-1", ResourceVersion: "1"}}},
+			}
+			w := watch.NewFake()
+			var watchCalls int64
+			ret := controller.MustRetrieverFromListerWatcher(&cache.ListWatch{
+				ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) { return nsl, nil },
+				WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) {
+					if atomic.AddInt64(&watchCalls, 1) == 1 && test.watchErr != nil {
+						return nil, test.watchErr
+					}
+					return w, nil
+				},
+			})
+
+			hookCalls := int64(0)
+			mrec := &watchExpiredRecorder{MetricsRecorder: controller.DummyMetricsRecorder}
+			c, err := controller.New(&controller.Config{
+				Name:            "test",
+				Handler:         controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+				Retriever:       ret,
+				MetricsRecorder: mrec,
+				OnWatchExpired:  func(error) { atomic.AddInt64(&hookCalls, 1) },
+				Logger:          log.Dummy,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			if test.eventErr != nil {
+				status := test.eventErr.(apierrors.APIStatus).Status()
+				w.Error(&status)
+			}
+
+			if test.expExpired > 0 {
+				assert.Eventually(func() bool { return atomic.LoadInt64(&mrec.expired) == test.expExpired }, 1*time.Second, 5*time.Millisecond)
+			} else {
+				time.Sleep(50 * time.Millisecond)
+			}
+			assert.Equal(test.expExpired, atomic.LoadInt64(&mrec.expired))
+			assert.Equal(test.expExpired, atomic.LoadInt64(&hookCalls))
+		})
+	}
+}
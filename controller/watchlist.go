@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// initialEventsEndAnnotation is the annotation of the bookmark that marks the end of the
+// initial events of a watch list.
+const initialEventsEndAnnotation = "k8s.io/initial-events-end"
+
+// watchListTimeout is the max time to receive the initial events of a watch list, the API servers that
+// ignore the watch list parameters will never send the initial events end bookmark.
+const watchListTimeout = 5 * time.Minute
+
+// errWatchListNotSupported is used when the watch ended without the initial events end bookmark.
+var errWatchListNotSupported = errors.New("watch ended before the initial events end bookmark")
+
+// WatchListRetriever is an optional interface that a Retriever can implement to stream the
+// initial objects with a watch (WatchList) instead of listing them (check `Config.WatchList`).
+type WatchListRetriever interface {
+	Retriever
+	// WatchList starts a watch that sends the initial objects as added events (`sendInitialEvents=true`),
+	// followed by a bookmark annotated with `k8s.io/initial-events-end`.
+	WatchList(ctx context.Context, options metav1.ListOptions) (watch.Interface, error)
+}
+
+type watchListRetriever struct {
+	Retriever
+	client    rest.Interface
+	resource  string
+	namespace string
+}
+
+// RetrieverWithWatchList returns a Retriever that can stream the initial objects with a watch (check
+// `WatchListRetriever`). The watch lists are made with the REST client of the resource (e.g
+// `kubeCli.CoreV1().RESTClient()` for `namespaces`), the rest of the calls use the received retriever.
+// If the namespace is empty, it will retrieve the resources of all the namespaces.
+func RetrieverWithWatchList(r Retriever, client rest.Interface, resource, namespace string) WatchListRetriever {
+	return watchListRetriever{Retriever: r, client: client, resource: resource, namespace: namespace}
+}
+
+func (w watchListRetriever) WatchList(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	// The client-go version used doesn't have the `SendInitialEvents` option, use the raw parameter.
+	options.Watch = true
+	return w.client.Get().
+		Namespace(w.namespace).
+		Resource(w.resource).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Param("sendInitialEvents", "true").
+		Watch(ctx)
+}
+
+// newWatchListListerWatcher returns a ListerWatcher that lists the objects with a watch list. If the
+// API server rejects the watch list, it falls back to the list of the received ListerWatcher.
+func newWatchListListerWatcher(ret WatchListRetriever, logger log.Logger, lw cache.ListerWatcher) cache.ListerWatcher {
+	var mu sync.Mutex
+	fallback := false
+
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if fallback {
+				return lw.List(options)
+			}
+
+			list, err := watchList(ret, options)
+			switch {
+			case err == nil:
+				logger.Infof("objects listed with a watch list")
+				return list, nil
+			case errors.Is(err, errWatchListNotSupported), apierrors.IsBadRequest(err), apierrors.IsInvalid(err), apierrors.IsMethodNotSupported(err):
+				fallback = true
+				logger.Warningf("watch list not supported by the API server, falling back to list and watch: %s", err)
+				return lw.List(options)
+			default:
+				return nil, err
+			}
+		},
+		WatchFunc: lw.Watch,
+	}
+}
+
+// watchList gets the list of the objects from the initial events of a watch list, the list resource
+// version is the one of the initial events end bookmark.
+func watchList(ret WatchListRetriever, options metav1.ListOptions) (runtime.Object, error) {
+	options.AllowWatchBookmarks = true
+	options.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
+	// The initial events are not paginated.
+	options.Limit = 0
+	options.Continue = ""
+	timeout := int64(watchListTimeout.Seconds())
+	options.TimeoutSeconds = &timeout
+
+	// TODO(slok): pass context when Kubernetes updates its ListerWatchers ¯\_(ツ)_/¯.
+	w, err := ret.WatchList(context.TODO(), options)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	list := &metav1.List{}
+	for e := range w.ResultChan() {
+		switch e.Type {
+		case watch.Added:
+			list.Items = append(list.Items, runtime.RawExtension{Object: e.Object})
+		case watch.Bookmark:
+			m, err := meta.Accessor(e.Object)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bookmark: %w", err)
+			}
+			if m.GetAnnotations()[initialEventsEndAnnotation] == "true" {
+				list.ResourceVersion = m.GetResourceVersion()
+				return list, nil
+			}
+		case watch.Error:
+			return nil, apierrors.FromObject(e.Object)
+		}
+	}
+
+	return nil, errWatchListNotSupported
+}
@@ -0,0 +1,187 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+// watchListServer is a fake API server for namespaces that can support the watch lists.
+type watchListServer struct {
+	supported  bool
+	mu         sync.Mutex
+	lists      int
+	watchLists int
+}
+
+func (s *watchListServer) counts() (lists, watchLists int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists, s.watchLists
+}
+
+func (s *watchListServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ns := func(name, rv string) corev1.Namespace {
+		return corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: rv},
+		}
+	}
+	sendEvent := func(t watch.EventType, obj runtime.Object) {
+		_ = json.NewEncoder(w).Encode(metav1.WatchEvent{Type: string(t), Object: runtime.RawExtension{Object: obj}})
+		w.(http.Flusher).Flush()
+	}
+	q := r.URL.Query()
+
+	switch {
+	// Classic list.
+	case q.Get("watch") != "true":
+		s.mu.Lock()
+		s.lists++
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(&corev1.NamespaceList{
+			TypeMeta: metav1.TypeMeta{Kind: "NamespaceList", APIVersion: "v1"},
+			ListMeta: metav1.ListMeta{ResourceVersion: "5"},
+			Items:    []corev1.Namespace{ns("ns-0", "1"), ns("ns-1", "2"), ns("ns-2", "3")},
+		})
+		return
+
+	// Watch list.
+	case q.Get("sendInitialEvents") == "true":
+		s.mu.Lock()
+		s.watchLists++
+		s.mu.Unlock()
+
+		// Reject it like the API servers that don't support the watch lists.
+		if !s.supported {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(w).Encode(&metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusFailure,
+				Reason:   metav1.StatusReasonInvalid,
+				Message:  "resourceVersionMatch is forbidden for watch",
+				Code:     http.StatusUnprocessableEntity,
+			})
+			return
+		}
+
+		for _, n := range []corev1.Namespace{ns("ns-0", "1"), ns("ns-1", "2"), ns("ns-2", "3")} {
+			n := n
+			sendEvent(watch.Added, &n)
+		}
+		bookmark := ns("", "5")
+		bookmark.Annotations = map[string]string{"k8s.io/initial-events-end": "true"}
+		sendEvent(watch.Bookmark, &bookmark)
+
+	// Regular watch.
+	default:
+		w.(http.Flusher).Flush()
+	}
+
+	<-r.Context().Done()
+}
+
+func TestGenericControllerWatchList(t *testing.T) {
+	tests := map[string]struct {
+		supported     bool
+		expLists      int
+		expWatchLists int
+	}{
+		"If the API server supports the watch lists, the objects should be listed with a watch list.": {
+			supported:     true,
+			expLists:      0,
+			expWatchLists: 1,
+		},
+
+		"If the API server doesn't support the watch lists, the objects should be listed with a classic list.": {
+			supported:     false,
+			expLists:      1,
+			expWatchLists: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			srv := &watchListServer{supported: test.supported}
+			httpSrv := httptest.NewServer(srv)
+			defer func() {
+				// The watches are streaming until the connections are closed.
+				httpSrv.CloseClientConnections()
+				httpSrv.Close()
+			}()
+
+			// Stop the controller before the server.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			cli, err := kubernetes.NewForConfig(&rest.Config{Host: httpSrv.URL})
+			require.NoError(err)
+
+			var mu sync.Mutex
+			handled := []string{}
+			c, err := controller.New(&controller.Config{
+				Name: "test",
+				Handler: controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+					mu.Lock()
+					defer mu.Unlock()
+					handled = append(handled, obj.(*corev1.Namespace).Name)
+					return nil
+				}),
+				Retriever: controller.RetrieverWithWatchList(newNamespaceRetriever(cli), cli.CoreV1().RESTClient(), "namespaces", ""),
+				WatchList: true,
+				Logger:    log.Dummy,
+			})
+			require.NoError(err)
+			go func() { _ = c.Run(ctx) }()
+
+			// The objects should be delivered whatever the used path.
+			getHandled := func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				res := append([]string{}, handled...)
+				sort.Strings(res)
+				return res
+			}
+			require.Eventually(func() bool { return len(getHandled()) == 3 }, 2*time.Second, 5*time.Millisecond)
+			assert.Equal([]string{"ns-0", "ns-1", "ns-2"}, getHandled())
+
+			lists, watchLists := srv.counts()
+			assert.Equal(test.expLists, lists)
+			assert.Equal(test.expWatchLists, watchLists)
+		})
+	}
+}
+
+func TestGenericControllerWatchListInvalidRetriever(t *testing.T) {
+	_, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   controller.HandlerFunc(func(context.Context, runtime.Object) error { return nil }),
+		Retriever: newNamespaceRetriever(&fake.Clientset{}),
+		WatchList: true,
+		Logger:    log.Dummy,
+	})
+	assert.Error(t, err)
+}
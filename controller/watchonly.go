@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// initialListIgnorer tracks the object versions received on the first list, so the
+// events of these objects can be ignored and only the changes received afterwards are handled.
+type initialListIgnorer struct {
+	mu       sync.Mutex
+	listed   bool
+	versions map[string]string
+}
+
+func newInitialListIgnorer() *initialListIgnorer {
+	return &initialListIgnorer{versions: map[string]string{}}
+}
+
+// wrap returns a ListerWatcher that tracks the objects of the first successful list.
+func (i *initialListIgnorer) wrap(lw cache.ListerWatcher) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			if i.listed {
+				return obj, nil
+			}
+			i.listed = true
+
+			objs, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+			for _, o := range objs {
+				key, err := cache.MetaNamespaceKeyFunc(o)
+				if err != nil {
+					continue
+				}
+				i.versions[key] = objectVersion(o)
+			}
+
+			return obj, nil
+		},
+		WatchFunc: lw.Watch,
+	}
+}
+
+// ignore returns true if the object is the same version received on the first list.
+func (i *initialListIgnorer) ignore(key string, obj runtime.Object) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	version, ok := i.versions[key]
+	if !ok {
+		return false
+	}
+
+	// Once we receive a change we don't need to track the object anymore.
+	if version != objectVersion(obj) {
+		delete(i.versions, key)
+		return false
+	}
+
+	return true
+}
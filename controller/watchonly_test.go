@@ -0,0 +1,61 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+func TestGenericControllerWatchOnly(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsList, _ := createNamespaceList("testing", 3)
+	ret, w := newFakeNamespaceRetriever(nsList)
+
+	var mu sync.Mutex
+	handled := []string{}
+	h := controller.HandlerFunc(func(_ context.Context, obj runtime.Object) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ns := obj.(*corev1.Namespace)
+		handled = append(handled, ns.Name+"@"+ns.ResourceVersion)
+		return nil
+	})
+
+	c, err := controller.New(&controller.Config{
+		Name:      "test",
+		Handler:   h,
+		Retriever: ret,
+		WatchOnly: true,
+		Logger:    log.Dummy,
+	})
+	require.NoError(err)
+	go func() { _ = c.Run(ctx) }()
+
+	// Send the changes after the initial list.
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new", ResourceVersion: "100"}})
+	updated := nsList.Items[1].DeepCopy()
+	updated.ResourceVersion = "101"
+	w.Modify(updated)
+
+	assert.Eventually(func() bool { return c.Stats().Processed == 2 }, 1*time.Second, 5*time.Millisecond)
+
+	// Give time in case the initial list objects are handled.
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch([]string{"new@100", "testing-1@101"}, handled)
+}
@@ -4,4 +4,7 @@ set -o errexit
 set -o nounset
 
 go test -race -coverprofile=.test_coverage.txt ./...
-go tool cover -func=.test_coverage.txt | tail -n1 | awk '{print "Total test coverage: " $3}'
\ No newline at end of file
+go tool cover -func=.test_coverage.txt | tail -n1 | awk '{print "Total test coverage: " $3}'
+
+# Nested modules are not part of the main module packages.
+(cd ./controller/controllerruntime && go test -race ./...)
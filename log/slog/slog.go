@@ -0,0 +1,50 @@
+//go:build go1.21
+
+package slog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+type logger struct {
+	*slog.Logger
+}
+
+// New returns a new log.Logger for a slog implementation.
+func New(l *slog.Logger) log.Logger {
+	return logger{Logger: l}
+}
+
+func (l logger) logf(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+func (l logger) Infof(format string, args ...interface{})    { l.logf(slog.LevelInfo, format, args...) }
+func (l logger) Warningf(format string, args ...interface{}) { l.logf(slog.LevelWarn, format, args...) }
+func (l logger) Errorf(format string, args ...interface{})   { l.logf(slog.LevelError, format, args...) }
+func (l logger) Debugf(format string, args ...interface{})   { l.logf(slog.LevelDebug, format, args...) }
+
+func (l logger) WithKV(kv log.KV) log.Logger {
+	// Sort the keys so the attributes have a stable order.
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]interface{}, 0, len(kv))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, kv[k]))
+	}
+
+	return New(l.Logger.With(attrs...))
+}
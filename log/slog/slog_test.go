@@ -0,0 +1,75 @@
+//go:build go1.21
+
+package slog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adevjoe/kooper/v2/log"
+	kooperslog "github.com/adevjoe/kooper/v2/log/slog"
+)
+
+func TestLoggerWithKV(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var b bytes.Buffer
+	h := slog.NewJSONHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		// Remove the time so the entries can be checked.
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := kooperslog.New(slog.New(h))
+
+	base := l.WithKV(log.KV{"controller": "test"})
+	base.WithKV(log.KV{"object-key": "ns/obj-1", "attempt": 2}).Warningf("object %s failed", "obj-1")
+	base.Infof("done")
+	l.Errorf("error %d", 1)
+	l.Debugf("debug %d", 1) // Disabled level.
+
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		e := map[string]interface{}{}
+		require.NoError(json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+	require.Len(entries, 3)
+
+	// The chained KVs should be kept.
+	assert.Equal(map[string]interface{}{"level": "WARN", "msg": "object obj-1 failed", "controller": "test", "object-key": "ns/obj-1", "attempt": float64(2)}, entries[0])
+
+	// The parent logger should not have the child KVs.
+	assert.Equal(map[string]interface{}{"level": "INFO", "msg": "done", "controller": "test"}, entries[1])
+
+	assert.Equal(map[string]interface{}{"level": "ERROR", "msg": "error 1"}, entries[2])
+}
+
+func TestLoggerWithKVOrder(t *testing.T) {
+	var b bytes.Buffer
+	h := slog.NewTextHandler(&b, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := kooperslog.New(slog.New(h))
+
+	l.WithKV(log.KV{"c": 3, "a": 1, "b": 2}).Infof("test")
+
+	// The attributes should have a stable (sorted) order.
+	assert.Equal(t, "level=INFO msg=test a=1 b=2 c=3\n", b.String())
+}
@@ -0,0 +1,39 @@
+package zap
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+type logger struct {
+	*zap.SugaredLogger
+}
+
+// New returns a new log.Logger for a zap implementation. The formatted messages use the sugared
+// logger and the KVs are added as structured fields.
+func New(l *zap.Logger) log.Logger {
+	return logger{SugaredLogger: l.Sugar()}
+}
+
+func (l logger) Warningf(format string, args ...interface{}) {
+	l.SugaredLogger.Warnf(format, args...)
+}
+
+func (l logger) WithKV(kv log.KV) log.Logger {
+	// Sort the keys so the fields have a stable order.
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(kv))
+	for _, k := range keys {
+		fields = append(fields, zap.Any(k, kv[k]))
+	}
+
+	return New(l.SugaredLogger.Desugar().With(fields...))
+}
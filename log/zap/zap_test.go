@@ -0,0 +1,43 @@
+package zap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/adevjoe/kooper/v2/log"
+	kooperzap "github.com/adevjoe/kooper/v2/log/zap"
+)
+
+func TestLoggerWithKV(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := kooperzap.New(zap.New(core))
+
+	base := l.WithKV(log.KV{"controller": "test"})
+	base.WithKV(log.KV{"object-key": "ns/obj-1", "attempt": 2}).Warningf("object %s failed", "obj-1")
+	base.Infof("done")
+	l.Debugf("debug %d", 1)
+
+	entries := logs.AllUntimed()
+	require.Len(entries, 3)
+
+	// The chained KVs should be kept.
+	assert.Equal(zapcore.WarnLevel, entries[0].Level)
+	assert.Equal("object obj-1 failed", entries[0].Message)
+	assert.Equal(map[string]interface{}{"controller": "test", "object-key": "ns/obj-1", "attempt": int64(2)}, entries[0].ContextMap())
+
+	// The parent logger should not have the child KVs.
+	assert.Equal(zapcore.InfoLevel, entries[1].Level)
+	assert.Equal(map[string]interface{}{"controller": "test"}, entries[1].ContextMap())
+
+	assert.Equal(zapcore.DebugLevel, entries[2].Level)
+	assert.Equal("debug 1", entries[2].Message)
+	assert.Empty(entries[2].ContextMap())
+}
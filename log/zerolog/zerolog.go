@@ -0,0 +1,36 @@
+package zerolog
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/adevjoe/kooper/v2/log"
+)
+
+type logger struct {
+	l zerolog.Logger
+}
+
+// New returns a new log.Logger for a zerolog implementation. The KVs are added as structured fields.
+func New(l zerolog.Logger) log.Logger {
+	return logger{l: l}
+}
+
+func (l logger) Infof(format string, args ...interface{}) {
+	l.l.Info().Msgf(format, args...)
+}
+
+func (l logger) Warningf(format string, args ...interface{}) {
+	l.l.Warn().Msgf(format, args...)
+}
+
+func (l logger) Errorf(format string, args ...interface{}) {
+	l.l.Error().Msgf(format, args...)
+}
+
+func (l logger) Debugf(format string, args ...interface{}) {
+	l.l.Debug().Msgf(format, args...)
+}
+
+func (l logger) WithKV(kv log.KV) log.Logger {
+	return New(l.l.With().Fields(map[string]interface{}(kv)).Logger())
+}
@@ -0,0 +1,46 @@
+package zerolog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adevjoe/kooper/v2/log"
+	kooperzerolog "github.com/adevjoe/kooper/v2/log/zerolog"
+)
+
+func TestLoggerWithKV(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var b bytes.Buffer
+	l := kooperzerolog.New(zerolog.New(&b).Level(zerolog.DebugLevel))
+
+	base := l.WithKV(log.KV{"controller": "test"})
+	base.WithKV(log.KV{"object-key": "ns/obj-1", "attempt": 2}).Warningf("object %s failed", "obj-1")
+	base.Infof("done")
+	l.Errorf("error %d", 1)
+	l.Debugf("debug %d", 1)
+
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		e := map[string]interface{}{}
+		require.NoError(json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+
+	exp := []map[string]interface{}{
+		// The chained KVs should be kept.
+		{"level": "warn", "message": "object obj-1 failed", "controller": "test", "object-key": "ns/obj-1", "attempt": float64(2)},
+		// The parent logger should not have the child KVs.
+		{"level": "info", "message": "done", "controller": "test"},
+		{"level": "error", "message": "error 1"},
+		{"level": "debug", "message": "debug 1"},
+	}
+	assert.Equal(exp, entries)
+}
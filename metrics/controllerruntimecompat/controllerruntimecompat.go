@@ -0,0 +1,222 @@
+// Package controllerruntimecompat has a metrics recorder that exports the controller metrics
+// using the controller-runtime (https://github.com/kubernetes-sigs/controller-runtime) metric
+// names and labels, this way the dashboards and alerts made for controller-runtime controllers
+// work unchanged with kooper controllers.
+package controllerruntimecompat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// Config is the Recorder Config.
+type Config struct {
+	// Registerer is a prometheus registerer, e.g: prometheus.Registry.
+	// By default will use Prometheus default registry.
+	//
+	// Don't use the controller-runtime registry (`metrics.Registry`) if controller-runtime
+	// controllers are registering their metrics on it, the metrics would collide.
+	Registerer prometheus.Registerer
+}
+
+func (c *Config) defaults() {
+	if c.Registerer == nil {
+		c.Registerer = prometheus.DefaultRegisterer
+	}
+}
+
+// Recorder implements the metrics recording in a prometheus registry using controller-runtime
+// metric names. The kooper specific metrics that don't have a controller-runtime counterpart
+// are not recorded, and the controller labels (`controller.Config.Labels`) are ignored because
+// controller-runtime metrics don't have them.
+type Recorder struct {
+	reg          prometheus.Registerer
+	queueLengths *queueLengthGauges
+
+	reconcileTotal         *prometheus.CounterVec
+	reconcileErrorsTotal   *prometheus.CounterVec
+	reconcileTime          *prometheus.HistogramVec
+	workqueueAddsTotal     *prometheus.CounterVec
+	workqueueRetriesTotal  *prometheus.CounterVec
+	workqueueQueueDuration *prometheus.HistogramVec
+	workqueueWorkDuration  *prometheus.HistogramVec
+}
+
+// New returns a new controller-runtime compatible implementation for a metrics recorder.
+func New(cfg Config) *Recorder {
+	cfg.defaults()
+
+	r := &Recorder{
+		reg:          cfg.Registerer,
+		queueLengths: &queueLengthGauges{gauges: map[string]prometheus.Collector{}},
+
+		reconcileTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "controller_runtime_reconcile_total",
+			Help: "Total number of reconciliations per controller",
+		}, []string{"controller", "result"}),
+
+		reconcileErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "controller_runtime_reconcile_errors_total",
+			Help: "Total number of reconciliation errors per controller",
+		}, []string{"controller"}),
+
+		reconcileTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "controller_runtime_reconcile_time_seconds",
+			Help: "Length of time per reconciliation per controller",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4, 0.45, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0,
+				1.25, 1.5, 1.75, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5, 5, 6, 7, 8, 9, 10, 15, 20, 25, 30, 40, 50, 60},
+		}, []string{"controller"}),
+
+		workqueueAddsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "adds_total",
+			Help:      "Total number of adds handled by workqueue",
+		}, []string{"name"}),
+
+		workqueueRetriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "retries_total",
+			Help:      "Total number of retries handled by workqueue",
+		}, []string{"name"}),
+
+		workqueueQueueDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "queue_duration_seconds",
+			Help:      "How long in seconds an item stays in workqueue before being requested",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, []string{"name"}),
+
+		workqueueWorkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "work_duration_seconds",
+			Help:      "How long in seconds processing an item from workqueue takes.",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, []string{"name"}),
+	}
+
+	// Register metrics.
+	r.reg.MustRegister(
+		r.reconcileTotal,
+		r.reconcileErrorsTotal,
+		r.reconcileTime,
+		r.workqueueAddsTotal,
+		r.workqueueRetriesTotal,
+		r.workqueueQueueDuration,
+		r.workqueueWorkDuration)
+
+	return r
+}
+
+// IncResourceEventQueued satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceEventQueued(ctx context.Context, controller string, isRequeue bool) {
+	r.workqueueAddsTotal.WithLabelValues(controller).Inc()
+	if isRequeue {
+		r.workqueueRetriesTotal.WithLabelValues(controller).Inc()
+	}
+}
+
+// ObserveResourceInQueueDuration satisfies controller.MetricsRecorder interface.
+func (r Recorder) ObserveResourceInQueueDuration(ctx context.Context, controller string, queuedAt time.Time) {
+	r.workqueueQueueDuration.WithLabelValues(controller).Observe(time.Since(queuedAt).Seconds())
+}
+
+// ObserveResourceProcessingDuration satisfies controller.MetricsRecorder interface.
+func (r Recorder) ObserveResourceProcessingDuration(ctx context.Context, controller string, success bool, startProcessingAt time.Time) {
+	d := time.Since(startProcessingAt).Seconds()
+	r.reconcileTime.WithLabelValues(controller).Observe(d)
+	r.workqueueWorkDuration.WithLabelValues(controller).Observe(d)
+
+	if !success {
+		r.reconcileErrorsTotal.WithLabelValues(controller).Inc()
+		r.reconcileTotal.WithLabelValues(controller, "error").Inc()
+		return
+	}
+	r.reconcileTotal.WithLabelValues(controller, "success").Inc()
+}
+
+// RegisterResourceQueueLengthFunc satisfies controller.MetricsRecorder interface.
+func (r Recorder) RegisterResourceQueueLengthFunc(controller string, f func(context.Context) int) error {
+	gauge := prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Subsystem:   "workqueue",
+			Name:        "depth",
+			Help:        "Current depth of workqueue",
+			ConstLabels: prometheus.Labels{"name": controller},
+		},
+		func() float64 { return float64(f(context.Background())) },
+	)
+	err := r.reg.Register(gauge)
+	if err != nil {
+		return fmt.Errorf("could not register ResourceQueueLengthFunc metrics: %w", err)
+	}
+	r.queueLengths.set(controller, gauge)
+
+	return nil
+}
+
+// UnregisterResourceQueueLengthFunc satisfies controller.ResourceQueueLengthFuncUnregisterer interface.
+func (r Recorder) UnregisterResourceQueueLengthFunc(controller string) {
+	if gauge, ok := r.queueLengths.pop(controller); ok {
+		r.reg.Unregister(gauge)
+	}
+}
+
+// RegisterControllerLabels satisfies controller.MetricsRecorder interface.
+func (Recorder) RegisterControllerLabels(controller string, labels map[string]string) error {
+	return nil
+}
+
+// IncResourceOversizedSkipped satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceOversizedSkipped(context.Context, string) {}
+
+// IncResourceInvalidSkipped satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceInvalidSkipped(context.Context, string) {}
+
+// IncResourceProcessingOutcome satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceProcessingOutcome(context.Context, string, string) {}
+
+// IncResourceStaleCacheConflict satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceStaleCacheConflict(context.Context, string) {}
+
+// IncResourceWatchExpired satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceWatchExpired(context.Context, string) {}
+
+// IncResourceInformerRebuild satisfies controller.MetricsRecorder interface.
+func (Recorder) IncResourceInformerRebuild(context.Context, string) {}
+
+// SetResourceDegraded satisfies controller.MetricsRecorder interface.
+func (Recorder) SetResourceDegraded(context.Context, string, bool) {}
+
+// Check interfaces implementation.
+// queueLengthGauges stores the registered queue length gauge of each controller, so they
+// can be unregistered once the controller stops.
+type queueLengthGauges struct {
+	mu     sync.Mutex
+	gauges map[string]prometheus.Collector
+}
+
+func (q *queueLengthGauges) set(controller string, gauge prometheus.Collector) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.gauges[controller] = gauge
+}
+
+func (q *queueLengthGauges) pop(controller string) (prometheus.Collector, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	gauge, ok := q.gauges[controller]
+	delete(q.gauges, controller)
+	return gauge, ok
+}
+
+// Check interfaces implementation.
+var (
+	_ controller.MetricsRecorder                     = &Recorder{}
+	_ controller.ResourceQueueLengthFuncUnregisterer = &Recorder{}
+)
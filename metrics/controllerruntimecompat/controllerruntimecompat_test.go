@@ -0,0 +1,76 @@
+package controllerruntimecompat_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adevjoe/kooper/v2/metrics/controllerruntimecompat"
+)
+
+func TestRecorderMetricNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	reg := prometheus.NewRegistry()
+	r := controllerruntimecompat.New(controllerruntimecompat.Config{Registerer: reg})
+
+	ctx := context.TODO()
+	t0 := time.Now()
+	r.IncResourceEventQueued(ctx, "ctrl1", false)
+	r.IncResourceEventQueued(ctx, "ctrl1", true)
+	r.ObserveResourceInQueueDuration(ctx, "ctrl1", t0.Add(-1*time.Second))
+	r.ObserveResourceProcessingDuration(ctx, "ctrl1", true, t0.Add(-1*time.Second))
+	r.ObserveResourceProcessingDuration(ctx, "ctrl1", false, t0.Add(-1*time.Second))
+	err := r.RegisterResourceQueueLengthFunc("ctrl1", func(context.Context) int { return 3 })
+	require.NoError(err)
+
+	// The metric names and labels used by controller-runtime.
+	exp := map[string][]string{
+		"controller_runtime_reconcile_total":        {"controller", "result"},
+		"controller_runtime_reconcile_errors_total": {"controller"},
+		"controller_runtime_reconcile_time_seconds": {"controller"},
+		"workqueue_adds_total":                      {"name"},
+		"workqueue_retries_total":                   {"name"},
+		"workqueue_queue_duration_seconds":          {"name"},
+		"workqueue_work_duration_seconds":           {"name"},
+		"workqueue_depth":                           {"name"},
+	}
+
+	mfs, err := reg.Gather()
+	require.NoError(err)
+	got := map[string][]string{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := []string{}
+			for _, l := range m.GetLabel() {
+				labels = append(labels, l.GetName())
+			}
+			sort.Strings(labels)
+			got[mf.GetName()] = labels
+		}
+	}
+	assert.Equal(exp, got)
+
+	// The reconcile results should use the controller-runtime label values.
+	for _, mf := range mfs {
+		if mf.GetName() != "controller_runtime_reconcile_total" {
+			continue
+		}
+		results := []string{}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "result" {
+					results = append(results, l.GetValue())
+				}
+			}
+		}
+		sort.Strings(results)
+		assert.Equal([]string{"error", "success"}, results)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/controller/leaderelection"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
-	promNamespace           = "kooper"
-	promControllerSubsystem = "controller"
+	promNamespace               = "kooper"
+	promControllerSubsystem     = "controller"
+	promLeaderElectionSubsystem = "leader_election"
 )
 
 // Config is the Recorder Config.
@@ -26,6 +29,10 @@ type Config struct {
 	// ProcessingBuckets sets custom buckets for the duration/latency processing metrics.
 	// Check https://godoc.org/github.com/prometheus/client_golang/prometheus#pkg-variables
 	ProcessingBuckets []float64
+	// ControllerLabels are the controller label keys (`controller.Config.Labels`) allowed on the metrics,
+	// these will be added as labels to all the metrics. Controllers with label keys that are not
+	// allowed will be rejected to keep the cardinality of the metrics in check.
+	ControllerLabels []string
 }
 
 func (c *Config) defaults() {
@@ -46,11 +53,22 @@ func (c *Config) defaults() {
 
 // Recorder implements the metrics recording in a prometheus registry.
 type Recorder struct {
-	reg prometheus.Registerer
+	reg          prometheus.Registerer
+	labelKeys    []string
+	labelValues  *controllerLabelValues
+	queueLengths *queueLengthGauges
 
 	queuedEventsTotal      *prometheus.CounterVec
 	inQueueEventDuration   *prometheus.HistogramVec
 	processedEventDuration *prometheus.HistogramVec
+	oversizedSkippedTotal  *prometheus.CounterVec
+	invalidSkippedTotal    *prometheus.CounterVec
+	processingOutcomeTotal *prometheus.CounterVec
+	staleConflictTotal     *prometheus.CounterVec
+	watchExpiredTotal      *prometheus.CounterVec
+	informerRebuildTotal   *prometheus.CounterVec
+	degraded               *prometheus.GaugeVec
+	leaderElectionSkew     *prometheus.HistogramVec
 }
 
 // New returns a new Prometheus implementaiton for a metrics recorder.
@@ -58,14 +76,17 @@ func New(cfg Config) *Recorder {
 	cfg.defaults()
 
 	r := &Recorder{
-		reg: cfg.Registerer,
+		reg:          cfg.Registerer,
+		labelKeys:    cfg.ControllerLabels,
+		labelValues:  &controllerLabelValues{values: map[string][]string{}},
+		queueLengths: &queueLengthGauges{gauges: map[string]prometheus.Collector{}},
 
 		queuedEventsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: promNamespace,
 			Subsystem: promControllerSubsystem,
 			Name:      "queued_events_total",
 			Help:      "Total number of events queued.",
-		}, []string{"controller", "requeue"}),
+		}, append([]string{"controller", "requeue"}, cfg.ControllerLabels...)),
 
 		inQueueEventDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: promNamespace,
@@ -73,7 +94,7 @@ func New(cfg Config) *Recorder {
 			Name:      "event_in_queue_duration_seconds",
 			Help:      "The duration of an event in the queue.",
 			Buckets:   cfg.InQueueBuckets,
-		}, []string{"controller"}),
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
 
 		processedEventDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: promNamespace,
@@ -81,53 +102,254 @@ func New(cfg Config) *Recorder {
 			Name:      "processed_event_duration_seconds",
 			Help:      "The duration for an event to be processed.",
 			Buckets:   cfg.ProcessingBuckets,
-		}, []string{"controller", "success"}),
+		}, append([]string{"controller", "success"}, cfg.ControllerLabels...)),
+
+		oversizedSkippedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "oversized_skipped_events_total",
+			Help:      "Total number of events skipped because the object exceeds the max size.",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		invalidSkippedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "invalid_skipped_events_total",
+			Help:      "Total number of events skipped because the object has invalid object meta.",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		processingOutcomeTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "processing_outcomes_total",
+			Help:      "Total number of final outcomes of events processing.",
+		}, append([]string{"controller", "outcome"}, cfg.ControllerLabels...)),
+
+		staleConflictTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "stale_cache_conflicts_total",
+			Help:      "Total number of handling conflicts caused by stale cache objects.",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		watchExpiredTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "watch_expired_total",
+			Help:      "Total number of watches expired due to a too old resource version.",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		informerRebuildTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "informer_rebuilds_total",
+			Help:      "Total number of forced informer rebuilds.",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		degraded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: promNamespace,
+			Subsystem: promControllerSubsystem,
+			Name:      "degraded",
+			Help:      "Whether the controller is degraded because it can't reach the API server (1) or not (0).",
+		}, append([]string{"controller"}, cfg.ControllerLabels...)),
+
+		leaderElectionSkew: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: promNamespace,
+			Subsystem: promLeaderElectionSubsystem,
+			Name:      "clock_skew_seconds",
+			Help:      "The clock skew detected with the leader.",
+			Buckets:   []float64{1, 2, 5, 10, 30, 60, 300},
+		}, []string{"leader_election_id"}),
 	}
 
 	// Register metrics.
 	r.reg.MustRegister(
 		r.queuedEventsTotal,
 		r.inQueueEventDuration,
-		r.processedEventDuration)
+		r.processedEventDuration,
+		r.oversizedSkippedTotal,
+		r.invalidSkippedTotal,
+		r.processingOutcomeTotal,
+		r.staleConflictTotal,
+		r.watchExpiredTotal,
+		r.informerRebuildTotal,
+		r.degraded,
+		r.leaderElectionSkew)
 
 	return r
 }
 
 // IncResourceEventQueued satisfies controller.MetricsRecorder interface.
 func (r Recorder) IncResourceEventQueued(ctx context.Context, controller string, isRequeue bool) {
-	r.queuedEventsTotal.WithLabelValues(controller, strconv.FormatBool(isRequeue)).Inc()
+	r.queuedEventsTotal.WithLabelValues(r.labels(controller, strconv.FormatBool(isRequeue))...).Inc()
 }
 
 // ObserveResourceInQueueDuration satisfies controller.MetricsRecorder interface.
 func (r Recorder) ObserveResourceInQueueDuration(ctx context.Context, controller string, queuedAt time.Time) {
-	r.inQueueEventDuration.WithLabelValues(controller).
+	r.inQueueEventDuration.WithLabelValues(r.labels(controller)...).
 		Observe(time.Since(queuedAt).Seconds())
 }
 
 // ObserveResourceProcessingDuration satisfies controller.MetricsRecorder interface.
 func (r Recorder) ObserveResourceProcessingDuration(ctx context.Context, controller string, success bool, startProcessingAt time.Time) {
-	r.processedEventDuration.WithLabelValues(controller, strconv.FormatBool(success)).
+	r.processedEventDuration.WithLabelValues(r.labels(controller, strconv.FormatBool(success))...).
 		Observe(time.Since(startProcessingAt).Seconds())
 }
 
 // RegisterResourceQueueLengthFunc satisfies controller.MetricsRecorder interface.
 func (r Recorder) RegisterResourceQueueLengthFunc(controller string, f func(context.Context) int) error {
-	err := r.reg.Register(prometheus.NewGaugeFunc(
+	constLabels := prometheus.Labels{"controller": controller}
+	values := r.labelValues.get(controller, len(r.labelKeys))
+	for i, k := range r.labelKeys {
+		constLabels[k] = values[i]
+	}
+
+	gauge := prometheus.NewGaugeFunc(
 		prometheus.GaugeOpts{
 			Namespace:   promNamespace,
 			Subsystem:   promControllerSubsystem,
 			Name:        "event_queue_length",
 			Help:        "Length of the controller resource queue.",
-			ConstLabels: prometheus.Labels{"controller": controller},
+			ConstLabels: constLabels,
 		},
 		func() float64 { return float64(f(context.Background())) },
-	))
+	)
+	err := r.reg.Register(gauge)
 	if err != nil {
 		return fmt.Errorf("could not register ResourceQueueLengthFunc metrics: %w", err)
 	}
+	r.queueLengths.set(controller, gauge)
+
+	return nil
+}
+
+// UnregisterResourceQueueLengthFunc satisfies controller.ResourceQueueLengthFuncUnregisterer interface.
+func (r Recorder) UnregisterResourceQueueLengthFunc(controller string) {
+	if gauge, ok := r.queueLengths.pop(controller); ok {
+		r.reg.Unregister(gauge)
+	}
+}
+
+// RegisterControllerLabels satisfies controller.MetricsRecorder interface.
+func (r Recorder) RegisterControllerLabels(controller string, labels map[string]string) error {
+	allowed := map[string]bool{}
+	for _, k := range r.labelKeys {
+		allowed[k] = true
+	}
+
+	for k := range labels {
+		if !allowed[k] {
+			return fmt.Errorf("label %q is not allowed on the metrics", k)
+		}
+	}
+
+	values := make([]string, 0, len(r.labelKeys))
+	for _, k := range r.labelKeys {
+		values = append(values, labels[k])
+	}
+	r.labelValues.set(controller, values)
 
 	return nil
 }
 
+// IncResourceOversizedSkipped satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceOversizedSkipped(ctx context.Context, controller string) {
+	r.oversizedSkippedTotal.WithLabelValues(r.labels(controller)...).Inc()
+}
+
+// IncResourceInvalidSkipped satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceInvalidSkipped(ctx context.Context, controller string) {
+	r.invalidSkippedTotal.WithLabelValues(r.labels(controller)...).Inc()
+}
+
+// IncResourceProcessingOutcome satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceProcessingOutcome(ctx context.Context, controller string, outcome string) {
+	r.processingOutcomeTotal.WithLabelValues(r.labels(controller, outcome)...).Inc()
+}
+
+// IncResourceStaleCacheConflict satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceStaleCacheConflict(ctx context.Context, controller string) {
+	r.staleConflictTotal.WithLabelValues(r.labels(controller)...).Inc()
+}
+
+// IncResourceWatchExpired satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceWatchExpired(ctx context.Context, controller string) {
+	r.watchExpiredTotal.WithLabelValues(r.labels(controller)...).Inc()
+}
+
+// IncResourceInformerRebuild satisfies controller.MetricsRecorder interface.
+func (r Recorder) IncResourceInformerRebuild(ctx context.Context, controller string) {
+	r.informerRebuildTotal.WithLabelValues(r.labels(controller)...).Inc()
+}
+
+// SetResourceDegraded satisfies controller.MetricsRecorder interface.
+func (r Recorder) SetResourceDegraded(ctx context.Context, controller string, degraded bool) {
+	v := 0.0
+	if degraded {
+		v = 1
+	}
+	r.degraded.WithLabelValues(r.labels(controller)...).Set(v)
+}
+
+// ObserveLeaderElectionClockSkew satisfies leaderelection.MetricsRecorder interface.
+func (r Recorder) ObserveLeaderElectionClockSkew(ctx context.Context, leaderElectionID string, skew time.Duration) {
+	r.leaderElectionSkew.WithLabelValues(leaderElectionID).Observe(skew.Seconds())
+}
+
+// labels returns the label values of a metric for a controller, the controller labels
+// are always the last ones.
+func (r Recorder) labels(controller string, values ...string) []string {
+	ls := append([]string{controller}, values...)
+	return append(ls, r.labelValues.get(controller, len(r.labelKeys))...)
+}
+
+// controllerLabelValues stores the registered label values of each controller.
+type controllerLabelValues struct {
+	mu     sync.RWMutex
+	values map[string][]string
+}
+
+func (c *controllerLabelValues) set(controller string, values []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[controller] = values
+}
+
+// get returns the label values of a controller, if the controller doesn't have registered
+// labels it will return empty values.
+func (c *controllerLabelValues) get(controller string, n int) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if values, ok := c.values[controller]; ok {
+		return values
+	}
+	return make([]string, n)
+}
+
+// queueLengthGauges stores the registered queue length gauge of each controller, so they
+// can be unregistered once the controller stops.
+type queueLengthGauges struct {
+	mu     sync.Mutex
+	gauges map[string]prometheus.Collector
+}
+
+func (q *queueLengthGauges) set(controller string, gauge prometheus.Collector) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.gauges[controller] = gauge
+}
+
+func (q *queueLengthGauges) pop(controller string) (prometheus.Collector, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	gauge, ok := q.gauges[controller]
+	delete(q.gauges, controller)
+	return gauge, ok
+}
+
 // Check interfaces implementation.
-var _ controller.MetricsRecorder = &Recorder{}
+var (
+	_ controller.MetricsRecorder                     = &Recorder{}
+	_ controller.ResourceQueueLengthFuncUnregisterer = &Recorder{}
+	_ leaderelection.MetricsRecorder                 = &Recorder{}
+)
@@ -214,6 +214,147 @@ func TestPrometheusRecorder(t *testing.T) {
 				`kooper_controller_event_queue_length{controller="ctrl3"} 242`,
 			},
 		},
+
+		"Registering again the resource queue length function of a stopped controller should replace the previous one.": {
+			cfg: kooperprometheus.Config{},
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				_ = r.RegisterResourceQueueLengthFunc("ctrl1", func(_ context.Context) int { return 42 })
+				r.UnregisterResourceQueueLengthFunc("ctrl1")
+				_ = r.RegisterResourceQueueLengthFunc("ctrl1", func(_ context.Context) int { return 7 })
+			},
+			expMetrics: []string{
+				`kooper_controller_event_queue_length{controller="ctrl1"} 7`,
+			},
+		},
+
+		"Registering controller labels should add the labels to the controller metrics.": {
+			cfg: kooperprometheus.Config{
+				ControllerLabels: []string{"team"},
+			},
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				_ = r.RegisterControllerLabels("ctrl1", map[string]string{"team": "platform"})
+				r.IncResourceEventQueued(ctx, "ctrl1", false)
+				r.IncResourceEventQueued(ctx, "ctrl2", false)
+				_ = r.RegisterResourceQueueLengthFunc("ctrl1", func(_ context.Context) int { return 42 })
+			},
+			expMetrics: []string{
+				`kooper_controller_queued_events_total{controller="ctrl1",requeue="false",team="platform"} 1`,
+				`kooper_controller_queued_events_total{controller="ctrl2",requeue="false",team=""} 1`,
+				`kooper_controller_event_queue_length{controller="ctrl1",team="platform"} 42`,
+			},
+		},
+
+		"Observing the leader election clock skew should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.ObserveLeaderElectionClockSkew(ctx, "default/le1", 3*time.Second)
+				r.ObserveLeaderElectionClockSkew(ctx, "default/le1", 45*time.Second)
+			},
+			expMetrics: []string{
+				`# HELP kooper_leader_election_clock_skew_seconds The clock skew detected with the leader.`,
+				`# TYPE kooper_leader_election_clock_skew_seconds histogram`,
+				`kooper_leader_election_clock_skew_seconds_bucket{leader_election_id="default/le1",le="2"} 0`,
+				`kooper_leader_election_clock_skew_seconds_bucket{leader_election_id="default/le1",le="5"} 1`,
+				`kooper_leader_election_clock_skew_seconds_bucket{leader_election_id="default/le1",le="60"} 2`,
+				`kooper_leader_election_clock_skew_seconds_count{leader_election_id="default/le1"} 2`,
+			},
+		},
+
+		"Incrementing the oversized skipped events should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceOversizedSkipped(ctx, "ctrl1")
+				r.IncResourceOversizedSkipped(ctx, "ctrl1")
+				r.IncResourceOversizedSkipped(ctx, "ctrl2")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_oversized_skipped_events_total Total number of events skipped because the object exceeds the max size.`,
+				`# TYPE kooper_controller_oversized_skipped_events_total counter`,
+				`kooper_controller_oversized_skipped_events_total{controller="ctrl1"} 2`,
+				`kooper_controller_oversized_skipped_events_total{controller="ctrl2"} 1`,
+			},
+		},
+
+		"Incrementing the invalid skipped events should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceInvalidSkipped(ctx, "ctrl1")
+				r.IncResourceInvalidSkipped(ctx, "ctrl1")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_invalid_skipped_events_total Total number of events skipped because the object has invalid object meta.`,
+				`# TYPE kooper_controller_invalid_skipped_events_total counter`,
+				`kooper_controller_invalid_skipped_events_total{controller="ctrl1"} 2`,
+			},
+		},
+
+		"Incrementing the processing outcomes should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceProcessingOutcome(ctx, "ctrl1", "first-try-success")
+				r.IncResourceProcessingOutcome(ctx, "ctrl1", "first-try-success")
+				r.IncResourceProcessingOutcome(ctx, "ctrl1", "gaveup")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_processing_outcomes_total Total number of final outcomes of events processing.`,
+				`# TYPE kooper_controller_processing_outcomes_total counter`,
+				`kooper_controller_processing_outcomes_total{controller="ctrl1",outcome="first-try-success"} 2`,
+				`kooper_controller_processing_outcomes_total{controller="ctrl1",outcome="gaveup"} 1`,
+			},
+		},
+
+		"Incrementing the stale cache conflicts should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceStaleCacheConflict(ctx, "ctrl1")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_stale_cache_conflicts_total Total number of handling conflicts caused by stale cache objects.`,
+				`# TYPE kooper_controller_stale_cache_conflicts_total counter`,
+				`kooper_controller_stale_cache_conflicts_total{controller="ctrl1"} 1`,
+			},
+		},
+
+		"Incrementing the expired watches should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceWatchExpired(ctx, "ctrl1")
+				r.IncResourceWatchExpired(ctx, "ctrl1")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_watch_expired_total Total number of watches expired due to a too old resource version.`,
+				`# TYPE kooper_controller_watch_expired_total counter`,
+				`kooper_controller_watch_expired_total{controller="ctrl1"} 2`,
+			},
+		},
+
+		"Incrementing the informer rebuilds should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.IncResourceInformerRebuild(ctx, "ctrl1")
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_informer_rebuilds_total Total number of forced informer rebuilds.`,
+				`# TYPE kooper_controller_informer_rebuilds_total counter`,
+				`kooper_controller_informer_rebuilds_total{controller="ctrl1"} 1`,
+			},
+		},
+
+		"Setting the degraded state should record the metrics.": {
+			addMetrics: func(r *kooperprometheus.Recorder) {
+				ctx := context.TODO()
+				r.SetResourceDegraded(ctx, "ctrl1", true)
+				r.SetResourceDegraded(ctx, "ctrl2", true)
+				r.SetResourceDegraded(ctx, "ctrl2", false)
+			},
+			expMetrics: []string{
+				`# HELP kooper_controller_degraded Whether the controller is degraded because it can't reach the API server (1) or not (0).`,
+				`# TYPE kooper_controller_degraded gauge`,
+				`kooper_controller_degraded{controller="ctrl1"} 1`,
+				`kooper_controller_degraded{controller="ctrl2"} 0`,
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -243,3 +384,13 @@ func TestPrometheusRecorder(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusRecorderDuplicateQueueLengthFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	r := kooperprometheus.New(kooperprometheus.Config{Registerer: prometheus.NewRegistry()})
+
+	// A live controller with the same name should not be replaced.
+	assert.NoError(r.RegisterResourceQueueLengthFunc("ctrl1", func(_ context.Context) int { return 42 }))
+	assert.Error(r.RegisterResourceQueueLengthFunc("ctrl1", func(_ context.Context) int { return 7 }))
+}
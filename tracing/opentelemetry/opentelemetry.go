@@ -0,0 +1,127 @@
+// Package opentelemetry has a controller tracer that traces the controller handlings
+// with OpenTelemetry (https://opentelemetry.io).
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/adevjoe/kooper/v2/controller"
+)
+
+// tracerName is the name of the tracer used to create the controller spans.
+const tracerName = "github.com/adevjoe/kooper/v2/controller"
+
+// Config is the Tracer Config.
+type Config struct {
+	// TracerProvider provides the tracer of the spans.
+	// By default will use the OpenTelemetry global provider.
+	TracerProvider trace.TracerProvider
+}
+
+func (c *Config) defaults() {
+	if c.TracerProvider == nil {
+		c.TracerProvider = otel.GetTracerProvider()
+	}
+}
+
+// Tracer implements the controller tracing with OpenTelemetry.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New returns a new OpenTelemetry implementation for a controller tracer.
+func New(cfg Config) *Tracer {
+	cfg.defaults()
+
+	return &Tracer{
+		tracer: cfg.TracerProvider.Tracer(tracerName),
+	}
+}
+
+// StartSpan satisfies controller.Tracer interface.
+func (t *Tracer) StartSpan(ctx context.Context, name string, opts controller.SpanOptions) (context.Context, controller.Span) {
+	attrs := make([]attribute.KeyValue, 0, len(opts.Attributes))
+	for _, a := range opts.Attributes {
+		attrs = append(attrs, attributeKeyValue(a))
+	}
+	links := make([]trace.Link, 0, len(opts.Links))
+	for _, l := range opts.Links {
+		if sc, ok := l.(trace.SpanContext); ok {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+
+	startOpts := []trace.SpanStartOption{trace.WithAttributes(attrs...), trace.WithLinks(links...)}
+	if !opts.StartTime.IsZero() {
+		startOpts = append(startOpts, trace.WithTimestamp(opts.StartTime))
+	}
+
+	ctx, s := t.tracer.Start(ctx, name, startOpts...)
+	return ctx, span{span: s}
+}
+
+// TraceID satisfies controller.Tracer interface.
+func (t *Tracer) TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// ContextWithBaggage satisfies controller.Tracer interface.
+func (t *Tracer) ContextWithBaggage(ctx context.Context, value string) (context.Context, error) {
+	objBaggage, err := baggage.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse baggage: %w", err)
+	}
+
+	b := baggage.FromContext(ctx)
+	for _, member := range objBaggage.Members() {
+		if b, err = b.SetMember(member); err != nil {
+			return nil, fmt.Errorf("could not set baggage member: %w", err)
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+func attributeKeyValue(a controller.SpanAttribute) attribute.KeyValue {
+	switch v := a.Value.(type) {
+	case string:
+		return attribute.String(a.Key, v)
+	case bool:
+		return attribute.Bool(a.Key, v)
+	case int:
+		return attribute.Int(a.Key, v)
+	case int64:
+		return attribute.Int64(a.Key, v)
+	case float64:
+		return attribute.Float64(a.Key, v)
+	default:
+		return attribute.String(a.Key, fmt.Sprint(v))
+	}
+}
+
+// span is the controller span of an OpenTelemetry span, the links are the span contexts.
+type span struct {
+	span trace.Span
+}
+
+func (s span) End() { s.span.End() }
+
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) Link() controller.SpanLink { return s.span.SpanContext() }
+
+var _ controller.Tracer = &Tracer{}
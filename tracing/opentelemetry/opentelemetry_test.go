@@ -0,0 +1,110 @@
+package opentelemetry_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/adevjoe/kooper/v2/controller"
+	"github.com/adevjoe/kooper/v2/tracing/opentelemetry"
+)
+
+func TestTracerSpans(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sr := tracetest.NewSpanRecorder()
+	tr := opentelemetry.New(opentelemetry.Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)),
+	})
+
+	// A failed span.
+	ctx, span1 := tr.StartSpan(context.TODO(), "span1", controller.SpanOptions{
+		Attributes: []controller.SpanAttribute{
+			{Key: "test.string", Value: "value"},
+			{Key: "test.bool", Value: true},
+		},
+	})
+	assert.NotEmpty(tr.TraceID(ctx))
+	span1.RecordError(fmt.Errorf("wanted error"))
+	span1.End()
+
+	// A span linked to the first one, started in the past.
+	start := time.Now().Add(-1 * time.Minute)
+	_, span2 := tr.StartSpan(context.TODO(), "span2", controller.SpanOptions{
+		Links:     []controller.SpanLink{span1.Link()},
+		StartTime: start,
+	})
+	span2.End()
+
+	spans := sr.Ended()
+	require.Len(spans, 2)
+	assert.Equal("span1", spans[0].Name())
+	assert.Equal([]attribute.KeyValue{attribute.String("test.string", "value"), attribute.Bool("test.bool", true)}, spans[0].Attributes())
+	assert.Equal(codes.Error, spans[0].Status().Code)
+
+	assert.Equal("span2", spans[1].Name())
+	require.Len(spans[1].Links(), 1)
+	assert.Equal(spans[0].SpanContext(), spans[1].Links()[0].SpanContext)
+	assert.Equal(start, spans[1].StartTime())
+}
+
+func TestTracerTraceIDWithoutSpan(t *testing.T) {
+	tr := opentelemetry.New(opentelemetry.Config{})
+	assert.Empty(t, tr.TraceID(context.TODO()))
+}
+
+func TestTracerContextWithBaggage(t *testing.T) {
+	tests := map[string]struct {
+		baggage    string
+		expMembers map[string]string
+		expErr     bool
+	}{
+		"A valid baggage should be added to the context baggage.": {
+			baggage:    "tenant=team-a,request=1234",
+			expMembers: map[string]string{"previous": "true", "tenant": "team-a", "request": "1234"},
+		},
+
+		"An invalid baggage should fail.": {
+			baggage: "tenant=team-a,=,",
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := baggage.NewMember("previous", "true")
+			require.NoError(err)
+			b, err := baggage.New(m)
+			require.NoError(err)
+			ctx := baggage.ContextWithBaggage(context.TODO(), b)
+
+			tr := opentelemetry.New(opentelemetry.Config{})
+			ctx, err = tr.ContextWithBaggage(ctx, test.baggage)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			got := map[string]string{}
+			for _, m := range baggage.FromContext(ctx).Members() {
+				got[m.Key()] = m.Value()
+			}
+			assert.Equal(test.expMembers, got)
+		})
+	}
+}